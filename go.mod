@@ -7,4 +7,5 @@ require (
 	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.8.0
 	github.com/markbates/pkger v0.17.1
+	gopkg.in/yaml.v2 v2.2.7
 )
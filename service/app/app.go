@@ -1,8 +1,12 @@
 package app
 
 import (
+	"context"
 	"html/template"
 	"imgnheap/service/models"
+	"io"
+	"os"
+	"time"
 )
 
 // Container defines our app's container interface
@@ -10,24 +14,102 @@ type Container interface {
 	TemplatesInjector
 	KeyValStoreInjector
 	FileSystemInjector
+	TimestampPatternRegistryInjector
+	JobRunnerInjector
+	ThumbnailCacheInjector
+	RootsInjector
+	AccessTokenInjector
+	ConfigInjector
 }
 
 type TemplatesInjector interface{ Templates() *template.Template }
 type KeyValStoreInjector interface{ KeyValStore() KeyValStore }
 type FileSystemInjector interface{ FileSystem() FileSystem }
+type TimestampPatternRegistryInjector interface {
+	TimestampPatternRegistry() TimestampPatternRegistry
+}
+type JobRunnerInjector interface{ JobRunner() JobRunner }
+type ThumbnailCacheInjector interface{ ThumbnailCache() ThumbnailCache }
+type RootsInjector interface{ Roots() []string }
+type AccessTokenInjector interface{ AccessToken() string }
+type ConfigInjector interface{ Config() models.Config }
 
 // KeyValStore defines operations for transacting with key/value storage
 type KeyValStore interface {
-	Read(key string) (interface{}, error)
-	Write(key string, val interface{}) error
+	Read(key string) ([]byte, error)
+	Write(key string, val []byte) error
+	WriteWithTTL(key string, val []byte, ttl time.Duration) error
+	CompareAndSwap(key string, oldVal []byte, newVal []byte, ttl time.Duration) (bool, error)
+	Delete(key string) error
 }
 
-// FileSystem defines operations for transacting with a file system
+// FileSystem defines operations for transacting with a file system, whose copies and moves return the hex-encoded
+// SHA-256 checksum of the contents that they wrote
 type FileSystem interface {
 	IsDirectory(path string) bool
 	GetFilesInDirectory(path string) ([]models.File, error)
+	GetFilesInDirectoryTree(path string, maxDepth int, excludes []string) ([]models.File, error)
 	GetDirectoriesInDirectory(path string) ([]models.Directory, error)
 	GetContents(file models.File) ([]byte, error)
-	Copy(file models.File, dest string) error
-	Move(file models.File, dest string) error
+	Open(file models.File) (ReadSeekCloser, error)
+	Stat(file models.File) (os.FileInfo, error)
+	Copy(file models.File, dest models.File) (string, error)
+	Move(file models.File, dest models.File) (string, error)
+	Remove(file models.File) error
+}
+
+// FileOps defines the low-level operations with which a file system writes files, so that failures can be simulated
+type FileOps interface {
+	CreateTemp(dir string, pattern string) (WritableFile, error)
+	Rename(oldPath string, newPath string) error
+	Remove(path string) error
+}
+
+// WritableFile defines a file that is being written
+type WritableFile interface {
+	io.Writer
+	Name() string
+	Chmod(mode os.FileMode) error
+	Sync() error
+	Close() error
+}
+
+// Journal defines operations for recording file operations so that they can be reversed
+type Journal interface {
+	Append(entry models.JournalEntry) error
+	Entries() ([]models.JournalEntry, error)
+}
+
+// ThumbnailCache defines operations for storing and retrieving generated thumbnails
+type ThumbnailCache interface {
+	Get(key string) (data []byte, ok bool, err error)
+	Put(key string, data []byte) error
+}
+
+// TimestampPatternRegistry defines operations for matching filenames against registered timestamp patterns
+type TimestampPatternRegistry interface {
+	Match(fileName string) (ts time.Time, patternName string, ok bool)
+}
+
+// JobRunner defines operations for running long-running operations in the background and tracking their progress
+type JobRunner interface {
+	Start(job models.Job, fn JobFunc) (models.Job, error)
+	Get(id string) (models.Job, error)
+	Cancel(id string) error
+	Subscribe(id string) (updates <-chan models.Job, unsubscribe func(), err error)
+}
+
+// JobFunc defines a long-running operation, which must report its progress and stop when the provided context is done
+type JobFunc func(ctx context.Context, progress JobProgress) error
+
+// JobProgress defines operations for reporting the progress of a long-running operation
+type JobProgress interface {
+	Processing(item string)
+	Processed(item string, outcome string, err error)
+}
+
+// ReadSeekCloser defines a readable, seekable and closeable source of data
+type ReadSeekCloser interface {
+	io.ReadSeeker
+	io.Closer
 }
@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// apiPathPrefix represents the path beneath which every route of the JSON API is served
+const apiPathPrefix = "/api/v1"
+
+// apiSession represents a session within the JSON API
+type apiSession struct {
+	Token     string   `json:"token"`
+	BaseDir   string   `json:"base_dir"`
+	SubDir    string   `json:"sub_dir"`
+	Recursive bool     `json:"recursive"`
+	MaxDepth  int      `json:"max_depth"`
+	Excludes  []string `json:"excludes"`
+}
+
+// apiFile represents a file and its metadata within the JSON API
+type apiFile struct {
+	Path             string     `json:"path"`
+	Name             string     `json:"name"`
+	Size             int64      `json:"size"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Timestamp        *time.Time `json:"timestamp,omitempty"`
+	TimestampSource  string     `json:"timestamp_source,omitempty"`
+	TimestampPattern string     `json:"timestamp_pattern,omitempty"`
+	CameraModel      string     `json:"camera_model,omitempty"`
+}
+
+// apiTag represents a tag and the number of image files within it
+type apiTag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// apiProcessResult represents the outcome of processing a single file within the JSON API
+type apiProcessResult struct {
+	File        string `json:"file"`
+	Destination string `json:"destination"`
+	Outcome     string `json:"outcome"`
+}
+
+// apiPlan represents a plan within the JSON API
+type apiPlan struct {
+	ID              string        `json:"id"`
+	Layout          string        `json:"layout"`
+	CollisionPolicy string        `json:"collision_policy"`
+	Items           []apiPlanItem `json:"items"`
+	Duplicates      []string      `json:"duplicates"`
+	Executed        bool          `json:"executed"`
+}
+
+// apiPlanItem represents a single file operation of a plan within the JSON API
+type apiPlanItem struct {
+	File        apiFile `json:"file"`
+	Destination string  `json:"destination"`
+}
+
+// apiJob represents a job within the JSON API
+type apiJob struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	Total      int            `json:"total"`
+	Done       int            `json:"done"`
+	Failed     int            `json:"failed"`
+	Current    string         `json:"current,omitempty"`
+	Outcomes   map[string]int `json:"outcomes"`
+	Notices    []string       `json:"notices"`
+	Errors     []string       `json:"errors"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// apiError represents the body of an error response within the JSON API
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	} `json:"error"`
+}
+
+// registerAPIRoutes attaches the routes of the JSON API to the provided router
+func registerAPIRoutes(r *mux.Router, c app.Container) {
+	api := r.PathPrefix(apiPathPrefix).Subrouter()
+	api.HandleFunc("/sessions", apiNewSessionHandler(c)).Methods(http.MethodPost)
+
+	// routes that require session token
+	s := api.PathPrefix("").Subrouter()
+	s.Use(addAPISessionToRequestContext(c))
+	s.HandleFunc("/session", apiSessionHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/session", apiDeleteSessionHandler(c)).Methods(http.MethodDelete)
+	s.HandleFunc("/files", apiFilesHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/tags", apiTagsHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/tags/{tag}/files", apiTagFileHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/plans/by-date", apiPlanByDateHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/plans/{id}/execute", apiExecutePlanHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/jobs/{id}", apiJobHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/jobs/{id}/cancel", apiCancelJobHandler(c)).Methods(http.MethodPost)
+
+	// any other route beneath the prefix
+	api.PathPrefix("").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, domain.NotFoundError{Err: fmt.Errorf("no route found for %s %s", r.Method, r.URL.Path)})
+	})
+}
+
+func apiNewSessionHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Directory string   `json:"directory"`
+			Recursive bool     `json:"recursive"`
+			MaxDepth  int      `json:"max_depth"`
+			Excludes  []string `json:"excludes"`
+			Resume    string   `json:"resume"`
+		}
+		if err := decodeJSONBody(r, &body); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if body.Directory == "" {
+			writeAPIError(w, missingFieldError("directory"))
+			return
+		}
+
+		scan, err := domain.ParseScanOptions(body.Recursive, body.MaxDepth, strings.Join(body.Excludes, ","))
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+		// save new or resumed session
+		var sess *models.Session
+		if body.Resume == "" {
+			sess, err = sessAgent.NewSessionFromDirectoryAndTimestamp(body.Directory, time.Now(), scan)
+		} else {
+			sess, err = sessAgent.ResumeSessionFromDirectory(body.Directory, body.Resume, scan)
+		}
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		// clear up after any copies of previous sessions that were cut short
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		if _, err := fsAgent.RemoveStaleTempFilesFromSessionRuns(sess.BaseDir); err != nil {
+			log.Println(err)
+		}
+
+		writeJSON(w, http.StatusCreated, newAPISession(sess))
+	}
+}
+
+func apiSessionHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPISession(sess))
+	}
+}
+
+func apiDeleteSessionHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		if err := sessAgent.DeleteSession(getSessionFromRequest(r)); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func apiFilesHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+
+		files, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		resp := make([]apiFile, 0, len(files))
+		for _, file := range files {
+			resp = append(resp, newAPIFile(file, fsAgent.ResolveMetadata(file), sess))
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func apiTagsHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		resp := make([]apiTag, 0)
+
+		tagsDir := sess.FullDir(domain.SubDirByTag)
+		if c.FileSystem().IsDirectory(tagsDir) {
+			fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+
+			dirs, err := fsAgent.GetDirectoriesWithFileCountByExtension(tagsDir, domain.ImgFileExts...)
+			if err != nil {
+				writeAPIError(w, err)
+				return
+			}
+			for _, dir := range dirs {
+				resp = append(resp, apiTag{Name: dir.Name, Count: dir.FileCount})
+			}
+		}
+
+		sort.Slice(resp, func(i, j int) bool {
+			return resp[i].Name < resp[j].Name
+		})
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func apiTagFileHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		var tag string
+		if err := routeParam(&tag, "tag", r); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if err := domain.ValidateTagName(tag); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		var body struct {
+			File      string `json:"file"`
+			Collision string `json:"collision"`
+		}
+		if err := decodeJSONBody(r, &body); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if body.File == "" {
+			writeAPIError(w, missingFieldError("file"))
+			return
+		}
+
+		if body.Collision == "" {
+			body.Collision = c.Config().CollisionPolicy
+		}
+
+		policy, err := domain.ParseCollisionPolicy(body.Collision)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		file, err := domain.FileFromRelativePath(sess.BaseDir, body.File)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: c,
+			Journal:                 domain.NewSessionJournal(sess),
+			CollisionPolicy:         policy,
+		}
+
+		result, err := fsAgent.ProcessFileByMove(file, domain.GetDestinationDirByTag(sess, tag))
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, apiProcessResult{
+			File:        result.File.RelativePath(sess.BaseDir),
+			Destination: result.Destination.RelativePath(sess.BaseDir),
+			Outcome:     result.Outcome,
+		})
+	}
+}
+
+func apiPlanByDateHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		var body struct {
+			Layout         string `json:"layout"`
+			Collision      string `json:"collision"`
+			SkipDuplicates bool   `json:"skip_duplicates"`
+		}
+		if err := decodeJSONBody(r, &body); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if body.Layout == "" {
+			body.Layout = c.Config().Layout
+		}
+
+		layout, err := domain.ParseDirLayout(body.Layout)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		if body.Collision == "" {
+			body.Collision = c.Config().CollisionPolicy
+		}
+
+		policy, err := domain.ParseCollisionPolicy(body.Collision)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, CollisionPolicy: policy}
+		planAgent := domain.PlanAgent{PlanAgentInjector: c}
+
+		plan, err := fsAgent.PlanByDate(sess, layout, body.SkipDuplicates)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		// save plan so that exactly this plan is executed
+		if err := planAgent.SavePlan(plan); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, newAPIPlan(plan, sess))
+	}
+}
+
+func apiExecutePlanHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		var planID string
+		if err := routeParam(&planID, "id", r); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+		planAgent := domain.PlanAgent{PlanAgentInjector: c}
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		// claim plan so that it cannot be executed twice
+		plan, err := planAgent.ClaimPlanForSession(planID, sess)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		// execute plan in the background
+		job, err := jobAgent.StartJobForSession(sess, "Processing By Date", len(plan.Items), func(ctx context.Context, progress app.JobProgress) error {
+			return fsAgent.ExecutePlan(ctx, plan, progress)
+		})
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("%s/jobs/%s", apiPathPrefix, job.ID))
+		writeJSON(w, http.StatusAccepted, newAPIJob(job))
+	}
+}
+
+func apiJobHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		var jobID string
+		if err := routeParam(&jobID, "id", r); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		job, err := jobAgent.GetJobForSession(jobID, sess)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newAPIJob(job))
+	}
+}
+
+func apiCancelJobHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			writeAPIError(w, errors.New("session is nil"))
+			return
+		}
+
+		var jobID string
+		if err := routeParam(&jobID, "id", r); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		if err := jobAgent.CancelJobForSession(jobID, sess); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		job, err := jobAgent.GetJobForSession(jobID, sess)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newAPIJob(job))
+	}
+}
+
+// decodeJSONBody decodes the JSON body of the provided request into the provided recipient, returning an
+// UnsupportedMediaTypeError if it is not declared as JSON, or a BadRequestError if it can't be decoded.
+// An empty body leaves the recipient as it is
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+
+	// only json can be posted, which a cross-site form cannot do without the browser asking permission first
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return domain.UnsupportedMediaTypeError{Err: fmt.Errorf("content type must be application/json: %s", r.Header.Get("Content-Type"))}
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return domain.BadRequestError{Err: fmt.Errorf("invalid json body: %s", err)}
+	}
+
+	return nil
+}
+
+// writeJSON writes the provided value as a JSON response with the provided status code to the provided writer
+func writeJSON(w http.ResponseWriter, code int, val interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(val); err != nil {
+		log.Println(err)
+	}
+}
+
+// writeAPIError writes the provided error as a JSON response to the provided writer
+func writeAPIError(w http.ResponseWriter, err error) {
+	code := getResponseStatusFromError(err)
+
+	var resp apiError
+	resp.Error.Code = code
+	resp.Error.Message = http.StatusText(code)
+	resp.Error.Detail = err.Error()
+
+	writeJSON(w, code, resp)
+}
+
+// newAPISession returns the JSON API representation of the provided session
+func newAPISession(sess *models.Session) apiSession {
+	excludes := sess.Scan.Excludes
+	if excludes == nil {
+		excludes = []string{}
+	}
+
+	return apiSession{
+		Token:     sess.Token,
+		BaseDir:   sess.BaseDir,
+		SubDir:    sess.SubDir,
+		Recursive: sess.Scan.Recursive,
+		MaxDepth:  sess.Scan.MaxDepth,
+		Excludes:  excludes,
+	}
+}
+
+// newAPIFile returns the JSON API representation of the provided file within the provided session, and its metadata
+func newAPIFile(file models.File, meta models.FileMetadata, sess *models.Session) apiFile {
+	resp := apiFile{
+		Path:             file.RelativePath(sess.BaseDir),
+		Name:             file.NameWithExt(),
+		Size:             file.Size,
+		CreatedAt:        file.CreatedAt,
+		TimestampSource:  meta.TimestampSource,
+		TimestampPattern: meta.TimestampPattern,
+		CameraModel:      meta.CameraModel,
+	}
+	if !meta.Timestamp.IsZero() {
+		resp.Timestamp = &meta.Timestamp
+	}
+
+	return resp
+}
+
+// newAPIPlan returns the JSON API representation of the provided plan for the provided session
+func newAPIPlan(plan *models.Plan, sess *models.Session) apiPlan {
+	resp := apiPlan{
+		ID:              plan.ID,
+		Layout:          plan.Layout,
+		CollisionPolicy: plan.CollisionPolicy,
+		Items:           make([]apiPlanItem, 0, len(plan.Items)),
+		Duplicates:      make([]string, 0, len(plan.Duplicates)),
+		Executed:        plan.Executed,
+	}
+
+	for _, item := range plan.Items {
+		resp.Items = append(resp.Items, apiPlanItem{
+			File:        newAPIFile(item.File, item.Metadata, sess),
+			Destination: models.NewFile(item.File.Name, item.File.Ext, item.DestDir, nil).RelativePath(sess.BaseDir),
+		})
+	}
+	for _, file := range plan.Duplicates {
+		resp.Duplicates = append(resp.Duplicates, file.RelativePath(sess.BaseDir))
+	}
+
+	return resp
+}
+
+// newAPIJob returns the JSON API representation of the provided job
+func newAPIJob(job models.Job) apiJob {
+	resp := apiJob{
+		ID:        job.ID,
+		Name:      job.Name,
+		Status:    job.Status,
+		Total:     job.Total,
+		Done:      job.Done,
+		Failed:    job.Failed,
+		Current:   job.Current,
+		Outcomes:  job.Outcomes,
+		Notices:   job.Notices,
+		Errors:    job.Errors,
+		StartedAt: job.StartedAt,
+	}
+	if resp.Outcomes == nil {
+		resp.Outcomes = map[string]int{}
+	}
+	if resp.Notices == nil {
+		resp.Notices = []string{}
+	}
+	if resp.Errors == nil {
+		resp.Errors = []string{}
+	}
+	if job.IsFinished() {
+		resp.FinishedAt = &job.FinishedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,247 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apiErrorBody represents the body of an error response from the JSON API
+type apiErrorBody struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	} `json:"error"`
+}
+
+// newAPIRequest returns a new request of the JSON API, authorised by the provided bearer token (if any)
+func newAPIRequest(method string, target string, body string, token string) *http.Request {
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, target, nil)
+	} else {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+// decodeResponse decodes the JSON body of the provided response into the provided recipient
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("expected application/json, got %s", contentType)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectAPIError fails the provided test unless the provided response is an error of the provided status code
+func expectAPIError(t *testing.T, rec *httptest.ResponseRecorder, code int) {
+	if rec.Code != code {
+		t.Fatalf("expected status %d, got %d: %s", code, rec.Code, rec.Body)
+	}
+
+	var body apiErrorBody
+	decodeResponse(t, rec, &body)
+	if body.Error.Code != code || body.Error.Message != http.StatusText(code) || body.Error.Detail == "" {
+		t.Fatalf("expected error of code %d, got %+v", code, body.Error)
+	}
+}
+
+// newAPISession creates a session for the provided directory using the JSON API, and returns its token and sub-directory
+func newAPISession(t *testing.T, c testContainer, dir string) (string, string) {
+	rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/sessions", `{"directory": "`+dir+`"}`, ""))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body)
+	}
+
+	var sess struct {
+		Token  string `json:"token"`
+		SubDir string `json:"sub_dir"`
+	}
+	decodeResponse(t, rec, &sess)
+	if sess.Token == "" {
+		t.Fatal("expected token, got none")
+	}
+
+	return sess.Token, sess.SubDir
+}
+
+func TestAPISessions(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+
+	t.Run("creating a session with a json body must return its token", func(t *testing.T) {
+		newAPISession(t, c, dir)
+	})
+
+	t.Run("creating a session with a body that is not json must return an unsupported media type error", func(t *testing.T) {
+		testCases := []string{"text/plain", "application/x-www-form-urlencoded", "multipart/form-data; boundary=x", ""}
+
+		for _, contentType := range testCases {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/sessions", strings.NewReader(`{"directory": "`+dir+`"}`))
+			if contentType != "" {
+				r.Header.Set("Content-Type", contentType)
+			}
+
+			expectAPIError(t, serve(c, r), http.StatusUnsupportedMediaType)
+		}
+	})
+
+	t.Run("creating a session with invalid json must return a bad request error", func(t *testing.T) {
+		testCases := []string{`{"directory":`, `{"dir": "/"}`}
+
+		for _, body := range testCases {
+			expectAPIError(t, serve(c, newAPIRequest(http.MethodPost, "/api/v1/sessions", body, "")), http.StatusBadRequest)
+		}
+	})
+
+	t.Run("creating a session for a directory outside the roots must return a validation error", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/sessions", `{"directory": "`+path.Dir(dir)+`"}`, ""))
+		expectAPIError(t, rec, http.StatusUnprocessableEntity)
+	})
+}
+
+func TestAPIAuthorization(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	token, _ := newAPISession(t, c, dir)
+
+	t.Run("requesting the session with its bearer token must return the session", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodGet, "/api/v1/session", "", token))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+
+		var sess struct {
+			Token   string `json:"token"`
+			BaseDir string `json:"base_dir"`
+		}
+		decodeResponse(t, rec, &sess)
+		if sess.Token != token || sess.BaseDir != dir {
+			t.Fatalf("expected session %s of %s, got %+v", token, dir, sess)
+		}
+	})
+
+	t.Run("requesting the api without a valid bearer token must return a forbidden error", func(t *testing.T) {
+		missing := newAPIRequest(http.MethodGet, "/api/v1/files", "", "")
+		invalid := newAPIRequest(http.MethodGet, "/api/v1/files", "", "invalid")
+		wrongScheme := newAPIRequest(http.MethodGet, "/api/v1/files", "", "")
+		wrongScheme.Header.Set("Authorization", "Basic "+token)
+
+		for _, r := range []*http.Request{missing, invalid, wrongScheme} {
+			expectAPIError(t, serve(c, r), http.StatusForbidden)
+		}
+	})
+
+	t.Run("requesting an unknown route of the api must return a not found error", func(t *testing.T) {
+		expectAPIError(t, serve(c, newAPIRequest(http.MethodGet, "/api/v1/unknown", "", token)), http.StatusNotFound)
+	})
+
+	t.Run("requesting the api after ending the session must return a forbidden error", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodDelete, "/api/v1/session", "", token))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body)
+		}
+
+		expectAPIError(t, serve(c, newAPIRequest(http.MethodGet, "/api/v1/session", "", token)), http.StatusForbidden)
+	})
+}
+
+func TestAPIPlans(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	writeFiles(t, dir, map[string]string{"20190102_101010.jpg": "image"})
+	token, subDir := newAPISession(t, c, dir)
+
+	var plan struct {
+		ID    string `json:"id"`
+		Items []struct {
+			File struct {
+				Path string `json:"path"`
+			} `json:"file"`
+			Destination string `json:"destination"`
+		} `json:"items"`
+	}
+
+	t.Run("planning by date must return the relative destination of each file", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/plans/by-date", `{"layout": "{year}"}`, token))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body)
+		}
+
+		decodeResponse(t, rec, &plan)
+		if len(plan.Items) != 1 {
+			t.Fatalf("expected 1 item, got %d", len(plan.Items))
+		}
+
+		expected := path.Join(subDir, "by-date", "2019", "20190102_101010.jpg")
+		if plan.Items[0].File.Path != "20190102_101010.jpg" || plan.Items[0].Destination != expected {
+			t.Fatalf("expected 20190102_101010.jpg to %s, got %+v", expected, plan.Items[0])
+		}
+	})
+
+	t.Run("planning with an invalid layout must return a validation error", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/plans/by-date", `{"layout": "/abs"}`, token))
+		expectAPIError(t, rec, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("executing a plan must start a job that copies each file, and only once", func(t *testing.T) {
+		rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/plans/"+plan.ID+"/execute", "", token))
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+		}
+
+		var job struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		}
+		decodeResponse(t, rec, &job)
+		if location := rec.Header().Get("Location"); location != "/api/v1/jobs/"+job.ID {
+			t.Fatalf("expected location of job %s, got %s", job.ID, location)
+		}
+
+		for deadline := time.Now().Add(5 * time.Second); job.Status == "running"; {
+			if time.Now().After(deadline) {
+				t.Fatal("expected job to finish")
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			rec := serve(c, newAPIRequest(http.MethodGet, "/api/v1/jobs/"+job.ID, "", token))
+			decodeResponse(t, rec, &job)
+		}
+		if job.Status != "completed" {
+			t.Fatalf("expected completed, got %s", job.Status)
+		}
+
+		if contents, err := ioutil.ReadFile(path.Join(dir, plan.Items[0].Destination)); err != nil || string(contents) != "image" {
+			t.Fatalf("expected image, got %s (%v)", contents, err)
+		}
+
+		rec = serve(c, newAPIRequest(http.MethodPost, "/api/v1/plans/"+plan.ID+"/execute", "", token))
+		expectAPIError(t, rec, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("executing another session's plan must return a not found error", func(t *testing.T) {
+		otherToken, _ := newAPISession(t, c, dir)
+
+		rec := serve(c, newAPIRequest(http.MethodPost, "/api/v1/plans/"+plan.ID+"/execute", "", otherToken))
+		expectAPIError(t, rec, http.StatusNotFound)
+	})
+}
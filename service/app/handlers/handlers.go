@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -10,12 +12,49 @@ import (
 	"imgnheap/service/views"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
+const (
+	// sseStreamDuration represents how long an event stream is held open for before the browser must reconnect
+	sseStreamDuration = 10 * time.Second
+	// sseRetry represents how long the browser waits before reconnecting to an event stream
+	sseRetry = time.Second
+)
+
+// runNew represents the choice of starting a new session rather than resuming a previous one
+const runNew = "new"
+
 func indexHandler(c app.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		data := views.IndexPage{Page: views.NewPage("Enter your directory", "", false)}
+		showHidden := r.FormValue("hidden") != ""
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		listing, err := fsAgent.BrowseDirectory(c.Roots(), r.FormValue("browse"), showHidden)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// forms that start a session must carry the token of the page from which they were submitted
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		csrfToken := sessAgent.GetIndexCSRFTokenFromCookie(r)
+		if csrfToken == "" {
+			if csrfToken, err = domain.NewSecretToken(); err != nil {
+				handleError(err, c, w)
+				return
+			}
+			sessAgent.WriteIndexCSRFCookie(csrfToken, w)
+		}
+
+		data := views.IndexPage{
+			Page:       views.NewPage("Enter your directory", "", false),
+			Listing:    listing,
+			ShowHidden: showHidden,
+		}
+		data.CSRFToken = csrfToken
 
 		if err := c.Templates().ExecuteTemplate(w, "index", data); err != nil {
 			handleError(err, c, w)
@@ -25,22 +64,79 @@ func indexHandler(c app.Container) http.HandlerFunc {
 
 func newSessionHandler(c app.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// get directory path from request
+		// get directory path from request, favouring a directory chosen from the browser over a typed one
 		dirPath := r.FormValue("directory")
+		if chosen := r.FormValue("chosen"); chosen != "" {
+			dirPath = chosen
+		}
 		if dirPath == "" {
 			handleError(missingFieldError("directory"), c, w)
 			return
 		}
 
+		// get scan options from request
+		maxDepth := 0
+		if value := r.FormValue("max_depth"); value != "" {
+			var err error
+			if maxDepth, err = strconv.Atoi(value); err != nil {
+				handleError(domain.ValidationError{Err: fmt.Errorf("invalid max depth: %s", value)}, c, w)
+				return
+			}
+		}
+		scan, err := domain.ParseScanOptions(r.FormValue("recursive") != "", maxDepth, r.FormValue("excludes"))
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// offer to resume a previous session, unless a choice has already been made
+		run := r.FormValue("run")
+		if run == "" {
+			fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+			runs, err := fsAgent.GetSessionRuns(dirPath)
+			if err != nil {
+				handleError(err, c, w)
+				return
+			}
+
+			if len(runs) > 0 {
+				data := views.ResumeSelectionPage{
+					Page:      views.NewPage("Resume a previous session?", dirPath, false),
+					Recursive: scan.Recursive,
+					MaxDepth:  scan.MaxDepth,
+					Excludes:  r.FormValue("excludes"),
+					Runs:      runs,
+				}
+				sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+				data.CSRFToken = sessAgent.GetIndexCSRFTokenFromCookie(r)
+
+				if err := c.Templates().ExecuteTemplate(w, "resume-selection", data); err != nil {
+					handleError(err, c, w)
+				}
+				return
+			}
+		}
+
 		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
 
-		// save new session
-		sess, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dirPath, time.Now())
+		// save new or resumed session
+		var sess *models.Session
+		if run == "" || run == runNew {
+			sess, err = sessAgent.NewSessionFromDirectoryAndTimestamp(dirPath, time.Now(), scan)
+		} else {
+			sess, err = sessAgent.ResumeSessionFromDirectory(dirPath, run, scan)
+		}
 		if err != nil {
 			handleError(err, c, w)
 			return
 		}
 
+		// clear up after any copies of previous sessions that were cut short
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		if _, err := fsAgent.RemoveStaleTempFilesFromSessionRuns(sess.BaseDir); err != nil {
+			log.Println(err)
+		}
+
 		// write cookie
 		if err := sessAgent.WriteCookie(sess, w); err != nil {
 			handleError(err, c, w)
@@ -54,24 +150,36 @@ func newSessionHandler(c app.Container) http.HandlerFunc {
 
 func catalogMethodSelectionHandler(c app.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
-
 		sess := getSessionFromRequest(r)
 		if sess == nil {
 			handleError(errors.New("session is nil"), c, w)
 			return
 		}
-		dirPath := sess.BaseDir
 
-		imgFiles, err := fsAgent.GetFilesFromDirectoryByExtension(dirPath, domain.ImgFileExts...)
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+
+		imgFiles, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		pending, err := fsAgent.PendingUndos()
 		if err != nil {
 			handleError(err, c, w)
 			return
 		}
 
 		data := views.CatalogMethodSelectionPage{
-			Page:            views.NewPage("Select your catalog method", dirPath, dirPath != ""),
+			Page:            views.NewSessionPage("Select your catalog method", sess),
 			ImageFilesCount: len(imgFiles),
+			Layouts:         domain.DirLayoutPresets(),
+			DefaultLayout:   c.Config().Layout,
+			CollisionSelection: views.CollisionSelection{
+				Policies: domain.CollisionPolicies(),
+				Selected: c.Config().CollisionPolicy,
+			},
+			PendingUndos: len(pending),
 		}
 
 		if err := c.Templates().ExecuteTemplate(w, "catalog-method-selection", data); err != nil {
@@ -82,14 +190,18 @@ func catalogMethodSelectionHandler(c app.Container) http.HandlerFunc {
 
 func resetHandler(c app.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// delete session cookie
+		// delete session and its cookie
 		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		if err := sessAgent.DeleteSession(getSessionFromRequest(r)); err != nil {
+			handleError(err, c, w)
+			return
+		}
 		sessAgent.DeleteCookie(w)
 		redirectToHome(w)
 	}
 }
 
-func processFilesByDateInFilename(c app.Container) http.HandlerFunc {
+func planFilesByDate(c app.Container) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sess := getSessionFromRequest(r)
 		if sess == nil {
@@ -97,29 +209,203 @@ func processFilesByDateInFilename(c app.Container) http.HandlerFunc {
 			return
 		}
 
-		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		// validate layout from request before planning starts
+		layout, err := domain.ParseDirLayout(layoutFromRequest(r, c))
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
 
-		files, err := fsAgent.GetFilesFromDirectoryByExtension(sess.BaseDir, domain.ImgFileExts...)
+		// validate collision policy from request, as the plan is executed with it
+		policy, err := collisionPolicyFromRequest(r, c)
 		if err != nil {
 			handleError(err, c, w)
 			return
 		}
 
-		for _, file := range files {
-			destDir := domain.GetDestinationDirByDate(file, sess)
-			if err := fsAgent.ProcessFileByCopy(file, destDir); err != nil {
-				handleError(err, c, w)
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, CollisionPolicy: policy}
+		planAgent := domain.PlanAgent{PlanAgentInjector: c}
+
+		skipDuplicates := r.FormValue("skip_duplicates") != ""
+
+		plan, err := fsAgent.PlanByDate(sess, layout, skipDuplicates)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// save plan so that exactly this plan is executed on confirmation
+		if err := planAgent.SavePlan(plan); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.ByDatePreviewPage{
+			Page:   views.NewSessionPage("Preview Processing By Date", sess),
+			Plan:   plan,
+			Groups: plan.GroupByDestDir(),
+		}
+		if err := c.Templates().ExecuteTemplate(w, "catalog-by-date-preview", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func processFilesByDate(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		// get plan id from request
+		planID := r.FormValue("plan_id")
+		if planID == "" {
+			handleError(missingFieldError("plan_id"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+		planAgent := domain.PlanAgent{PlanAgentInjector: c}
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		// claim plan so that it cannot be executed twice
+		plan, err := planAgent.ClaimPlanForSession(planID, sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// execute plan in the background
+		job, err := jobAgent.StartJobForSession(sess, "Processing By Date", len(plan.Items), func(ctx context.Context, progress app.JobProgress) error {
+			return fsAgent.ExecutePlan(ctx, plan, progress)
+		})
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// redirect to job status
+		redirect(w, fmt.Sprintf("/jobs/%s", job.ID))
+	}
+}
+
+func jobHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		var jobID string
+		if err := routeParam(&jobID, "id", r); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		job, err := jobAgent.GetJobForSession(jobID, sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.JobPage{
+			Page: views.NewSessionPage(job.Name, sess),
+			Job:  job,
+		}
+		if err := c.Templates().ExecuteTemplate(w, "job", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func jobEventsHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		var jobID string
+		if err := routeParam(&jobID, "id", r); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			handleError(errors.New("streaming is not supported"), c, w)
+			return
+		}
+
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		updates, unsubscribe, err := jobAgent.SubscribeForSession(jobID, sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+		flusher.Flush()
+
+		// end the stream before the server's write timeout is reached, the browser will reconnect automatically
+		timeout := time.NewTimer(sseStreamDuration)
+		defer timeout.Stop()
+
+		for {
+			select {
+			case job, ok := <-updates:
+				if !ok {
+					// job has finished
+					return
+				}
+				if err := writeEvent(w, "job", job); err != nil {
+					log.Println(err)
+					return
+				}
+				flusher.Flush()
+			case <-timeout.C:
+				return
+			case <-r.Context().Done():
 				return
 			}
 		}
+	}
+}
 
-		data := views.ProcessedByDatePage{
-			Page:              views.NewPage("Finished Processing By Date", sess.BaseDir, true),
-			CompletionMessage: fmt.Sprintf("%d files in %s", len(files), sess.FullDir()),
+func cancelJobHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
 		}
-		if err := c.Templates().ExecuteTemplate(w, "processed-by-date", data); err != nil {
+
+		var jobID string
+		if err := routeParam(&jobID, "id", r); err != nil {
 			handleError(err, c, w)
+			return
 		}
+
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		if err := jobAgent.CancelJobForSession(jobID, sess); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// redirect to job status
+		redirect(w, fmt.Sprintf("/jobs/%s", jobID))
 	}
 }
 
@@ -132,34 +418,64 @@ func catalogByTag(c app.Container) http.HandlerFunc {
 			}
 		}
 
-		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
-
 		sess := getSessionFromRequest(r)
 		if sess == nil {
 			handleError(errors.New("session is nil"), c, w)
 			return
 		}
-		dirPath := sess.BaseDir
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+
+		policy, err := collisionPolicyFromRequest(r, c)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
 
 		data := views.CatalogByTagPage{
-			Page: views.NewPage("Catalog image by tag", dirPath, dirPath != ""),
+			Page: views.NewSessionPage("Catalog image by tag", sess),
+			CollisionSelection: views.CollisionSelection{
+				Policies:   domain.CollisionPolicies(),
+				Selected:   policy,
+				AutoSubmit: true,
+			},
+			LastResult: lastResultFromRequest(r),
+		}
+
+		pending, err := fsAgent.PendingUndos()
+		if err != nil {
+			handleError(err, c, w)
+			return
 		}
+		data.PendingUndos = len(pending)
 
-		// see if we have any more files that need to be processed
-		files, err := fsAgent.GetFilesFromDirectoryByExtension(dirPath, domain.ImgFileExts...)
+		// see if we have any more files that need to be processed, leaving out those that have been skipped
+		files, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
 		if err != nil {
 			handleError(err, c, w)
 			return
 		}
+
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		skipped, err := sessAgent.GetSkippedFilesForSession(sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+		files = domain.ExcludeSkippedFiles(sess, files, skipped)
+
 		data.ImageFilesCount = len(files)
 		if data.ImageFilesCount == 0 {
 			data.CompletionMessage = "Done all the images!"
+			if len(skipped) > 0 {
+				data.CompletionMessage = fmt.Sprintf("Done all the images! %d skipped.", len(skipped))
+			}
 			writeResponse(data)
 			return
 		}
 
 		// get next file to be processed
-		data.ImageFileName = files[0].NameWithExt()
+		data.ImageFileName = files[0].RelativePath(sess.BaseDir)
 
 		data.TagsWithCount = make(map[string]int)
 		dirs, err := fsAgent.GetDirectoriesWithFileCountByExtension(sess.FullDir(domain.SubDirByTag), domain.ImgFileExts...)
@@ -192,22 +508,380 @@ func processFileByTag(c app.Container) http.HandlerFunc {
 			handleError(missingFieldError("tag"), c, w)
 			return
 		}
+		if err := domain.ValidateTagName(tag); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// get collision policy from request
+		policy, err := collisionPolicyFromRequest(r, c)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
 
 		// instantiate file object
-		name, ext := domain.ParseNameAndExtensionFromFileName(fileName)
-		file := models.NewFile(name, ext, sess.BaseDir, nil)
+		file, err := domain.FileFromRelativePath(sess.BaseDir, fileName)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
 
-		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: c,
+			Journal:                 domain.NewSessionJournal(sess),
+			CollisionPolicy:         policy,
+		}
 
 		// do the move bit...
 		destDir := domain.GetDestinationDirByTag(sess, tag)
-		if err := fsAgent.ProcessFileByMove(file, destDir); err != nil {
+		result, err := fsAgent.ProcessFileByMove(file, destDir)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		if result.Outcome == domain.ProcessOutcomeSkipped || result.Outcome == domain.ProcessOutcomeSkippedIdentical {
+			// file stays where it is, so don't offer it again
+			sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+			if err := sessAgent.SkipFileForSession(sess, file.RelativePath(sess.BaseDir)); err != nil {
+				handleError(err, c, w)
+				return
+			}
+		}
+
+		// redirect to control panel, reporting the outcome
+		query := url.Values{}
+		query.Set("collision", policy)
+		query.Set("processed", fileName)
+		query.Set("outcome", result.Outcome)
+		query.Set("destination", result.Destination.NameWithExt())
+		redirect(w, "/catalog/by-tag?"+query.Encode())
+	}
+}
+
+func duplicatesHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+		groups, scanned, err := sessAgent.GetDuplicatesForSession(sess)
+		if err != nil {
 			handleError(err, c, w)
 			return
 		}
 
-		// redirect to control panel
-		redirect(w, "/catalog/by-tag")
+		data := views.DuplicatesPage{
+			Page:    views.NewSessionPage("Duplicates", sess),
+			Scanned: scanned,
+			Groups:  groups,
+		}
+		if err := c.Templates().ExecuteTemplate(w, "duplicates", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func scanDuplicatesHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		files, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// hash the files in the background, keeping the duplicates found for the duplicates page
+		total := domain.CountDuplicateCandidates(files)
+		job, err := jobAgent.StartScanForSession(sess, "Finding Duplicates", total, "/duplicates", func(ctx context.Context, progress app.JobProgress) error {
+			groups, err := fsAgent.ScanDuplicates(ctx, files, progress)
+			if err != nil {
+				return err
+			}
+			return sessAgent.SaveDuplicatesForSession(sess, groups)
+		})
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// redirect to job status
+		redirect(w, fmt.Sprintf("/jobs/%s", job.ID))
+	}
+}
+
+func resolveDuplicatesHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+		groups, scanned, err := sessAgent.GetDuplicatesForSession(sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+		if !scanned {
+			handleError(domain.ValidationError{Err: errors.New("duplicates have not been found yet")}, c, w)
+			return
+		}
+
+		var results []models.ProcessResult
+		var remaining []models.DuplicateGroup
+		for _, group := range groups {
+			keep := r.FormValue("keep_" + group.Checksum)
+			if keep == "" {
+				// group has not been resolved
+				remaining = append(remaining, group)
+				continue
+			}
+
+			groupResults, err := fsAgent.ResolveDuplicates(sess, group, keep)
+			results = append(results, groupResults...)
+			if err != nil {
+				handleError(err, c, w)
+				return
+			}
+		}
+
+		if err := sessAgent.SaveDuplicatesForSession(sess, remaining); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.DuplicatesPage{
+			Page:              views.NewSessionPage("Duplicates", sess),
+			CompletionMessage: fmt.Sprintf("Moved %d duplicate(s) to %s", domain.CountMoved(results), sess.FullDir(domain.SubDirDuplicates)),
+			Results:           results,
+			Scanned:           true,
+			Groups:            remaining,
+		}
+		if err := c.Templates().ExecuteTemplate(w, "duplicates", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func similarHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		distance, err := distanceFromRequest(r)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+		items, scanned, err := sessAgent.GetSimilarForSession(sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.SimilarPage{
+			Page:     views.NewSessionPage("Similar Images", sess),
+			Distance: distance,
+			Scanned:  scanned,
+			Groups:   domain.GroupSimilar(items, distance),
+		}
+		if err := c.Templates().ExecuteTemplate(w, "similar", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func scanSimilarHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, HashCache: c.KeyValStore()}
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		jobAgent := domain.JobAgent{JobAgentInjector: c}
+
+		files, err := fsAgent.GetFilesForSession(sess, domain.SimilarFileExts...)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// hash the images in the background, keeping the hashes for the similar images page
+		job, err := jobAgent.StartScanForSession(sess, "Finding Similar Images", len(files), "/duplicates/similar", func(ctx context.Context, progress app.JobProgress) error {
+			items, err := fsAgent.ScanSimilar(ctx, files, progress)
+			if err != nil {
+				return err
+			}
+			return sessAgent.SaveSimilarForSession(sess, items)
+		})
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		// redirect to job status
+		redirect(w, fmt.Sprintf("/jobs/%s", job.ID))
+	}
+}
+
+func resolveSimilarHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		distance, err := distanceFromRequest(r)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: c,
+			Journal:                 domain.NewSessionJournal(sess),
+			HashCache:               c.KeyValStore(),
+		}
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+		items, scanned, err := sessAgent.GetSimilarForSession(sess)
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+		if !scanned {
+			handleError(domain.ValidationError{Err: errors.New("similar images have not been found yet")}, c, w)
+			return
+		}
+
+		var results []models.ProcessResult
+		resolved := make(map[string]bool)
+		for _, group := range domain.GroupSimilar(items, distance) {
+			keep := r.FormValue("keep_" + group.ID)
+			if keep == "" {
+				// group has not been resolved
+				continue
+			}
+
+			groupResults, err := fsAgent.ResolveSimilar(sess, group, keep)
+			results = append(results, groupResults...)
+			if err != nil {
+				handleError(err, c, w)
+				return
+			}
+
+			// the kept image remains, whereas the rest have been moved aside or have changed since being hashed
+			for _, item := range group.Items {
+				if item.File.RelativePath(sess.BaseDir) != keep {
+					resolved[item.File.FullPath()] = true
+				}
+			}
+		}
+
+		var remaining []models.SimilarItem
+		for _, item := range items {
+			if !resolved[item.File.FullPath()] {
+				remaining = append(remaining, item)
+			}
+		}
+		if err := sessAgent.SaveSimilarForSession(sess, remaining); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.SimilarPage{
+			Page:              views.NewSessionPage("Similar Images", sess),
+			CompletionMessage: fmt.Sprintf("Moved %d similar image(s) to %s", domain.CountMoved(results), sess.FullDir(domain.SubDirDuplicates)),
+			Results:           results,
+			Distance:          distance,
+			Scanned:           true,
+			Groups:            domain.GroupSimilar(remaining, distance),
+		}
+		if err := c.Templates().ExecuteTemplate(w, "similar", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func undoLastHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+
+		entry, err := fsAgent.UndoLast()
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.UndonePage{
+			Page:              views.NewSessionPage("Undone", sess),
+			CompletionMessage: "Undone the last file operation",
+			Entries:           []models.JournalEntry{entry},
+		}
+		if err := c.Templates().ExecuteTemplate(w, "undone", data); err != nil {
+			handleError(err, c, w)
+		}
+	}
+}
+
+func undoSessionHandler(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c, Journal: domain.NewSessionJournal(sess)}
+
+		entries, err := fsAgent.UndoAll()
+		if err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		data := views.UndonePage{
+			Page:              views.NewSessionPage("Undone", sess),
+			CompletionMessage: fmt.Sprintf("Undone %d file operation(s)", len(entries)),
+			Entries:           entries,
+		}
+		if err := c.Templates().ExecuteTemplate(w, "undone", data); err != nil {
+			handleError(err, c, w)
+		}
 	}
 }
 
@@ -225,16 +899,68 @@ func renderFile(c app.Container) http.HandlerFunc {
 			return
 		}
 
-		name, ext := domain.ParseNameAndExtensionFromFileName(fileName)
-		file := models.NewFile(name, ext, sess.BaseDir, nil)
+		file, err := domain.FileFromRelativePath(sess.BaseDir, fileName)
+		if err != nil {
+			w.WriteHeader(getResponseStatusFromError(err))
+			log.Println(err)
+			return
+		}
 
 		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: c}
 
-		err := fsAgent.Stream(file, w)
+		if err := fsAgent.Stream(file, w, r); err != nil {
+			w.WriteHeader(getResponseStatusFromError(err))
+			log.Println(err)
+		}
+	}
+}
+
+func renderThumbnail(c app.Container) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := getSessionFromRequest(r)
+		if sess == nil {
+			handleError(errors.New("session is nil"), c, w)
+			return
+		}
+
+		var fileName string
+		if err := routeParam(&fileName, "filename", r); err != nil {
+			handleError(err, c, w)
+			return
+		}
+
+		file, err := domain.FileFromRelativePath(sess.BaseDir, fileName)
+		if err != nil {
+			w.WriteHeader(getResponseStatusFromError(err))
+			log.Println(err)
+			return
+		}
+
+		if !domain.IsThumbnailable(file) {
+			// fall back to the original file
+			http.Redirect(w, r, "/file/"+url.PathEscape(fileName), http.StatusFound)
+			return
+		}
+
+		size := r.FormValue("size")
+		if size == "" {
+			size = domain.DefaultThumbnailSize
+		}
+
+		thumbAgent := domain.ThumbnailAgent{ThumbnailAgentInjector: c}
+
+		data, err := thumbAgent.Thumbnail(file, size)
 		if err != nil {
 			w.WriteHeader(getResponseStatusFromError(err))
 			log.Println(err)
+			return
 		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
 	}
 }
 
@@ -245,10 +971,16 @@ func handleError(err error, c app.Container, w http.ResponseWriter) {
 	switch err.(type) {
 	case domain.BadRequestError:
 		msg = "Bad Request"
+	case domain.ForbiddenError:
+		msg = "Forbidden"
 	case domain.NotFoundError:
 		msg = "Not Found"
 	case domain.ValidationError:
 		msg = "Unprocessable Entity"
+	case domain.UnsupportedMediaTypeError:
+		msg = "Unsupported Media Type"
+	case domain.UnavailableError:
+		msg = "Service Unavailable"
 	default:
 		msg = "Internal Server Error"
 	}
@@ -271,10 +1003,16 @@ func getResponseStatusFromError(err error) int {
 	switch err.(type) {
 	case domain.BadRequestError:
 		return http.StatusBadRequest
+	case domain.ForbiddenError:
+		return http.StatusForbidden
 	case domain.NotFoundError:
 		return http.StatusNotFound
 	case domain.ValidationError:
 		return http.StatusUnprocessableEntity
+	case domain.UnsupportedMediaTypeError:
+		return http.StatusUnsupportedMediaType
+	case domain.UnavailableError:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -287,6 +1025,73 @@ func missingFieldError(fieldName string) domain.BadRequestError {
 	}
 }
 
+// writeEvent writes the provided value as a server-sent event of the provided type to the provided response writer
+func writeEvent(w http.ResponseWriter, event string, val interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// lastResultFromRequest returns the outcome of the previously processed file reported by the provided request, if any
+func lastResultFromRequest(r *http.Request) *models.ProcessResult {
+	processed := r.FormValue("processed")
+	if processed == "" {
+		return nil
+	}
+
+	name, ext := domain.ParseNameAndExtensionFromFileName(processed)
+	destName, destExt := domain.ParseNameAndExtensionFromFileName(r.FormValue("destination"))
+
+	return &models.ProcessResult{
+		File:        models.NewFile(name, ext, "", nil),
+		Destination: models.NewFile(destName, destExt, "", nil),
+		Outcome:     r.FormValue("outcome"),
+	}
+}
+
+// distanceFromRequest returns the similarity distance provided by the provided request,
+// otherwise the default distance if none has been provided
+func distanceFromRequest(r *http.Request) (int, error) {
+	value := r.FormValue("distance")
+	if value == "" {
+		return domain.DefaultSimilarityDistance, nil
+	}
+
+	distance, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, domain.ValidationError{Err: fmt.Errorf("invalid distance: %s", value)}
+	}
+
+	return domain.ParseSimilarityDistance(distance)
+}
+
+// layoutFromRequest returns the by-date layout template selected by the provided request,
+// otherwise the configured default layout if none has been selected
+func layoutFromRequest(r *http.Request, c app.Container) string {
+	layout := r.FormValue("layout")
+	if layout == "custom" {
+		layout = r.FormValue("custom_layout")
+	}
+	if layout == "" {
+		layout = c.Config().Layout
+	}
+	return layout
+}
+
+// collisionPolicyFromRequest returns the collision policy selected by the provided request,
+// otherwise the configured default policy if none has been selected
+func collisionPolicyFromRequest(r *http.Request, c app.Container) (string, error) {
+	policy := r.FormValue("collision")
+	if policy == "" {
+		policy = c.Config().CollisionPolicy
+	}
+	return domain.ParseCollisionPolicy(policy)
+}
+
 // routeParam loads the value of the provided route parameter from the provided request object into the provided recipient variable
 func routeParam(p *string, name string, r *http.Request) error {
 	if p == nil {
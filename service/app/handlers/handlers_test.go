@@ -0,0 +1,395 @@
+package handlers_test
+
+import (
+	"bytes"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"imgnheap/service/app"
+	"imgnheap/service/app/handlers"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"imgnheap/service/views"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testContainer provides the handlers with an in-memory store and the OS file system, restricted to its roots
+type testContainer struct {
+	templates *template.Template
+	store     app.KeyValStore
+	fs        app.FileSystem
+	patterns  app.TimestampPatternRegistry
+	jobs      app.JobRunner
+	thumbs    app.ThumbnailCache
+	roots     []string
+	access    string
+	config    models.Config
+}
+
+func (c testContainer) Templates() *template.Template                          { return c.templates }
+func (c testContainer) KeyValStore() app.KeyValStore                           { return c.store }
+func (c testContainer) FileSystem() app.FileSystem                             { return c.fs }
+func (c testContainer) TimestampPatternRegistry() app.TimestampPatternRegistry { return c.patterns }
+func (c testContainer) JobRunner() app.JobRunner                               { return c.jobs }
+func (c testContainer) ThumbnailCache() app.ThumbnailCache                     { return c.thumbs }
+func (c testContainer) Roots() []string                                        { return c.roots }
+func (c testContainer) AccessToken() string                                    { return c.access }
+func (c testContainer) Config() models.Config                                  { return c.config }
+
+// newTestContainer returns a test container whose only root is the provided directory
+func newTestContainer(t *testing.T, root string) testContainer {
+	patterns, err := domain.NewInMemoryTimestampPatternRegistry(domain.DefaultTimestampPatterns()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := domain.NewRestrictedFileSystem(&domain.OsFileSystem{}, []string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := domain.DefaultConfig()
+	cfg.Roots = []string{root}
+
+	return testContainer{
+		templates: views.MustParseTemplates(),
+		store:     domain.NewInMemoryKeyValStore(),
+		fs:        fs,
+		patterns:  patterns,
+		jobs:      domain.NewInMemoryJobRunner(),
+		thumbs:    domain.NewDiskThumbnailCache(path.Join(root, ".thumbs"), 1024*1024),
+		roots:     []string{root},
+		config:    cfg,
+	}
+}
+
+// newTestSession stores a new session for the provided directory, and returns it along with its cookie
+func newTestSession(t *testing.T, c testContainer, dir string) (*models.Session, *http.Cookie) {
+	sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+	sess, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dir, time.Now(), models.ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := sessAgent.WriteCookie(sess, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	return sess, rec.Result().Cookies()[0]
+}
+
+// newTempDir returns a new temporary directory, along with a function that removes it
+func newTempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// writeFiles writes the provided contents to each of the provided paths, relative to the provided directory
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	for relPath, content := range contents {
+		fullPath := path.Join(dir, relPath)
+		if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serve returns the response of the router of the provided container to the provided request
+func serve(c testContainer, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handlers.RegisterRouter(c).ServeHTTP(rec, r)
+	return rec
+}
+
+// newFormRequest returns a new request that posts the provided form values to the provided target
+func newFormRequest(target string, form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestCatalogByTag(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	sess, cookie := newTestSession(t, c, dir)
+
+	// both files collide with files of the same name that have already been tagged
+	tagDir := domain.GetDestinationDirByTag(sess, "holiday")
+	writeFiles(t, dir, map[string]string{
+		"a.jpg": "identical",
+		"b.jpg": "original",
+		strings.TrimPrefix(path.Join(tagDir, "a.jpg"), dir): "identical",
+		strings.TrimPrefix(path.Join(tagDir, "b.jpg"), dir): "different",
+	})
+
+	// catalogPage returns the body of the catalog by tag page
+	catalogPage := func(t *testing.T) string {
+		r := httptest.NewRequest(http.MethodGet, "/catalog/by-tag", nil)
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		return rec.Body.String()
+	}
+
+	// tagFile tags the provided file using the provided collision policy
+	tagFile := func(t *testing.T, fileName string, policy string) {
+		r := newFormRequest("/catalog/by-tag", url.Values{
+			"csrf_token": {sess.CSRFToken},
+			"file_name":  {fileName},
+			"tag":        {"holiday"},
+			"collision":  {policy},
+		})
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusFound, rec.Code, rec.Body)
+		}
+	}
+
+	t.Run("tagging files that collide with existing files must move on to the next file each time", func(t *testing.T) {
+		if body := catalogPage(t); !strings.Contains(body, `<p class="bold">a.jpg</p>`) {
+			t.Fatalf("expected a.jpg to be offered, got %s", body)
+		}
+
+		tagFile(t, "a.jpg", domain.CollisionPolicySkipIdentical)
+		if _, err := os.Stat(path.Join(dir, "a.jpg")); err != nil {
+			t.Fatalf("expected identical original to be kept, got %v", err)
+		}
+
+		if body := catalogPage(t); !strings.Contains(body, `<p class="bold">b.jpg</p>`) {
+			t.Fatalf("expected b.jpg to be offered, got %s", body)
+		}
+
+		tagFile(t, "b.jpg", domain.CollisionPolicySkip)
+		if _, err := os.Stat(path.Join(dir, "b.jpg")); err != nil {
+			t.Fatalf("expected skipped original to be kept, got %v", err)
+		}
+
+		if body := catalogPage(t); !strings.Contains(body, "Done all the images! 2 skipped.") {
+			t.Fatalf("expected completion message, got %s", body)
+		}
+	})
+}
+
+// waitForJob waits for the job that the provided response redirected to to finish, and returns it
+func waitForJob(t *testing.T, c testContainer, rec *httptest.ResponseRecorder) models.Job {
+	location := rec.Header().Get("Location")
+	if rec.Code != http.StatusFound || !strings.HasPrefix(location, "/jobs/") {
+		t.Fatalf("expected redirect to job, got %d to %s: %s", rec.Code, location, rec.Body)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		job, err := c.JobRunner().Get(strings.TrimPrefix(location, "/jobs/"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.IsFinished() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected job to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	sess, cookie := newTestSession(t, c, dir)
+
+	writeFiles(t, dir, map[string]string{
+		"a.jpg": "same",
+		"b.jpg": "same",
+		"c.jpg": "unique file",
+	})
+
+	// duplicatesPage returns the body of the duplicates page
+	duplicatesPage := func(t *testing.T) string {
+		r := httptest.NewRequest(http.MethodGet, "/duplicates", nil)
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		return rec.Body.String()
+	}
+
+	t.Run("viewing duplicates before they have been found must offer to find them", func(t *testing.T) {
+		if body := duplicatesPage(t); !strings.Contains(body, "Find Duplicates") {
+			t.Fatalf("expected to be offered to find duplicates, got %s", body)
+		}
+	})
+
+	t.Run("finding duplicates must hash the files in a job whose results are then shown", func(t *testing.T) {
+		r := newFormRequest("/duplicates/scan", url.Values{"csrf_token": {sess.CSRFToken}})
+		r.AddCookie(cookie)
+
+		job := waitForJob(t, c, serve(c, r))
+		if job.Status != models.JobStatusCompleted || job.Done != 2 || job.ResultPath != "/duplicates" {
+			t.Fatalf("expected 2 files hashed with results at /duplicates, got %+v", job)
+		}
+
+		if body := duplicatesPage(t); !strings.Contains(body, "Found 1 set(s) of identical files") {
+			t.Fatalf("expected 1 set of duplicates, got %s", body)
+		}
+	})
+
+	t.Run("resolving duplicates must count only the files that were moved", func(t *testing.T) {
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		groups, _, err := sessAgent.GetDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+
+		// b.jpg is no longer a duplicate, so must not be moved
+		writeFiles(t, dir, map[string]string{"b.jpg": "edit"})
+
+		r := newFormRequest("/duplicates", url.Values{
+			"csrf_token":                 {sess.CSRFToken},
+			"keep_" + groups[0].Checksum: {"a.jpg"},
+		})
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "Moved 0 duplicate(s)") {
+			t.Fatalf("expected nothing to be moved, got %s", body)
+		}
+		if _, err := os.Stat(path.Join(dir, "b.jpg")); err != nil {
+			t.Fatalf("expected changed file to be kept, got %v", err)
+		}
+
+		if body := duplicatesPage(t); !strings.Contains(body, "No duplicates found") {
+			t.Fatalf("expected resolved set to be removed, got %s", body)
+		}
+	})
+}
+
+func TestSimilar(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	sess, cookie := newTestSession(t, c, dir)
+
+	// both images are of the same gradient, at different sizes
+	for name, width := range map[string]int{"a.png": 160, "b.png": 80} {
+		img := image.NewGray(image.Rect(0, 0, width, width))
+		for x := 0; x < width; x++ {
+			for y := 0; y < width; y++ {
+				img.SetGray(x, y, color.Gray{Y: uint8(x * 255 / width)})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, map[string]string{name: buf.String()})
+	}
+
+	t.Run("finding similar images must hash the images in a job whose results are then shown", func(t *testing.T) {
+		r := newFormRequest("/duplicates/similar/scan", url.Values{"csrf_token": {sess.CSRFToken}})
+		r.AddCookie(cookie)
+
+		job := waitForJob(t, c, serve(c, r))
+		if job.Status != models.JobStatusCompleted || job.Done != 2 || job.ResultPath != "/duplicates/similar" {
+			t.Fatalf("expected 2 images hashed with results at /duplicates/similar, got %+v", job)
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/duplicates/similar", nil)
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if body := rec.Body.String(); !strings.Contains(body, "Found 1 set(s) of similar images") {
+			t.Fatalf("expected 1 set of similar images, got %s", body)
+		}
+	})
+
+	t.Run("resolving similar images must move the rest aside", func(t *testing.T) {
+		sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+		items, _, err := sessAgent.GetSimilarForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups := domain.GroupSimilar(items, domain.DefaultSimilarityDistance)
+		if len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+
+		r := newFormRequest("/duplicates/similar", url.Values{
+			"csrf_token":           {sess.CSRFToken},
+			"keep_" + groups[0].ID: {"a.png"},
+		})
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		if body := rec.Body.String(); !strings.Contains(body, "Moved 1 similar image(s)") || !strings.Contains(body, "No similar images found") {
+			t.Fatalf("expected b.png to be moved, got %s", body)
+		}
+		if _, err := os.Stat(sess.FullDir(domain.SubDirDuplicates, "b.png")); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestRenderThumbnail(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	_, cookie := newTestSession(t, c, dir)
+
+	writeFiles(t, dir, map[string]string{"clip #1?.mp4": "video"})
+
+	t.Run("requesting the thumbnail of a file that can't be thumbnailed must redirect to its escaped original", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/thumb/"+url.PathEscape("clip #1?.mp4"), nil)
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusFound, rec.Code, rec.Body)
+		}
+
+		expected := "/file/clip%20%231%3F.mp4"
+		if location := rec.Header().Get("Location"); location != expected {
+			t.Fatalf("expected location %s, got %s", expected, location)
+		}
+	})
+}
@@ -2,14 +2,72 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"imgnheap/service/app"
 	"imgnheap/service/domain"
 	"imgnheap/service/models"
 	"net/http"
+	"strings"
 )
 
 const ctxSessionKey = "CTX_SESSION"
 
+const (
+	// csrfFieldName represents the name of the form field that carries a session's CSRF token
+	csrfFieldName = "csrf_token"
+	// csrfHeaderName represents the name of the header that carries a session's CSRF token, as an alternative to the form field
+	csrfHeaderName = "X-CSRF-Token"
+	// accessTokenParam represents the name of the query parameter that carries the app's access token
+	accessTokenParam = "access_token"
+	// accessTokenHeaderName represents the name of the header that carries the app's access token, as an alternative to the query parameter
+	accessTokenHeaderName = "X-Access-Token"
+)
+
+// requireAccessToken provides a middleware method for rejecting requests that don't carry the app's access token
+// (if it has one), either as a cookie, a header or a query parameter, which is then remembered as a cookie
+func requireAccessToken(c app.Container) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expected := c.AccessToken()
+			if expected == "" {
+				// access is unrestricted
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+			if domain.SecretTokensMatch(expected, sessAgent.GetAccessTokenFromCookie(r)) ||
+				domain.SecretTokensMatch(expected, r.Header.Get(accessTokenHeaderName)) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			query := r.URL.Query()
+			if token := query.Get(accessTokenParam); domain.SecretTokensMatch(expected, token) {
+				sessAgent.WriteAccessCookie(token, w)
+
+				// remove token from the address bar
+				query.Del(accessTokenParam)
+				location := r.URL.Path
+				if len(query) > 0 {
+					location += "?" + query.Encode()
+				}
+				redirect(w, location)
+				return
+			}
+
+			err := domain.ForbiddenError{Err: errors.New("missing or invalid access token, open the address that was printed when imgnheap was launched")}
+			if isAPIRequest(r) {
+				writeAPIError(w, err)
+				return
+			}
+			handleError(err, c, w)
+		})
+	}
+}
+
 // addSessionToRequestContext provides a middleware method for adding the session to the request context
 // otherwise, redirects current request to home if no valid session has been found
 func addSessionToRequestContext(c app.Container) func(http.Handler) http.Handler {
@@ -32,6 +90,13 @@ func addSessionToRequestContext(c app.Container) func(http.Handler) http.Handler
 				return
 			}
 
+			if sess.CSRFToken == "" {
+				// session pre-dates csrf tokens, so can't be used to submit forms
+				sessAgent.DeleteCookie(w)
+				redirectToHome(w)
+				return
+			}
+
 			if !c.FileSystem().IsDirectory(sess.BaseDir) {
 				// dir path stored by session token does not represent a valid directory
 				sessAgent.DeleteCookie(w)
@@ -46,6 +111,87 @@ func addSessionToRequestContext(c app.Container) func(http.Handler) http.Handler
 	}
 }
 
+// addAPISessionToRequestContext provides a middleware method for adding the session identified by the bearer token
+// of the request's Authorization header to the request context, otherwise responds with a forbidden error
+func addAPISessionToRequestContext(c app.Container) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+			sessToken := sessAgent.GetTokenFromAuthorizationHeader(r)
+			if sessToken == "" {
+				writeAPIError(w, domain.ForbiddenError{Err: errors.New("missing bearer token")})
+				return
+			}
+
+			sess, err := sessAgent.GetSessionFromToken(sessToken)
+			if err != nil {
+				writeAPIError(w, domain.ForbiddenError{Err: errors.New("invalid bearer token")})
+				return
+			}
+
+			if !c.FileSystem().IsDirectory(sess.BaseDir) {
+				// dir path stored by session token does not represent a valid directory
+				writeAPIError(w, domain.NotFoundError{Err: fmt.Errorf("session directory %s no longer exists", sess.BaseDir)})
+				return
+			}
+
+			// add session to request context
+			ctxWithSession := context.WithValue(r.Context(), ctxSessionKey, sess)
+			h.ServeHTTP(w, r.WithContext(ctxWithSession))
+		})
+	}
+}
+
+// verifyCSRFToken provides a middleware method for rejecting requests that could change state but don't carry
+// the CSRF token of the session set on the request context by previous middleware
+func verifyCSRFToken(c app.Container) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				// safe methods don't change state
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(csrfHeaderName)
+			if token == "" {
+				token = r.FormValue(csrfFieldName)
+			}
+
+			sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+			if err := sessAgent.VerifyCSRFToken(getSessionFromRequest(r), token); err != nil {
+				handleError(err, c, w)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verifyIndexCSRFToken provides a middleware method for rejecting requests to start a session that don't carry
+// the CSRF token with which the index page was served
+func verifyIndexCSRFToken(c app.Container) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(csrfHeaderName)
+			if token == "" {
+				token = r.FormValue(csrfFieldName)
+			}
+
+			sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+			if err := sessAgent.VerifyIndexCSRFToken(sessAgent.GetIndexCSRFTokenFromCookie(r), token); err != nil {
+				handleError(err, c, w)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
 // redirectToHome writes a redirection to the provided response writer
 func redirectToHome(w http.ResponseWriter) {
 	redirect(w, "/")
@@ -57,6 +203,11 @@ func redirect(w http.ResponseWriter, location string) {
 	w.WriteHeader(http.StatusFound)
 }
 
+// isAPIRequest returns true if the provided request targets the JSON API, otherwise false
+func isAPIRequest(r *http.Request) bool {
+	return r.URL.Path == apiPathPrefix || strings.HasPrefix(r.URL.Path, apiPathPrefix+"/")
+}
+
 // getSessionFromRequest returns the session set on the request context by previous middleware
 func getSessionFromRequest(r *http.Request) *models.Session {
 	val := r.Context().Value(ctxSessionKey)
@@ -0,0 +1,279 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// findCookie returns the cookie of the provided name that was set by the provided response, or nil if none was set
+func findCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestRequireAccessToken(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	c.access = "secret"
+
+	t.Run("requesting a page with the access token must be permitted", func(t *testing.T) {
+		testCases := []func(r *http.Request){
+			func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "ACCESS_TOKEN", Value: "secret"}) },
+			func(r *http.Request) { r.Header.Set("X-Access-Token", "secret") },
+		}
+
+		for idx, tc := range testCases {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tc(r)
+
+			if rec := serve(c, r); rec.Code != http.StatusOK {
+				t.Errorf("tc %d: expected status %d, got %d", idx, http.StatusOK, rec.Code)
+			}
+		}
+	})
+
+	t.Run("requesting a page with the access token as a query parameter must remember it and redirect without it", func(t *testing.T) {
+		rec := serve(c, httptest.NewRequest(http.MethodGet, "/?access_token=secret&hidden=on", nil))
+
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected status %d, got %d", http.StatusFound, rec.Code)
+		}
+		if location := rec.Header().Get("Location"); location != "/?hidden=on" {
+			t.Fatalf("expected location /?hidden=on, got %s", location)
+		}
+
+		cookie := findCookie(rec, "ACCESS_TOKEN")
+		if cookie == nil {
+			t.Fatal("expected access token cookie, got none")
+		}
+		if cookie.Value != "secret" || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode || cookie.Path != "/" {
+			t.Fatalf("expected http-only, same-site strict cookie of secret at /, got %+v", cookie)
+		}
+	})
+
+	t.Run("requesting a page without the access token must be forbidden", func(t *testing.T) {
+		testCases := []func(r *http.Request){
+			func(r *http.Request) {},
+			func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "ACCESS_TOKEN", Value: "wrong"}) },
+			func(r *http.Request) { r.Header.Set("X-Access-Token", "wrong") },
+			func(r *http.Request) { r.URL.RawQuery = "access_token=wrong" },
+		}
+
+		for idx, tc := range testCases {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tc(r)
+
+			rec := serve(c, r)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("tc %d: expected status %d, got %d", idx, http.StatusForbidden, rec.Code)
+			}
+			if cookie := findCookie(rec, "ACCESS_TOKEN"); cookie != nil {
+				t.Errorf("tc %d: expected no access token cookie, got %+v", idx, cookie)
+			}
+		}
+	})
+
+	t.Run("requesting the api without the access token must be forbidden with a json error", func(t *testing.T) {
+		rec := serve(c, httptest.NewRequest(http.MethodGet, "/api/v1/session", nil))
+
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("expected status %d, got %d", http.StatusForbidden, rec.Code)
+		}
+		if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+			t.Fatalf("expected json, got %s", contentType)
+		}
+	})
+}
+
+func TestNewSession(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+
+	// indexCookie returns the csrf token cookie with which the index page is served
+	indexCookie := func(t *testing.T) *http.Cookie {
+		rec := serve(c, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		cookie := findCookie(rec, "INDEX_CSRF")
+		if cookie == nil {
+			t.Fatal("expected index csrf cookie, got none")
+		}
+		if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+			t.Fatalf("expected http-only, same-site strict cookie, got %+v", cookie)
+		}
+		if !strings.Contains(rec.Body.String(), cookie.Value) {
+			t.Fatal("expected index page's form to carry csrf token")
+		}
+		return cookie
+	}
+
+	t.Run("starting a session with the index page's csrf token must set a hardened session cookie", func(t *testing.T) {
+		cookie := indexCookie(t)
+
+		r := newFormRequest("/", url.Values{"directory": {dir}, "csrf_token": {cookie.Value}})
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusFound, rec.Code, rec.Body)
+		}
+
+		sessCookie := findCookie(rec, "SESS_ID")
+		if sessCookie == nil {
+			t.Fatal("expected session cookie, got none")
+		}
+		if !sessCookie.HttpOnly || sessCookie.SameSite != http.SameSiteStrictMode || sessCookie.Path != "/" || sessCookie.MaxAge <= 0 {
+			t.Fatalf("expected http-only, same-site strict, expiring cookie at /, got %+v", sessCookie)
+		}
+	})
+
+	t.Run("offering to resume a previous session must carry the index page's csrf token", func(t *testing.T) {
+		cookie := indexCookie(t)
+		writeFiles(t, dir, map[string]string{"imgnheap20200102150405/by-tag/a.jpg": "a"})
+
+		r := newFormRequest("/", url.Values{"directory": {dir}, "csrf_token": {cookie.Value}})
+		r.AddCookie(cookie)
+
+		rec := serve(c, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), cookie.Value) {
+			t.Fatal("expected resume selection page's form to carry csrf token")
+		}
+	})
+
+	t.Run("resuming a previous session must remove the stale temporary files of copies that were cut short", func(t *testing.T) {
+		cookie := indexCookie(t)
+		tmpPath := path.Join(dir, "imgnheap20200102150405/by-tag/.b.jpg.123.imgnheap-tmp")
+		writeFiles(t, dir, map[string]string{strings.TrimPrefix(tmpPath, dir): "partial"})
+		stale := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(tmpPath, stale, stale); err != nil {
+			t.Fatal(err)
+		}
+
+		r := newFormRequest("/", url.Values{"directory": {dir}, "run": {"imgnheap20200102150405"}, "csrf_token": {cookie.Value}})
+		r.AddCookie(cookie)
+
+		if rec := serve(c, r); rec.Code != http.StatusFound {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusFound, rec.Code, rec.Body)
+		}
+		if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+			t.Fatalf("expected stale temporary file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("starting a session without the index page's csrf token must be forbidden", func(t *testing.T) {
+		cookie := indexCookie(t)
+
+		testCases := []struct {
+			cookie *http.Cookie
+			token  string
+		}{
+			{cookie: nil, token: ""},
+			{cookie: cookie, token: ""},
+			{cookie: cookie, token: "wrong"},
+			{cookie: nil, token: cookie.Value},
+			{cookie: &http.Cookie{Name: "INDEX_CSRF", Value: ""}, token: ""},
+		}
+
+		for idx, tc := range testCases {
+			r := newFormRequest("/", url.Values{"directory": {dir}, "csrf_token": {tc.token}})
+			if tc.cookie != nil {
+				r.AddCookie(tc.cookie)
+			}
+
+			rec := serve(c, r)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("tc %d: expected status %d, got %d", idx, http.StatusForbidden, rec.Code)
+			}
+			if sessCookie := findCookie(rec, "SESS_ID"); sessCookie != nil {
+				t.Errorf("tc %d: expected no session cookie, got %+v", idx, sessCookie)
+			}
+		}
+	})
+}
+
+func TestVerifyCSRFToken(t *testing.T) {
+	dir, teardown := newTempDir(t)
+	defer teardown()
+
+	c := newTestContainer(t, dir)
+	_, cookie := newTestSession(t, c, dir)
+
+	t.Run("submitting a form with the session's csrf token must be permitted", func(t *testing.T) {
+		testCases := []func(token string) *http.Request{
+			func(token string) *http.Request {
+				return newFormRequest("/reset", url.Values{"csrf_token": {token}})
+			},
+			func(token string) *http.Request {
+				r := newFormRequest("/reset", nil)
+				r.Header.Set("X-CSRF-Token", token)
+				return r
+			},
+		}
+
+		for idx, tc := range testCases {
+			// resetting ends the session, so each test case requires a session of its own
+			resetSess, resetCookie := newTestSession(t, c, dir)
+			r := tc(resetSess.CSRFToken)
+			r.AddCookie(resetCookie)
+
+			if rec := serve(c, r); rec.Code != http.StatusFound {
+				t.Errorf("tc %d: expected status %d, got %d", idx, http.StatusFound, rec.Code)
+			}
+		}
+	})
+
+	t.Run("submitting a form without the session's csrf token must be forbidden", func(t *testing.T) {
+		for idx, token := range []string{"", "wrong"} {
+			r := newFormRequest("/reset", url.Values{"csrf_token": {token}})
+			r.AddCookie(cookie)
+
+			if rec := serve(c, r); rec.Code != http.StatusForbidden {
+				t.Errorf("tc %d: expected status %d, got %d", idx, http.StatusForbidden, rec.Code)
+			}
+		}
+
+		// session must have survived
+		r := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+		r.AddCookie(cookie)
+		if rec := serve(c, r); rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("requesting a page without a valid session must redirect home and clear the cookie", func(t *testing.T) {
+		withoutCookie := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+		withInvalidCookie := httptest.NewRequest(http.MethodGet, "/catalog", nil)
+		withInvalidCookie.AddCookie(&http.Cookie{Name: "SESS_ID", Value: "invalid"})
+
+		for idx, r := range []*http.Request{withoutCookie, withInvalidCookie} {
+			rec := serve(c, r)
+			if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/" {
+				t.Errorf("tc %d: expected redirect to /, got %d to %s", idx, rec.Code, rec.Header().Get("Location"))
+			}
+		}
+
+		rec := serve(c, withInvalidCookie)
+		if cleared := findCookie(rec, "SESS_ID"); cleared == nil || cleared.MaxAge >= 0 {
+			t.Fatalf("expected session cookie to be cleared, got %+v", cleared)
+		}
+	})
+}
@@ -9,19 +9,37 @@ import (
 // RegisterRouter returns a new mux router with our handler routes attached
 func RegisterRouter(c app.Container) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(requireAccessToken(c))
 
 	// routes that require no session token
 	r.HandleFunc("/", indexHandler(c)).Methods(http.MethodGet)
-	r.HandleFunc("/", newSessionHandler(c)).Methods(http.MethodPost)
+	r.Handle("/", verifyIndexCSRFToken(c)(newSessionHandler(c))).Methods(http.MethodPost)
+
+	// routes of the json api, which must be registered ahead of the catch-all session subrouter
+	registerAPIRoutes(r, c)
 
 	// routes that require session token
 	s := r.PathPrefix("").Subrouter()
 	s.Use(addSessionToRequestContext(c))
+	s.Use(verifyCSRFToken(c))
 	s.HandleFunc("/catalog", catalogMethodSelectionHandler(c)).Methods(http.MethodGet)
-	s.HandleFunc("/catalog/by-date", processFilesByDateInFilename(c)).Methods(http.MethodPost)
+	s.HandleFunc("/catalog/by-date", planFilesByDate(c)).Methods(http.MethodPost)
+	s.HandleFunc("/catalog/by-date/confirm", processFilesByDate(c)).Methods(http.MethodPost)
 	s.HandleFunc("/catalog/by-tag", catalogByTag(c)).Methods(http.MethodGet)
 	s.HandleFunc("/catalog/by-tag", processFileByTag(c)).Methods(http.MethodPost)
-	s.HandleFunc("/file/{filename}", renderFile(c)).Methods(http.MethodGet)
+	s.HandleFunc("/file/{filename:.*}", renderFile(c)).Methods(http.MethodGet)
+	s.HandleFunc("/thumb/{filename:.*}", renderThumbnail(c)).Methods(http.MethodGet)
+	s.HandleFunc("/jobs/{id}", jobHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/jobs/{id}/events", jobEventsHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/jobs/{id}/cancel", cancelJobHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/duplicates", duplicatesHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/duplicates", resolveDuplicatesHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/duplicates/scan", scanDuplicatesHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/duplicates/similar", similarHandler(c)).Methods(http.MethodGet)
+	s.HandleFunc("/duplicates/similar", resolveSimilarHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/duplicates/similar/scan", scanSimilarHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/undo/last", undoLastHandler(c)).Methods(http.MethodPost)
+	s.HandleFunc("/undo/session", undoSessionHandler(c)).Methods(http.MethodPost)
 	s.HandleFunc("/reset", resetHandler(c)).Methods(http.MethodPost)
 
 	return r
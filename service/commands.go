@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// errUsage signifies that the arguments of a subcommand are invalid, and that its usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+// sessionPlaceholder represents the session directory that by-date creates, wherever it is printed in advance
+const sessionPlaceholder = "<session>"
+
+// command represents a subcommand of imgnheap
+type command struct {
+	name        string
+	args        string
+	description string
+	run         func(args []string) int
+}
+
+// commands returns the subcommands of imgnheap
+func commands() []command {
+	return []command{
+		{name: "serve", args: "[flags]", description: "run the web server (the default)", run: serveCommand},
+		{name: "by-date", args: "[flags] <dir>", description: "copy the images within a directory into sub-directories by date", run: byDateCommand},
+		{name: "plan", args: "[flags] <dir>", description: "print where by-date would copy the images within a directory to, without copying them", run: planCommand},
+		{name: "undo", args: "[flags] <journal>", description: "reverse the copies and moves recorded by a journal, or by a session directory's journal", run: undoCommand},
+	}
+}
+
+// runCommand runs the subcommand named by the provided arguments, and returns its exit code
+func runCommand(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		// flags without a subcommand are the server's, as they were before subcommands existed
+		return serveCommand(args)
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	if args[0] == "help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// printUsage writes the subcommands of imgnheap to the provided writer
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: imgnheap <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run imgnheap <command> -h for the flags of each command")
+}
+
+// catalogOptions represents the options of the subcommands that catalog a directory
+type catalogOptions struct {
+	dir            string
+	cfg            models.Config
+	scan           models.ScanOptions
+	layout         domain.DirLayout
+	collision      string
+	skipDuplicates bool
+}
+
+// parseCatalogArgs returns the catalog options described by the provided arguments of the named subcommand
+func parseCatalogArgs(name string, args []string) (catalogOptions, error) {
+	var cmd command
+	for _, c := range commands() {
+		if c.name == name {
+			cmd = c
+		}
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: imgnheap %s %s\n\n%s\n\nflags:\n", cmd.name, cmd.args, cmd.description)
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", "", "path to a YAML config file, whose settings apply unless overridden by a flag")
+	patternsPath := flags.String("patterns", "", "path to a JSON file of additional filename timestamp patterns")
+	recursive := flags.Bool("recursive", false, "include the images within sub-directories")
+	maxDepth := flags.Int("max-depth", 0, "maximum depth of sub-directories to include when recursive, or 0 for no limit")
+	excludes := flags.String("exclude", "", "comma-separated glob patterns to exclude when recursive, matched against each file or sub-directory's name and its path relative to the directory, e.g. .git,*/cache,*.tmp")
+	layout := flags.String("layout", domain.DefaultDirLayout, "layout of the sub-directories into which images are copied")
+	collision := flags.String("collision", domain.DefaultCollisionPolicy, "how to handle a file of the same name at the destination")
+	skipDuplicates := flags.Bool("skip-duplicates", false, "only copy the first of each set of images with identical contents")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return catalogOptions{}, err
+	} else if err != nil {
+		// usage has already been printed
+		return catalogOptions{}, errUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return catalogOptions{}, errUsage
+	}
+
+	dir, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return catalogOptions{}, err
+	}
+
+	cfg, err := domain.LoadConfigWithoutFlags(*configPath, os.Environ())
+	if err != nil {
+		return catalogOptions{}, err
+	}
+
+	// settings of the config apply unless overridden by a flag
+	provided := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { provided[f.Name] = true })
+	if provided["patterns"] {
+		cfg.PatternsPath = *patternsPath
+	}
+	if !provided["layout"] {
+		*layout = cfg.Layout
+	}
+	if !provided["collision"] {
+		*collision = cfg.CollisionPolicy
+	}
+
+	scan, err := domain.ParseScanOptions(*recursive, *maxDepth, *excludes)
+	if err != nil {
+		return catalogOptions{}, err
+	}
+
+	dirLayout, err := domain.ParseDirLayout(*layout)
+	if err != nil {
+		return catalogOptions{}, err
+	}
+
+	policy, err := domain.ParseCollisionPolicy(*collision)
+	if err != nil {
+		return catalogOptions{}, err
+	}
+
+	return catalogOptions{
+		dir:            dir,
+		cfg:            cfg,
+		scan:           scan,
+		layout:         dirLayout,
+		collision:      policy,
+		skipDuplicates: *skipDuplicates,
+	}, nil
+}
+
+// newCommandFileSystem returns a file system that only permits operations within the roots of the provided config,
+// and verifies the checksum of each copy if required
+func newCommandFileSystem(cfg models.Config) (app.FileSystem, error) {
+	return domain.NewRestrictedFileSystem(&domain.OsFileSystem{VerifyChecksum: cfg.VerifyCopies}, cfg.Roots)
+}
+
+// planByDate returns the agent and the by-date plan for a new session within the directory of the provided options
+func planByDate(opts catalogOptions) (*domain.FileSystemAgent, *models.Session, *models.Plan, error) {
+	// catalog the configured extensions throughout
+	domain.ImgFileExts = opts.cfg.Extensions
+
+	fs, err := newCommandFileSystem(opts.cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c := container{
+		store:    domain.NewInMemoryKeyValStore(),
+		fs:       fs,
+		patterns: mustNewTimestampPatternRegistry(opts.cfg.PatternsPath),
+		roots:    opts.cfg.Roots,
+		config:   opts.cfg,
+	}
+
+	sessAgent := domain.SessionAgent{SessionAgentInjector: c}
+
+	sess, err := sessAgent.NewSessionFromDirectoryAndTimestamp(opts.dir, time.Now(), opts.scan)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fsAgent := &domain.FileSystemAgent{FileSystemAgentInjector: c, CollisionPolicy: opts.collision}
+
+	plan, err := fsAgent.PlanByDate(sess, opts.layout, opts.skipDuplicates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return fsAgent, sess, plan, nil
+}
+
+// byDateCommand copies the images within the directory of the provided arguments into sub-directories by date,
+// and fails if any of them can't be copied
+func byDateCommand(args []string) int {
+	opts, err := parseCatalogArgs("by-date", args)
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	fsAgent, sess, plan, err := planByDate(opts)
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	// clear up after any copies of previous runs that were cut short
+	removed, err := fsAgent.RemoveStaleTempFilesFromSessionRuns(sess.BaseDir)
+	if len(removed) > 0 {
+		fmt.Printf("removed %d temporary files left behind by previous runs\n", len(removed))
+	}
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	fmt.Printf("copying %d files into %s\n", len(plan.Items), sess.FullDir(domain.SubDirByDate))
+	if len(plan.Duplicates) > 0 {
+		fmt.Printf("skipping %d duplicates\n", len(plan.Duplicates))
+	}
+
+	journal := domain.NewSessionJournal(sess)
+	fsAgent.Journal = journal
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	progress := domain.NewWriterJobProgress(os.Stdout, len(plan.Items))
+	err = fsAgent.ExecutePlan(ctx, plan, progress)
+	if err == context.Canceled {
+		err = errors.New("interrupted before every file was copied")
+	}
+
+	fmt.Println(progress.Summary())
+	if _, statErr := os.Stat(journal.Path()); statErr == nil {
+		fmt.Printf("to reverse, run: imgnheap undo %s\n", journal.Path())
+	}
+
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+	if progress.Failed() > 0 {
+		return exitFailure
+	}
+
+	return exitOK
+}
+
+// planCommand prints where the images within the directory of the provided arguments would be copied to by date,
+// relative to the session directory that by-date would create, whose name depends on when it runs
+func planCommand(args []string) int {
+	opts, err := parseCatalogArgs("plan", args)
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	_, sess, plan, err := planByDate(opts)
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	for _, item := range plan.Items {
+		dest := models.NewFile(item.File.Name, item.File.Ext, item.DestDir, nil).RelativePath(sess.FullDir())
+		fmt.Printf("%s -> %s\n", item.File.RelativePath(sess.BaseDir), path.Join(sessionPlaceholder, dest))
+	}
+	for _, file := range plan.Duplicates {
+		fmt.Printf("%s: duplicate, skipped\n", file.RelativePath(sess.BaseDir))
+	}
+	fmt.Printf("%d files planned, %d duplicates skipped\n", len(plan.Items), len(plan.Duplicates))
+	fmt.Printf("%s is the new directory within %s that by-date creates, named by when it runs, such as %s\n", sessionPlaceholder, sess.BaseDir, sess.SubDir)
+
+	return exitOK
+}
+
+// undoCommand reverses the copies and moves recorded by the journal of the provided arguments
+func undoCommand(args []string) int {
+	flags := flag.NewFlagSet("undo", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: imgnheap undo [flags] <journal>\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", "", "path to a YAML config file, whose roots and verify-copies settings apply")
+	last := flags.Bool("last", false, "only reverse the most recent copy or move")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return exitOK
+	} else if err != nil {
+		// usage has already been printed
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	cfg, err := domain.LoadConfigWithoutFlags(*configPath, os.Environ())
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	fs, err := newCommandFileSystem(cfg)
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	journalPath := flags.Arg(0)
+	if info, err := os.Stat(journalPath); err != nil {
+		return exitCodeFromError(err)
+	} else if info.IsDir() {
+		// session directory has been provided
+		journalPath = path.Join(journalPath, domain.JournalFileName)
+		if _, err := os.Stat(journalPath); err != nil {
+			return exitCodeFromError(err)
+		}
+	}
+
+	fsAgent := domain.FileSystemAgent{
+		FileSystemAgentInjector: container{fs: fs, roots: cfg.Roots, config: cfg},
+		Journal:                 domain.NewFileJournal(journalPath),
+	}
+
+	var undone []models.JournalEntry
+	if *last {
+		var entry models.JournalEntry
+		entry, err = fsAgent.UndoLast()
+		if err == nil {
+			undone = append(undone, entry)
+		}
+	} else {
+		undone, err = fsAgent.UndoAll()
+	}
+
+	for _, entry := range undone {
+		fmt.Printf("reversed %s of %s to %s\n", entry.Operation, entry.Source, entry.Destination)
+	}
+	fmt.Printf("%d reversed\n", len(undone))
+
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	return exitOK
+}
+
+// signalContext returns a context that is cancelled on the first interrupt or termination signal,
+// after which a second signal exits straight away
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "received %s, stopping after the current file (repeat to exit straight away)\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// exitCodeFromError writes the provided error (if any) and returns the matching exit code,
+// where flag.ErrHelp and errUsage signify that usage has already been printed, on request or otherwise
+func exitCodeFromError(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	}
+
+	fmt.Fprintf(os.Stderr, "imgnheap: %s\n", err)
+	return exitFailure
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"imgnheap/service/models"
+	"path"
+	"sort"
+	"strings"
+)
+
+// systemDirNames represents the names of directories that are managed by an operating system rather than a user
+var systemDirNames = []string{
+	"$RECYCLE.BIN",
+	"System Volume Information",
+	"lost+found",
+}
+
+// IsHiddenDirectory returns true if the provided directory is hidden or managed by the operating system, otherwise false
+func IsHiddenDirectory(dir models.Directory) bool {
+	return strings.HasPrefix(dir.Name, ".") || contains(systemDirNames, dir.Name)
+}
+
+// BrowseDirectory returns the directories within the provided directory path, which must be within one of the provided
+// roots, including the count of image files within each one. If the directory path is empty, the roots themselves are
+// returned. Hidden and system directories are omitted unless showHidden is true
+func (f *FileSystemAgent) BrowseDirectory(roots []string, dirPath string, showHidden bool) (models.DirectoryListing, error) {
+	if dirPath == "" {
+		return f.browseRoots(roots)
+	}
+
+	dirPath = path.Clean(dirPath)
+	root, ok := rootOf(roots, dirPath)
+	if !ok {
+		return models.DirectoryListing{}, ValidationError{Err: fmt.Errorf("not within a browsable directory: %s", dirPath)}
+	}
+	if !f.FileSystem().IsDirectory(dirPath) {
+		return models.DirectoryListing{}, ValidationError{Err: fmt.Errorf("not a directory: %s", dirPath)}
+	}
+
+	listing := models.DirectoryListing{Path: dirPath}
+	if dirPath != root {
+		listing.Parent = path.Dir(dirPath)
+	}
+
+	files, err := f.GetFilesFromDirectoryByExtension(dirPath, ImgFileExts...)
+	if err != nil {
+		return models.DirectoryListing{}, err
+	}
+	listing.FileCount = len(files)
+
+	dirs, err := f.GetDirectoriesWithFileCountByExtension(dirPath, ImgFileExts...)
+	if err != nil {
+		return models.DirectoryListing{}, err
+	}
+	for _, dir := range dirs {
+		if !showHidden && IsHiddenDirectory(dir) {
+			continue
+		}
+		listing.Directories = append(listing.Directories, dir)
+	}
+
+	sort.Slice(listing.Directories, func(i, j int) bool {
+		return strings.ToLower(listing.Directories[i].Name) < strings.ToLower(listing.Directories[j].Name)
+	})
+
+	return listing, nil
+}
+
+// browseRoots returns the provided roots that are directories, including the count of image files within each one
+func (f *FileSystemAgent) browseRoots(roots []string) (models.DirectoryListing, error) {
+	var listing models.DirectoryListing
+
+	for _, root := range roots {
+		root = path.Clean(root)
+		if !f.FileSystem().IsDirectory(root) {
+			continue
+		}
+
+		files, err := f.GetFilesFromDirectoryByExtension(root, ImgFileExts...)
+		if err != nil {
+			return models.DirectoryListing{}, err
+		}
+
+		listing.Directories = append(listing.Directories, models.Directory{
+			Name:      path.Base(root),
+			DirPath:   path.Dir(root),
+			FileCount: len(files),
+		})
+	}
+
+	return listing, nil
+}
+
+// rootOf returns the root of the provided roots that the provided path is within, and whether there is one
+func rootOf(roots []string, p string) (string, bool) {
+	for _, root := range roots {
+		root = path.Clean(root)
+		if p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/") {
+			return root, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,126 @@
+package domain_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileSystemAgent_BrowseDirectory(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	root := path.Join(baseDir, "root")
+	for _, relPath := range []string{
+		"root/a.jpg",
+		"root/Photos/b.jpg",
+		"root/Photos/c.png",
+		"root/Photos/deeper/d.jpg",
+		"root/.cache/e.jpg",
+		"root/lost+found/f.jpg",
+		"root/documents/notes.txt",
+		"outside/g.jpg",
+	} {
+		fullPath := path.Join(baseDir, relPath)
+		if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+	roots := []string{root, path.Join(baseDir, "missing")}
+
+	t.Run("browsing without a directory must provide the roots that exist", func(t *testing.T) {
+		listing, err := fsAgent.BrowseDirectory(roots, "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := models.DirectoryListing{
+			Directories: []models.Directory{{Name: "root", DirPath: baseDir, FileCount: 1}},
+		}
+		if diff := cmp.Diff(expected, listing); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, listing, diff)
+		}
+	})
+
+	t.Run("browsing a directory must provide its sub-directories with image counts", func(t *testing.T) {
+		testCases := []struct {
+			dirPath    string
+			showHidden bool
+			expected   models.DirectoryListing
+		}{
+			{
+				dirPath: root,
+				expected: models.DirectoryListing{
+					Path:      root,
+					FileCount: 1,
+					Directories: []models.Directory{
+						{Name: "documents", DirPath: root},
+						{Name: "Photos", DirPath: root, FileCount: 2},
+					},
+				},
+			},
+			{
+				dirPath:    root + "/",
+				showHidden: true,
+				expected: models.DirectoryListing{
+					Path:      root,
+					FileCount: 1,
+					Directories: []models.Directory{
+						{Name: ".cache", DirPath: root, FileCount: 1},
+						{Name: "documents", DirPath: root},
+						{Name: "lost+found", DirPath: root, FileCount: 1},
+						{Name: "Photos", DirPath: root, FileCount: 2},
+					},
+				},
+			},
+			{
+				dirPath: path.Join(root, "Photos"),
+				expected: models.DirectoryListing{
+					Path:      path.Join(root, "Photos"),
+					Parent:    root,
+					FileCount: 2,
+					Directories: []models.Directory{
+						{Name: "deeper", DirPath: path.Join(root, "Photos"), FileCount: 1},
+					},
+				},
+			},
+		}
+
+		for idx, tc := range testCases {
+			listing, err := fsAgent.BrowseDirectory(roots, tc.dirPath, tc.showHidden)
+			if err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			if diff := cmp.Diff(tc.expected, listing); diff != "" {
+				t.Errorf("tc %d: want %+v, got %+v, diff: %s", idx, tc.expected, listing, diff)
+			}
+		}
+	})
+
+	t.Run("browsing a directory outside of the roots must return a validation error", func(t *testing.T) {
+		for idx, dirPath := range []string{
+			path.Join(baseDir, "outside"),
+			path.Join(root, "..", "outside"),
+			root + "-sibling",
+			path.Join(root, "missing"),
+		} {
+			_, err := fsAgent.BrowseDirectory(roots, dirPath, false)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"fmt"
+	"imgnheap/service/models"
+)
+
+const (
+	CollisionPolicySkip          = "skip"
+	CollisionPolicyOverwrite     = "overwrite"
+	CollisionPolicyRename        = "rename"
+	CollisionPolicySkipIdentical = "skip-identical"
+
+	// CollisionPolicyRemoveIdentical skips copying a file whose contents are identical to its destination, like
+	// CollisionPolicySkipIdentical, but removes the original of a move as its contents are already there
+	CollisionPolicyRemoveIdentical = "remove-identical"
+)
+
+// DefaultCollisionPolicy represents the collision policy used when none has been selected
+const DefaultCollisionPolicy = CollisionPolicySkipIdentical
+
+const (
+	ProcessOutcomeCopied           = "copied"
+	ProcessOutcomeMoved            = "moved"
+	ProcessOutcomeSkipped          = "skipped"
+	ProcessOutcomeSkippedIdentical = "skipped identical"
+	ProcessOutcomeRemovedIdentical = "removed identical"
+	ProcessOutcomeOverwritten      = "overwritten"
+	ProcessOutcomeRenamed          = "renamed"
+	ProcessOutcomeNotJournaled     = "not journaled"
+)
+
+// movedOutcomes represents the outcomes of moving a file with which the file has been moved
+var movedOutcomes = []string{
+	ProcessOutcomeMoved,
+	ProcessOutcomeRenamed,
+	ProcessOutcomeOverwritten,
+	ProcessOutcomeNotJournaled,
+}
+
+// CountMoved returns the number of the provided results of moving files with which the file has been moved
+func CountMoved(results []models.ProcessResult) int {
+	count := 0
+	for _, result := range results {
+		if contains(movedOutcomes, result.Outcome) {
+			count++
+		}
+	}
+	return count
+}
+
+// maxRenameAttempts limits the number of suffixes that are tried when renaming a file to avoid a collision
+const maxRenameAttempts = 1000
+
+// CollisionPolicies returns the collision policies that are offered for selection
+func CollisionPolicies() []models.CollisionPolicyOption {
+	return []models.CollisionPolicyOption{
+		{Name: "Skip if identical, otherwise rename", Policy: CollisionPolicySkipIdentical},
+		{Name: "Remove original of a move if identical, otherwise rename", Policy: CollisionPolicyRemoveIdentical},
+		{Name: "Rename with suffix", Policy: CollisionPolicyRename},
+		{Name: "Skip", Policy: CollisionPolicySkip},
+		{Name: "Overwrite", Policy: CollisionPolicyOverwrite},
+	}
+}
+
+// ParseCollisionPolicy validates the provided collision policy, returning the default policy if none is provided
+func ParseCollisionPolicy(policy string) (string, error) {
+	if policy == "" {
+		return DefaultCollisionPolicy, nil
+	}
+
+	for _, option := range CollisionPolicies() {
+		if option.Policy == policy {
+			return policy, nil
+		}
+	}
+
+	return "", ValidationError{Err: fmt.Errorf("invalid collision policy: %s", policy)}
+}
+
+// resolveCollision returns the result of applying the agent's collision policy to the provided file and destination.
+// The result's outcome is only populated if the destination is already taken
+func (f *FileSystemAgent) resolveCollision(file, dest models.File) (models.ProcessResult, error) {
+	result := models.ProcessResult{File: file, Destination: dest}
+
+	taken, err := f.isTaken(dest)
+	if err != nil || !taken {
+		return result, err
+	}
+
+	policy, err := ParseCollisionPolicy(f.CollisionPolicy)
+	if err != nil {
+		return result, err
+	}
+
+	switch policy {
+	case CollisionPolicySkip:
+		result.Outcome = ProcessOutcomeSkipped
+		return result, nil
+	case CollisionPolicyOverwrite:
+		result.Outcome = ProcessOutcomeOverwritten
+		return result, nil
+	case CollisionPolicySkipIdentical, CollisionPolicyRemoveIdentical:
+		identical, err := f.isIdentical(file, dest)
+		if err != nil {
+			return result, err
+		}
+		if identical {
+			result.Outcome = ProcessOutcomeSkippedIdentical
+			return result, nil
+		}
+	}
+
+	// rename by appending the first free suffix
+	for idx := 1; idx <= maxRenameAttempts; idx++ {
+		renamed := models.NewFile(fmt.Sprintf("%s-%d", dest.Name, idx), dest.Ext, dest.DirPath, nil)
+
+		taken, err := f.isTaken(renamed)
+		if err != nil {
+			return result, err
+		}
+		if !taken {
+			result.Destination = renamed
+			result.Outcome = ProcessOutcomeRenamed
+			return result, nil
+		}
+	}
+
+	return result, fmt.Errorf("cannot rename %s: too many files of the same name", dest.FullPath())
+}
+
+// isTaken returns true if a file of the same path as the provided file already exists, otherwise false
+func (f *FileSystemAgent) isTaken(file models.File) (bool, error) {
+	fi, err := f.FileSystem().Stat(file)
+	if err != nil {
+		if _, ok := err.(NotFoundError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if fi.IsDir() {
+		return false, ValidationError{Err: fmt.Errorf("destination %s is a directory", file.FullPath())}
+	}
+
+	return true, nil
+}
+
+// isIdentical returns true if the contents of the two provided files are identical, otherwise false
+func (f *FileSystemAgent) isIdentical(a, b models.File) (bool, error) {
+	aInfo, err := f.FileSystem().Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := f.FileSystem().Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+
+	aChecksum, err := f.Checksum(a)
+	if err != nil {
+		return false, err
+	}
+	bChecksum, err := f.Checksum(b)
+	if err != nil {
+		return false, err
+	}
+
+	return aChecksum == bChecksum, nil
+}
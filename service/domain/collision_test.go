@@ -0,0 +1,269 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileSystemAgent_ProcessFileByCopy_Collisions(t *testing.T) {
+	setup := func(t *testing.T, existing string) (string, string, func()) {
+		baseDir, err := ioutil.TempDir("", "imgnheap")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		destDir := path.Join(baseDir, "dest")
+		if err := os.MkdirAll(destDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(baseDir, "a.jpg"), []byte("source"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(destDir, "a.jpg"), []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		return baseDir, destDir, func() { os.RemoveAll(baseDir) }
+	}
+
+	contentsOf := func(t *testing.T, filePath string) string {
+		contents, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(contents)
+	}
+
+	t.Run("copying a file onto an existing file must apply the collision policy", func(t *testing.T) {
+		testCases := []struct {
+			policy              string
+			existing            string
+			expectedOutcome     string
+			expectedDestination string
+			expectedContents    string
+		}{
+			{
+				policy:              domain.CollisionPolicySkip,
+				existing:            "existing",
+				expectedOutcome:     domain.ProcessOutcomeSkipped,
+				expectedDestination: "a.jpg",
+				expectedContents:    "existing",
+			},
+			{
+				policy:              domain.CollisionPolicyOverwrite,
+				existing:            "existing",
+				expectedOutcome:     domain.ProcessOutcomeOverwritten,
+				expectedDestination: "a.jpg",
+				expectedContents:    "source",
+			},
+			{
+				policy:              domain.CollisionPolicyRename,
+				existing:            "source",
+				expectedOutcome:     domain.ProcessOutcomeRenamed,
+				expectedDestination: "a-1.jpg",
+				expectedContents:    "source",
+			},
+			{
+				policy:              domain.CollisionPolicySkipIdentical,
+				existing:            "source",
+				expectedOutcome:     domain.ProcessOutcomeSkippedIdentical,
+				expectedDestination: "a.jpg",
+				expectedContents:    "source",
+			},
+			{
+				policy:              domain.CollisionPolicyRemoveIdentical,
+				existing:            "source",
+				expectedOutcome:     domain.ProcessOutcomeSkippedIdentical,
+				expectedDestination: "a.jpg",
+				expectedContents:    "source",
+			},
+			{
+				policy:              domain.CollisionPolicySkipIdentical,
+				existing:            "existing",
+				expectedOutcome:     domain.ProcessOutcomeRenamed,
+				expectedDestination: "a-1.jpg",
+				expectedContents:    "source",
+			},
+		}
+
+		for idx, tc := range testCases {
+			baseDir, destDir, teardown := setup(t, tc.existing)
+
+			fsAgent := domain.FileSystemAgent{
+				FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+				CollisionPolicy:         tc.policy,
+			}
+
+			result, err := fsAgent.ProcessFileByCopy(models.NewFile("a", "jpg", baseDir, nil), destDir)
+			if err != nil {
+				teardown()
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			if result.Outcome != tc.expectedOutcome {
+				t.Errorf("tc %d: expected outcome %s, got %s", idx, tc.expectedOutcome, result.Outcome)
+			}
+			if result.Destination.NameWithExt() != tc.expectedDestination {
+				t.Errorf("tc %d: expected destination %s, got %s", idx, tc.expectedDestination, result.Destination.NameWithExt())
+			}
+			if actual := contentsOf(t, path.Join(destDir, tc.expectedDestination)); actual != tc.expectedContents {
+				t.Errorf("tc %d: expected contents %s, got %s", idx, tc.expectedContents, actual)
+			}
+
+			teardown()
+		}
+	})
+
+	t.Run("copying a file with no collision must not be affected by the collision policy", func(t *testing.T) {
+		baseDir, destDir, teardown := setup(t, "existing")
+		defer teardown()
+
+		if err := ioutil.WriteFile(path.Join(baseDir, "b.jpg"), []byte("b"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			CollisionPolicy:         domain.CollisionPolicySkip,
+		}
+
+		result, err := fsAgent.ProcessFileByCopy(models.NewFile("b", "jpg", baseDir, nil), destDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Outcome != domain.ProcessOutcomeCopied {
+			t.Fatalf("expected outcome %s, got %s", domain.ProcessOutcomeCopied, result.Outcome)
+		}
+	})
+
+	t.Run("undoing a renamed move must restore the file's original name", func(t *testing.T) {
+		baseDir, destDir, teardown := setup(t, "existing")
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, domain.JournalFileName)),
+			CollisionPolicy:         domain.CollisionPolicyRename,
+		}
+
+		if _, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), destDir); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fsAgent.UndoLast(); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := contentsOf(t, path.Join(baseDir, "a.jpg")); actual != "source" {
+			t.Fatalf("expected contents source, got %s", actual)
+		}
+		if actual := contentsOf(t, path.Join(destDir, "a.jpg")); actual != "existing" {
+			t.Fatalf("expected contents existing, got %s", actual)
+		}
+	})
+
+	t.Run("moving a file onto an identical file must leave the original where it is", func(t *testing.T) {
+		baseDir, destDir, teardown := setup(t, "source")
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, domain.JournalFileName)),
+			CollisionPolicy:         domain.CollisionPolicySkipIdentical,
+		}
+
+		result, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), destDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Outcome != domain.ProcessOutcomeSkippedIdentical {
+			t.Fatalf("expected outcome %s, got %s", domain.ProcessOutcomeSkippedIdentical, result.Outcome)
+		}
+		if actual := contentsOf(t, path.Join(baseDir, "a.jpg")); actual != "source" {
+			t.Fatalf("expected contents source, got %s", actual)
+		}
+
+		_, err = fsAgent.UndoLast()
+		if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected nothing to undo, got %v", err)
+		}
+	})
+
+	t.Run("moving a file onto an identical file with the remove policy must remove the original, which undoing must restore", func(t *testing.T) {
+		baseDir, destDir, teardown := setup(t, "source")
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, domain.JournalFileName)),
+			CollisionPolicy:         domain.CollisionPolicyRemoveIdentical,
+		}
+
+		result, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), destDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Outcome != domain.ProcessOutcomeRemovedIdentical {
+			t.Fatalf("expected outcome %s, got %s", domain.ProcessOutcomeRemovedIdentical, result.Outcome)
+		}
+		if _, err := os.Stat(path.Join(baseDir, "a.jpg")); !os.IsNotExist(err) {
+			t.Fatalf("expected original to be removed, got %v", err)
+		}
+
+		entry, err := fsAgent.UndoLast()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Operation != models.JournalOperationRemove {
+			t.Fatalf("expected %s, got %s", models.JournalOperationRemove, entry.Operation)
+		}
+		if actual := contentsOf(t, path.Join(baseDir, "a.jpg")); actual != "source" {
+			t.Fatalf("expected contents source, got %s", actual)
+		}
+		if actual := contentsOf(t, path.Join(destDir, "a.jpg")); actual != "source" {
+			t.Fatalf("expected contents source, got %s", actual)
+		}
+	})
+
+	t.Run("undoing an overwrite must return a validation error", func(t *testing.T) {
+		baseDir, destDir, teardown := setup(t, "existing")
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, domain.JournalFileName)),
+			CollisionPolicy:         domain.CollisionPolicyOverwrite,
+		}
+
+		if _, err := fsAgent.ProcessFileByCopy(models.NewFile("a", "jpg", baseDir, nil), destDir); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := fsAgent.UndoLast()
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+}
+
+func TestParseCollisionPolicy(t *testing.T) {
+	t.Run("parsing an empty collision policy must return the default policy", func(t *testing.T) {
+		policy, err := domain.ParseCollisionPolicy("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy != domain.DefaultCollisionPolicy {
+			t.Fatalf("expected %s, got %s", domain.DefaultCollisionPolicy, policy)
+		}
+	})
+
+	t.Run("parsing an unknown collision policy must return a validation error", func(t *testing.T) {
+		_, err := domain.ParseCollisionPolicy("shred")
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+}
@@ -0,0 +1,467 @@
+package domain
+
+import (
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigEnvPrefix represents the prefix of the environment variables that provide config settings
+const ConfigEnvPrefix = "IMGNHEAP_"
+
+// configFileSettingName represents the name of the flag that provides the path of a config file,
+// which can also be provided by the matching environment variable
+const configFileSettingName = "config"
+
+// minConfigTimeout represents the shortest timeout that can be configured, which guards against durations
+// that have been provided without a unit
+const minConfigTimeout = time.Second
+
+// ConfigSetting represents a single config setting that can be provided by a config file, an environment variable
+// or a flag, in increasing order of precedence
+type ConfigSetting struct {
+	Name    string
+	Usage   string
+	boolean bool
+	get     func(cfg models.Config) string
+	set     func(cfg *models.Config, val string) error
+}
+
+// EnvName returns the name of the environment variable that provides the setting
+func (c ConfigSetting) EnvName() string {
+	return ConfigEnvPrefix + strings.ToUpper(strings.Replace(c.Name, "-", "_", -1))
+}
+
+// Set applies the provided value of the setting to the provided config
+func (c ConfigSetting) Set(cfg *models.Config, val string) error {
+	if err := c.set(cfg, strings.TrimSpace(val)); err != nil {
+		return ValidationError{Err: fmt.Errorf("invalid %s: %s", c.Name, err)}
+	}
+	return nil
+}
+
+// ConfigSettings returns the settings that make up a config
+func ConfigSettings() []ConfigSetting {
+	return []ConfigSetting{
+		{
+			Name:  "listen-addr",
+			Usage: "address on which to listen, as host:port",
+			get:   func(cfg models.Config) string { return cfg.ListenAddr },
+			set:   func(cfg *models.Config, val string) error { cfg.ListenAddr = val; return nil },
+		},
+		{
+			Name:  "read-timeout",
+			Usage: "maximum duration for reading a request",
+			get:   func(cfg models.Config) string { return cfg.ReadTimeout.String() },
+			set:   func(cfg *models.Config, val string) error { return setDuration(&cfg.ReadTimeout, val) },
+		},
+		{
+			Name:  "write-timeout",
+			Usage: "maximum duration for writing a response",
+			get:   func(cfg models.Config) string { return cfg.WriteTimeout.String() },
+			set:   func(cfg *models.Config, val string) error { return setDuration(&cfg.WriteTimeout, val) },
+		},
+		{
+			Name:  "shutdown-timeout",
+			Usage: "maximum duration for finishing requests, jobs and file operations when shutting down",
+			get:   func(cfg models.Config) string { return cfg.ShutdownTimeout.String() },
+			set:   func(cfg *models.Config, val string) error { return setDuration(&cfg.ShutdownTimeout, val) },
+		},
+		{
+			Name:  "roots",
+			Usage: "comma-separated list of the only directories that can be browsed and processed",
+			get:   func(cfg models.Config) string { return strings.Join(cfg.Roots, ",") },
+			set:   func(cfg *models.Config, val string) error { cfg.Roots = ParseList(val); return nil },
+		},
+		{
+			Name:  "extensions",
+			Usage: "comma-separated list of the file extensions to catalog",
+			get:   func(cfg models.Config) string { return strings.Join(cfg.Extensions, ",") },
+			set:   func(cfg *models.Config, val string) error { cfg.Extensions = ParseList(val); return nil },
+		},
+		{
+			Name:  "layout",
+			Usage: "layout that is selected by default when processing files by date",
+			get:   func(cfg models.Config) string { return cfg.Layout },
+			set:   func(cfg *models.Config, val string) error { cfg.Layout = val; return nil },
+		},
+		{
+			Name:  "collision",
+			Usage: "collision policy that is selected by default",
+			get:   func(cfg models.Config) string { return cfg.CollisionPolicy },
+			set:   func(cfg *models.Config, val string) error { cfg.CollisionPolicy = val; return nil },
+		},
+		{
+			Name:  "store",
+			Usage: "backend in which to hold sessions, either memory or file (file if a store path is provided)",
+			get:   func(cfg models.Config) string { return cfg.Store },
+			set:   func(cfg *models.Config, val string) error { cfg.Store = val; return nil },
+		},
+		{
+			Name:  "store-path",
+			Usage: "path to a file in which to persist sessions",
+			get:   func(cfg models.Config) string { return cfg.StorePath },
+			set:   func(cfg *models.Config, val string) error { cfg.StorePath = val; return nil },
+		},
+		{
+			Name:  "patterns",
+			Usage: "path to a JSON file of additional filename timestamp patterns",
+			get:   func(cfg models.Config) string { return cfg.PatternsPath },
+			set:   func(cfg *models.Config, val string) error { cfg.PatternsPath = val; return nil },
+		},
+		{
+			Name:  "thumb-cache-dir",
+			Usage: "directory in which to cache generated thumbnails",
+			get:   func(cfg models.Config) string { return cfg.ThumbCacheDir },
+			set:   func(cfg *models.Config, val string) error { cfg.ThumbCacheDir = val; return nil },
+		},
+		{
+			Name:  "thumb-cache-size",
+			Usage: "maximum size of the thumbnail cache, in megabytes",
+			get:   func(cfg models.Config) string { return strconv.FormatInt(cfg.ThumbCacheSize, 10) },
+			set: func(cfg *models.Config, val string) error {
+				size, err := strconv.ParseInt(val, 10, 64)
+				if err != nil {
+					return fmt.Errorf("not a whole number: %s", val)
+				}
+				cfg.ThumbCacheSize = size
+				return nil
+			},
+		},
+		{
+			Name:    "verify-copies",
+			Usage:   "read back each copy and compare its checksum with the original before putting it in place",
+			boolean: true,
+			get:     func(cfg models.Config) string { return strconv.FormatBool(cfg.VerifyCopies) },
+			set: func(cfg *models.Config, val string) error {
+				verify, err := strconv.ParseBool(val)
+				if err != nil {
+					return fmt.Errorf("not true or false: %s", val)
+				}
+				cfg.VerifyCopies = verify
+				return nil
+			},
+		},
+		{
+			Name:    "require-access-token",
+			Usage:   "require the access token printed on launch in order to use imgnheap",
+			boolean: true,
+			get:     func(cfg models.Config) string { return strconv.FormatBool(cfg.RequireAccessToken) },
+			set: func(cfg *models.Config, val string) error {
+				required, err := strconv.ParseBool(val)
+				if err != nil {
+					return fmt.Errorf("not true or false: %s", val)
+				}
+				cfg.RequireAccessToken = required
+				return nil
+			},
+		},
+	}
+}
+
+// DefaultConfig returns the config that applies where no setting has been provided
+func DefaultConfig() models.Config {
+	return models.Config{
+		ListenAddr:      ":8080",
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    15 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+		Roots:           []string{defaultRoot()},
+		Extensions:      append([]string(nil), defaultImgFileExts...),
+		Layout:          DefaultDirLayout,
+		CollisionPolicy: DefaultCollisionPolicy,
+		ThumbCacheDir:   path.Join(os.TempDir(), "imgnheap-thumbs"),
+		ThumbCacheSize:  256,
+	}
+}
+
+// LoadConfig adds a flag for each config setting to the provided flag set, parses the provided arguments with it,
+// and returns the config described by (in increasing order of precedence) the defaults, the config file named by
+// the config flag or environment variable, the provided environment and the flags, once it has been validated
+func LoadConfig(flags *flag.FlagSet, args []string, environ []string) (models.Config, error) {
+	cfg := DefaultConfig()
+	settings := ConfigSettings()
+
+	configPath := flags.String(configFileSettingName, "", "path to a YAML config file")
+	for _, setting := range settings {
+		flags.Var(&configFlag{val: setting.get(cfg), isBool: setting.boolean}, setting.Name, setting.Usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return models.Config{}, err
+	}
+
+	if err := applyConfigFileAndEnv(&cfg, *configPath, environ); err != nil {
+		return models.Config{}, err
+	}
+
+	// apply flags that have been provided
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, setting := range settings {
+			if setting.Name == f.Name && flagErr == nil {
+				if err := setting.Set(&cfg, f.Value.String()); err != nil {
+					flagErr = ValidationError{Err: fmt.Errorf("-%s: %s", f.Name, err)}
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return models.Config{}, flagErr
+	}
+
+	normaliseConfig(&cfg)
+	if err := ValidateConfig(cfg); err != nil {
+		return models.Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigWithoutFlags returns the config described by (in increasing order of precedence) the defaults,
+// the config file at the provided path (otherwise named by the config environment variable) and the provided
+// environment, once it has been validated
+func LoadConfigWithoutFlags(configPath string, environ []string) (models.Config, error) {
+	cfg := DefaultConfig()
+
+	if err := applyConfigFileAndEnv(&cfg, configPath, environ); err != nil {
+		return models.Config{}, err
+	}
+
+	normaliseConfig(&cfg)
+	if err := ValidateConfig(cfg); err != nil {
+		return models.Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// ValidateConfig returns a validation error that describes every invalid setting of the provided config, if any
+func ValidateConfig(cfg models.Config) error {
+	var problems []string
+	invalid := func(name string, format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", name, fmt.Sprintf(format, a...)))
+	}
+
+	if _, port, err := net.SplitHostPort(cfg.ListenAddr); err != nil {
+		invalid("listen-addr", "must be host:port, such as :8080: %s", cfg.ListenAddr)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		invalid("listen-addr", "invalid port: %s", port)
+	}
+
+	if cfg.ReadTimeout < minConfigTimeout {
+		invalid("read-timeout", "must be at least %s, with a unit such as 15s: %s", minConfigTimeout, cfg.ReadTimeout)
+	}
+	if cfg.WriteTimeout < minConfigTimeout {
+		invalid("write-timeout", "must be at least %s, with a unit such as 15s: %s", minConfigTimeout, cfg.WriteTimeout)
+	}
+	if cfg.ShutdownTimeout < minConfigTimeout {
+		invalid("shutdown-timeout", "must be at least %s, with a unit such as 30s: %s", minConfigTimeout, cfg.ShutdownTimeout)
+	}
+
+	if len(cfg.Roots) == 0 {
+		invalid("roots", "at least one root directory is required")
+	}
+	for _, root := range cfg.Roots {
+		if !path.IsAbs(root) {
+			invalid("roots", "must be an absolute path: %s", root)
+		} else if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			invalid("roots", "not a directory: %s", root)
+		}
+	}
+
+	if len(cfg.Extensions) == 0 {
+		invalid("extensions", "at least one file extension is required")
+	}
+	for _, ext := range cfg.Extensions {
+		if !isValidFileExt(ext) {
+			invalid("extensions", "must only contain letters and digits: %s", ext)
+		}
+	}
+
+	if _, err := ParseDirLayout(cfg.Layout); err != nil {
+		invalid("layout", "%s", err)
+	}
+	if _, err := ParseCollisionPolicy(cfg.CollisionPolicy); err != nil {
+		invalid("collision", "%s", err)
+	}
+
+	switch cfg.Store {
+	case models.StoreBackendMemory:
+	case models.StoreBackendFile:
+		if cfg.StorePath == "" {
+			invalid("store-path", "required by the %s store", models.StoreBackendFile)
+		}
+	default:
+		invalid("store", "must be %s or %s: %s", models.StoreBackendMemory, models.StoreBackendFile, cfg.Store)
+	}
+
+	if cfg.ThumbCacheDir == "" {
+		invalid("thumb-cache-dir", "a directory is required")
+	}
+	if cfg.ThumbCacheSize <= 0 {
+		invalid("thumb-cache-size", "must be greater than zero: %d", cfg.ThumbCacheSize)
+	}
+
+	if len(problems) > 0 {
+		return ValidationError{Err: fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))}
+	}
+
+	return nil
+}
+
+// ParseList returns the non-empty items within the provided comma-separated list
+func ParseList(list string) []string {
+	var parsed []string
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parsed = append(parsed, item)
+	}
+
+	return parsed
+}
+
+// applyConfigFileAndEnv applies the config file at the provided path (otherwise named by the config environment
+// variable) followed by the settings of the provided environment to the provided config
+func applyConfigFileAndEnv(cfg *models.Config, configPath string, environ []string) error {
+	env := make(map[string]string)
+	for _, pair := range environ {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	// apply config file
+	if configPath == "" {
+		configPath = env[ConfigEnvPrefix+strings.ToUpper(configFileSettingName)]
+	}
+	if configPath != "" {
+		if err := loadConfigFile(configPath, cfg); err != nil {
+			return err
+		}
+	}
+
+	// apply environment variables
+	for _, setting := range ConfigSettings() {
+		val, ok := env[setting.EnvName()]
+		if !ok {
+			continue
+		}
+		if err := setting.Set(cfg, val); err != nil {
+			return ValidationError{Err: fmt.Errorf("%s: %s", setting.EnvName(), err)}
+		}
+	}
+
+	return nil
+}
+
+// loadConfigFile applies the settings held by the YAML file at the provided path to the provided config
+func loadConfigFile(filePath string, cfg *models.Config) error {
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %s", err)
+	}
+
+	if err := yaml.UnmarshalStrict(contents, cfg); err != nil {
+		return ValidationError{Err: fmt.Errorf("invalid config file %s: %s", filePath, err)}
+	}
+
+	return nil
+}
+
+// normaliseConfig cleans the roots of the provided config, and lower-cases its extensions without leading dots
+func normaliseConfig(cfg *models.Config) {
+	for idx, root := range cfg.Roots {
+		cfg.Roots[idx] = path.Clean(root)
+	}
+
+	var exts []string
+	for _, ext := range cfg.Extensions {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if !contains(exts, ext) {
+			exts = append(exts, ext)
+		}
+	}
+	cfg.Extensions = exts
+
+	if cfg.Store == "" {
+		cfg.Store = models.StoreBackendMemory
+		if cfg.StorePath != "" {
+			cfg.Store = models.StoreBackendFile
+		}
+	}
+}
+
+// setDuration parses the provided value into the provided duration
+func setDuration(d *time.Duration, val string) error {
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return fmt.Errorf("not a duration such as 15s: %s", val)
+	}
+
+	*d = parsed
+	return nil
+}
+
+// isValidFileExt returns true if the provided file extension is made up of only letters and digits, otherwise false
+func isValidFileExt(ext string) bool {
+	if ext == "" {
+		return false
+	}
+
+	for _, r := range ext {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// defaultRoot returns the directory that can be browsed for images if none are provided,
+// which is the current user's home directory if it can be determined
+func defaultRoot() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "/"
+	}
+
+	return home
+}
+
+// configFlag represents the raw value of a config setting that has been provided as a flag
+type configFlag struct {
+	val    string
+	isBool bool
+}
+
+// String implements flag.Value.String()
+func (c *configFlag) String() string {
+	if c == nil {
+		return ""
+	}
+	return c.val
+}
+
+// Set implements flag.Value.Set()
+func (c *configFlag) Set(val string) error {
+	c.val = val
+	return nil
+}
+
+// IsBoolFlag allows boolean settings to be provided as a flag without a value
+func (c *configFlag) IsBoolFlag() bool {
+	return c.isBool
+}
@@ -0,0 +1,225 @@
+package domain_test
+
+import (
+	"flag"
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := path.Join(dir, "config.yml")
+	contents := `
+listen_addr: "127.0.0.1:9000"
+read_timeout: 30s
+roots: [` + dir + `]
+extensions: [JPG, .heic]
+layout: "{year}/{month}"
+collision: rename
+store_path: ` + path.Join(dir, "store.jsonl") + `
+`
+	if err := ioutil.WriteFile(configPath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(args []string, environ []string) (models.Config, error) {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(ioutil.Discard)
+		return domain.LoadConfig(flags, args, environ)
+	}
+
+	t.Run("loading a config without any settings must provide the defaults", func(t *testing.T) {
+		cfg, err := load(nil, []string{"IMGNHEAP_ROOTS=" + dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := domain.DefaultConfig()
+		expected.Roots = []string{dir}
+		expected.Store = models.StoreBackendMemory
+
+		if diff := cmp.Diff(expected, cfg); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, cfg, diff)
+		}
+	})
+
+	t.Run("loading a config must apply the file, then the environment, then the flags", func(t *testing.T) {
+		cfg, err := load(
+			[]string{"-config", configPath, "-write-timeout", "1m", "-collision", "overwrite", "-require-access-token"},
+			[]string{"IMGNHEAP_WRITE_TIMEOUT=45s", "IMGNHEAP_COLLISION=skip", "IMGNHEAP_THUMB_CACHE_SIZE=64", "IMGNHEAP_VERIFY_COPIES=true", "OTHER=ignored"},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := domain.DefaultConfig()
+		expected.ListenAddr = "127.0.0.1:9000"
+		expected.ReadTimeout = 30 * time.Second
+		expected.WriteTimeout = time.Minute
+		expected.Roots = []string{dir}
+		expected.Extensions = []string{"jpg", "heic"}
+		expected.Layout = "{year}/{month}"
+		expected.CollisionPolicy = "overwrite"
+		expected.Store = models.StoreBackendFile
+		expected.StorePath = path.Join(dir, "store.jsonl")
+		expected.ThumbCacheSize = 64
+		expected.VerifyCopies = true
+		expected.RequireAccessToken = true
+
+		if diff := cmp.Diff(expected, cfg); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, cfg, diff)
+		}
+	})
+
+	t.Run("loading a config file from the environment must apply it", func(t *testing.T) {
+		cfg, err := load(nil, []string{"IMGNHEAP_CONFIG=" + configPath})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.ListenAddr != "127.0.0.1:9000" {
+			t.Fatalf("expected 127.0.0.1:9000, got %s", cfg.ListenAddr)
+		}
+	})
+
+	t.Run("loading an invalid config must return a validation error that describes each problem", func(t *testing.T) {
+		testCases := []struct {
+			args     []string
+			environ  []string
+			expected []string
+		}{
+			{
+				args:     []string{"-roots", "relative,/missing", "-listen-addr", "8080", "-extensions", "jp/g"},
+				expected: []string{"listen-addr:", "relative", "/missing", "jp/g"},
+			},
+			{
+				args:     []string{"-roots", dir, "-read-timeout", "500ms", "-layout", "/abs", "-collision", "nope"},
+				expected: []string{"read-timeout:", "layout:", "collision:"},
+			},
+			{
+				args:     []string{"-roots", dir, "-store", "file", "-thumb-cache-size", "0"},
+				expected: []string{"store-path:", "thumb-cache-size:"},
+			},
+			{
+				args:     []string{"-roots", dir, "-store", "redis"},
+				expected: []string{"store:"},
+			},
+			{
+				environ:  []string{"IMGNHEAP_ROOTS=" + dir, "IMGNHEAP_REQUIRE_ACCESS_TOKEN=maybe"},
+				expected: []string{"IMGNHEAP_REQUIRE_ACCESS_TOKEN"},
+			},
+			{
+				args:     []string{"-roots", dir, "-write-timeout", "soon"},
+				expected: []string{"-write-timeout"},
+			},
+		}
+
+		for idx, tc := range testCases {
+			_, err := load(tc.args, tc.environ)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T: %v", idx, err, err)
+				continue
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("tc %d: expected error to mention %q, got %s", idx, expected, err)
+				}
+			}
+		}
+	})
+
+	t.Run("loading a config file with an unknown setting must return a validation error", func(t *testing.T) {
+		unknownPath := path.Join(dir, "unknown.yml")
+		if err := ioutil.WriteFile(unknownPath, []byte("listen_address: :9000\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := load([]string{"-config", unknownPath}, nil)
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+		if !strings.Contains(err.Error(), "listen_address") {
+			t.Fatalf("expected error to mention listen_address, got %s", err)
+		}
+	})
+
+	t.Run("loading a config file with a duration that has no unit must return a validation error", func(t *testing.T) {
+		unitlessPath := path.Join(dir, "unitless.yml")
+		if err := ioutil.WriteFile(unitlessPath, []byte("roots: ["+dir+"]\nread_timeout: 15\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := load([]string{"-config", unitlessPath}, nil)
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+		if !strings.Contains(err.Error(), "read-timeout:") {
+			t.Fatalf("expected error to mention read-timeout, got %s", err)
+		}
+	})
+
+	t.Run("loading a missing config file must return an error", func(t *testing.T) {
+		if _, err := load([]string{"-config", path.Join(dir, "missing.yml")}, nil); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestLoadConfigWithoutFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := path.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(configPath, []byte("roots: ["+dir+"]\nlayout: \"{year}\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("loading a config without flags must apply the file, then the environment", func(t *testing.T) {
+		testCases := []struct {
+			configPath string
+			environ    []string
+		}{
+			{configPath: configPath, environ: []string{"IMGNHEAP_VERIFY_COPIES=true"}},
+			{environ: []string{"IMGNHEAP_CONFIG=" + configPath, "IMGNHEAP_VERIFY_COPIES=true"}},
+		}
+
+		for idx, tc := range testCases {
+			cfg, err := domain.LoadConfigWithoutFlags(tc.configPath, tc.environ)
+			if err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			expected := domain.DefaultConfig()
+			expected.Roots = []string{dir}
+			expected.Layout = "{year}"
+			expected.Store = models.StoreBackendMemory
+			expected.VerifyCopies = true
+
+			if diff := cmp.Diff(expected, cfg); diff != "" {
+				t.Fatalf("tc %d: want %+v, got %+v, diff: %s", idx, expected, cfg, diff)
+			}
+		}
+	})
+
+	t.Run("loading an invalid config without flags must return a validation error", func(t *testing.T) {
+		_, err := domain.LoadConfigWithoutFlags(configPath, []string{"IMGNHEAP_ROOTS=relative"})
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"sort"
+	"sync"
+)
+
+// inFlightOperation represents a copy, move or removal that is in progress
+type inFlightOperation struct {
+	moving    bool
+	abandoned bool
+}
+
+// DrainableFileSystem defines a file system that keeps track of the copies, moves and removals in progress so that
+// they can be allowed to finish before shutting down, and otherwise defers to the file system that it wraps
+type DrainableFileSystem struct {
+	app.FileSystem
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	inFlight map[*inFlightOperation]struct{}
+	destDirs map[string]struct{}
+}
+
+// Copy implements app.FileSystem.Copy(). A copy is never abandoned, since once it has been renamed into place
+// it is complete and must be reported as such, so that it can be journaled
+func (d *DrainableFileSystem) Copy(file models.File, dest models.File) (string, error) {
+	op, err := d.begin(false, dest.DirPath)
+	if err != nil {
+		return "", err
+	}
+	defer d.end(op)
+
+	return d.FileSystem.Copy(file, dest)
+}
+
+// Move implements app.FileSystem.Move() by copying the file and then removing the original. If the move is abandoned
+// whilst copying, the copy is removed instead of the original, so that the file is only ever found at its source
+func (d *DrainableFileSystem) Move(file models.File, dest models.File) (string, error) {
+	op, err := d.begin(true, dest.DirPath)
+	if err != nil {
+		return "", err
+	}
+	defer d.end(op)
+
+	checksum, err := d.FileSystem.Copy(file, dest)
+	if err != nil {
+		return "", err
+	}
+	if d.copied(op) {
+		return checksum, d.FileSystem.Remove(file)
+	}
+
+	if err := d.FileSystem.Remove(dest); err != nil {
+		return "", UnavailableError{Err: fmt.Errorf("%s, cannot remove copy of abandoned move: %s", ErrShuttingDown, err)}
+	}
+
+	return "", ErrShuttingDown
+}
+
+// Remove implements app.FileSystem.Remove()
+func (d *DrainableFileSystem) Remove(file models.File) error {
+	op, err := d.begin(false, "")
+	if err != nil {
+		return err
+	}
+	defer d.end(op)
+
+	return d.FileSystem.Remove(file)
+}
+
+// Drain stops any further copies, moves and removals from starting, and waits for those in progress to finish.
+// If the provided context is done first, moves that are still copying are abandoned, so that they keep their originals
+// and remove their copies once complete. Any copy that is cut short by exiting only leaves a temporary file behind,
+// since copies are only renamed into place once complete
+func (d *DrainableFileSystem) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for op := range d.inFlight {
+		if op.moving {
+			op.abandoned = true
+		}
+	}
+
+	return ctx.Err()
+}
+
+// DestinationDirs returns the directories into which files have been copied or moved, in which the temporary files
+// of any copies that were cut short will have been left behind
+func (d *DrainableFileSystem) DestinationDirs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var dirs []string
+	for dir := range d.destDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// begin records the start of an operation, which may be abandoned whilst copying if it is a move,
+// along with the directory into which it copies (if any), unless draining has begun
+func (d *DrainableFileSystem) begin(moving bool, destDir string) (*inFlightOperation, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return nil, ErrShuttingDown
+	}
+
+	if destDir != "" {
+		d.destDirs[destDir] = struct{}{}
+	}
+
+	op := &inFlightOperation{moving: moving}
+	d.inFlight[op] = struct{}{}
+	d.wg.Add(1)
+
+	return op, nil
+}
+
+// copied records that the provided move has finished copying, and returns true if it can go on to remove
+// its original, otherwise false if it has been abandoned in the meantime
+func (d *DrainableFileSystem) copied(op *inFlightOperation) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if op.abandoned {
+		return false
+	}
+
+	op.moving = false
+	return true
+}
+
+// end records the end of the provided operation
+func (d *DrainableFileSystem) end(op *inFlightOperation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, op)
+	d.wg.Done()
+}
+
+// NewDrainableFileSystem returns a newly-instantiated DrainableFileSystem that wraps the provided file system
+func NewDrainableFileSystem(fs app.FileSystem) *DrainableFileSystem {
+	return &DrainableFileSystem{
+		FileSystem: fs,
+		inFlight:   make(map[*inFlightOperation]struct{}),
+		destDirs:   make(map[string]struct{}),
+	}
+}
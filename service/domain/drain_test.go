@@ -0,0 +1,171 @@
+package domain_test
+
+import (
+	"context"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// blockingCopyFileSystem provides a file system whose copies wait to be released before completing
+type blockingCopyFileSystem struct {
+	app.FileSystem
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingCopyFileSystem) Copy(file models.File, dest models.File) (string, error) {
+	close(b.started)
+	<-b.release
+
+	return b.FileSystem.Copy(file, dest)
+}
+
+func TestDrainableFileSystem(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	// setup returns a drainable file system whose copies block, and a file to move within a new directory
+	setup := func(t *testing.T, name string) (*domain.DrainableFileSystem, *blockingCopyFileSystem, models.File, models.File) {
+		dir := path.Join(baseDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "a.jpg"), []byte("complete"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		blocking := &blockingCopyFileSystem{
+			FileSystem: &domain.OsFileSystem{},
+			started:    make(chan struct{}),
+			release:    make(chan struct{}),
+		}
+
+		return domain.NewDrainableFileSystem(blocking), blocking, models.NewFile("a", "jpg", dir, nil), models.NewFile("b", "jpg", dir, nil)
+	}
+
+	t.Run("draining must wait for a move in progress to finish", func(t *testing.T) {
+		fs, blocking, file, dest := setup(t, "finish")
+
+		moved := make(chan error, 1)
+		go func() {
+			_, err := fs.Move(file, dest)
+			moved <- err
+		}()
+		<-blocking.started
+
+		drained := make(chan error, 1)
+		go func() { drained <- fs.Drain(context.Background()) }()
+
+		select {
+		case err := <-drained:
+			t.Fatalf("expected draining to wait, got %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(blocking.release)
+		if err := <-moved; err != nil {
+			t.Fatal(err)
+		}
+		if err := <-drained; err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(file.FullPath()); !os.IsNotExist(err) {
+			t.Fatalf("expected original to be removed, got %v", err)
+		}
+		contents, err := ioutil.ReadFile(dest.FullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "complete" {
+			t.Fatalf("expected complete, got %s", contents)
+		}
+
+		if _, err := fs.Copy(dest, file); err != domain.ErrShuttingDown {
+			t.Fatalf("expected ErrShuttingDown, got %v", err)
+		}
+
+		if dirs := fs.DestinationDirs(); len(dirs) != 1 || dirs[0] != dest.DirPath {
+			t.Fatalf("expected destination directory %s, got %+v", dest.DirPath, dirs)
+		}
+	})
+
+	t.Run("draining past the deadline must abandon an incomplete move, keeping the original and removing the copy", func(t *testing.T) {
+		fs, blocking, file, dest := setup(t, "abandon")
+
+		moved := make(chan error, 1)
+		go func() {
+			_, err := fs.Move(file, dest)
+			moved <- err
+		}()
+		<-blocking.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := fs.Drain(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+		if _, err := os.Stat(dest.FullPath()); !os.IsNotExist(err) {
+			t.Fatalf("expected no destination whilst copying, got %v", err)
+		}
+
+		close(blocking.release)
+		if err := <-moved; err != domain.ErrShuttingDown {
+			t.Fatalf("expected ErrShuttingDown, got %v", err)
+		}
+
+		contents, err := ioutil.ReadFile(file.FullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "complete" {
+			t.Fatalf("expected complete, got %s", contents)
+		}
+		if _, err := os.Stat(dest.FullPath()); !os.IsNotExist(err) {
+			t.Fatalf("expected copy of abandoned move to be removed, got %v", err)
+		}
+	})
+
+	t.Run("draining past the deadline must report a copy that goes on to complete as a success", func(t *testing.T) {
+		fs, blocking, file, dest := setup(t, "complete")
+
+		copied := make(chan error, 1)
+		go func() {
+			_, err := fs.Copy(file, dest)
+			copied <- err
+		}()
+		<-blocking.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := fs.Drain(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+
+		close(blocking.release)
+		if err := <-copied; err != nil {
+			t.Fatalf("expected copy in place to succeed, got %v", err)
+		}
+
+		for _, f := range []models.File{file, dest} {
+			contents, err := ioutil.ReadFile(f.FullPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(contents) != "complete" {
+				t.Fatalf("expected complete, got %s", contents)
+			}
+		}
+	})
+}
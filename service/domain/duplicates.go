@@ -0,0 +1,234 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+)
+
+// SubDirDuplicates represents the session sub-directory that duplicate files are moved into
+const SubDirDuplicates = "duplicates"
+
+// FindDuplicates returns the groups of files within the provided files whose contents are identical, in order of
+// first appearance. Files are only hashed if another file of the same size is present
+func (f *FileSystemAgent) FindDuplicates(files []models.File) ([]models.DuplicateGroup, error) {
+	return f.ScanDuplicates(context.Background(), files, nil)
+}
+
+// ScanDuplicates returns the groups of files within the provided files whose contents are identical, like FindDuplicates,
+// reporting the hashing of each file to the provided progress (if any) and stopping early if the provided context is done
+func (f *FileSystemAgent) ScanDuplicates(ctx context.Context, files []models.File, progress app.JobProgress) ([]models.DuplicateGroup, error) {
+	if progress == nil {
+		progress = noopJobProgress{}
+	}
+
+	sizes, bySize := duplicateCandidates(files)
+
+	var groups []models.DuplicateGroup
+
+	for _, size := range sizes {
+		var checksums []string
+		byChecksum := make(map[string][]models.File)
+		for _, file := range bySize[size] {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			name := file.NameWithExt()
+			progress.Processing(name)
+			checksum, err := f.Checksum(file)
+			progress.Processed(name, "", err)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, ok := byChecksum[checksum]; !ok {
+				checksums = append(checksums, checksum)
+			}
+			byChecksum[checksum] = append(byChecksum[checksum], file)
+		}
+
+		for _, checksum := range checksums {
+			if len(byChecksum[checksum]) < 2 {
+				continue
+			}
+			groups = append(groups, models.DuplicateGroup{
+				Checksum: checksum,
+				Size:     size,
+				Files:    byChecksum[checksum],
+			})
+		}
+	}
+
+	return groups, nil
+}
+
+// CountDuplicateCandidates returns the number of the provided files that share their size with another file,
+// which are those that must be hashed in order to find duplicates
+func CountDuplicateCandidates(files []models.File) int {
+	sizes, bySize := duplicateCandidates(files)
+
+	count := 0
+	for _, size := range sizes {
+		count += len(bySize[size])
+	}
+	return count
+}
+
+// duplicateCandidates returns the sizes that are shared by more than one of the provided files in order of
+// first appearance, along with the files of each size, since a file of a unique size can't have a duplicate
+func duplicateCandidates(files []models.File) ([]int64, map[int64][]models.File) {
+	var sizes []int64
+	bySize := make(map[int64][]models.File)
+	for _, file := range files {
+		if _, ok := bySize[file.Size]; !ok {
+			sizes = append(sizes, file.Size)
+		}
+		bySize[file.Size] = append(bySize[file.Size], file)
+	}
+
+	var shared []int64
+	for _, size := range sizes {
+		if len(bySize[size]) > 1 {
+			shared = append(shared, size)
+		}
+	}
+
+	return shared, bySize
+}
+
+// FindDuplicatesForSession returns the groups of image files within the provided session's directory whose contents are identical
+func (f *FileSystemAgent) FindDuplicatesForSession(sess *models.Session) ([]models.DuplicateGroup, error) {
+	files, err := f.GetFilesForSession(sess, ImgFileExts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.FindDuplicates(files)
+}
+
+// SaveDuplicatesForSession stores the provided groups of duplicates as those found within the provided session's directory
+func (s *SessionAgent) SaveDuplicatesForSession(sess *models.Session, groups []models.DuplicateGroup) error {
+	if sess == nil {
+		return errors.New("session is nil")
+	}
+
+	val, err := json.Marshal(groups)
+	if err != nil {
+		return err
+	}
+
+	return s.KeyValStore().WriteWithTTL(duplicatesKey(sess.Token), val, sessionTTL)
+}
+
+// GetDuplicatesForSession returns the groups of duplicates that were last found within the provided session's directory,
+// and whether they have been looked for at all
+func (s *SessionAgent) GetDuplicatesForSession(sess *models.Session) ([]models.DuplicateGroup, bool, error) {
+	if sess == nil {
+		return nil, false, errors.New("session is nil")
+	}
+
+	val, err := s.KeyValStore().Read(duplicatesKey(sess.Token))
+	if err != nil {
+		if _, ok := err.(NotFoundError); ok {
+			// not looked for yet
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var groups []models.DuplicateGroup
+	if err := json.Unmarshal(val, &groups); err != nil {
+		return nil, false, fmt.Errorf("error key %s does not represent duplicates: %s", duplicatesKey(sess.Token), err)
+	}
+
+	return groups, true, nil
+}
+
+// duplicatesKey returns the key at which the duplicates found by the session with the provided token are stored
+func duplicatesKey(sessToken string) string {
+	return fmt.Sprintf("duplicates:%s", sessToken)
+}
+
+// ResolveDuplicates keeps the file of the provided group with the provided path relative to the session's directory,
+// and moves the rest of the group into the provided session's duplicates directory. As the group may have been found
+// some time ago, files whose contents are no longer identical to the group's are skipped
+func (f *FileSystemAgent) ResolveDuplicates(sess *models.Session, group models.DuplicateGroup, keep string) ([]models.ProcessResult, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	var files []models.File
+	var results []models.ProcessResult
+	for _, file := range group.Files {
+		checksum, err := f.Checksum(file)
+		if err != nil {
+			if _, ok := err.(NotFoundError); !ok {
+				return nil, err
+			}
+		}
+
+		if checksum == group.Checksum {
+			files = append(files, file)
+			continue
+		}
+		if file.RelativePath(sess.BaseDir) == keep {
+			return nil, ValidationError{Err: fmt.Errorf("file %s has changed since its duplicates were found", keep)}
+		}
+		results = append(results, models.ProcessResult{File: file, Outcome: ProcessOutcomeSkipped})
+	}
+
+	moved, err := f.moveDuplicates(sess, files, keep)
+	return append(results, moved...), err
+}
+
+// moveDuplicates keeps the file of the provided files with the provided path relative to the session's directory,
+// and moves the rest into the provided session's duplicates directory
+func (f *FileSystemAgent) moveDuplicates(sess *models.Session, files []models.File, keep string) ([]models.ProcessResult, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	found := false
+	for _, file := range files {
+		if file.RelativePath(sess.BaseDir) == keep {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ValidationError{Err: fmt.Errorf("file %s does not belong to the group of duplicates", keep)}
+	}
+
+	// duplicates moved previously may share a name, and must neither be overwritten nor leave the file behind
+	f.CollisionPolicy = CollisionPolicyRename
+
+	var results []models.ProcessResult
+	for _, file := range files {
+		if file.RelativePath(sess.BaseDir) == keep {
+			continue
+		}
+
+		result, err := f.ProcessFileByMove(file, sess.FullDir(SubDirDuplicates))
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// duplicatesToSkip returns the files of the provided groups other than the first of each group, keyed by full path
+func duplicatesToSkip(groups []models.DuplicateGroup) map[string]bool {
+	skip := make(map[string]bool)
+	for _, group := range groups {
+		for _, file := range group.Files[1:] {
+			skip[file.FullPath()] = true
+		}
+	}
+	return skip
+}
@@ -0,0 +1,244 @@
+package domain_test
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileSystemAgent_FindDuplicates(t *testing.T) {
+	setup := func(t *testing.T) (*models.Session, func()) {
+		baseDir, err := ioutil.TempDir("", "imgnheap")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, contents := range map[string]string{
+			"a.jpg":  "same",
+			"b.jpg":  "diff",
+			"c.png":  "same",
+			"d.jpg":  "unique file",
+			"e.jpg":  "same",
+			"f.txt":  "same",
+			"g.jpg":  "other",
+			"h.jpeg": "other",
+		} {
+			if err := ioutil.WriteFile(path.Join(baseDir, name), []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		sess := &models.Session{Token: "token", BaseDir: baseDir, SubDir: "subdir"}
+		return sess, func() { os.RemoveAll(baseDir) }
+	}
+
+	names := func(files []models.File) []string {
+		var names []string
+		for _, file := range files {
+			names = append(names, file.NameWithExt())
+		}
+		return names
+	}
+
+	t.Run("finding duplicates must group image files with identical contents only", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+		groups, err := fsAgent.FindDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedGroups := [][]string{
+			{"a.jpg", "c.png", "e.jpg"},
+			{"g.jpg", "h.jpeg"},
+		}
+		if len(groups) != len(expectedGroups) {
+			t.Fatalf("expected %d groups, got %d", len(expectedGroups), len(groups))
+		}
+		for idx, group := range groups {
+			if diff := cmp.Diff(expectedGroups[idx], names(group.Files)); diff != "" {
+				t.Fatalf("tc %d: want %+v, got %+v, diff: %s", idx, expectedGroups[idx], names(group.Files), diff)
+			}
+		}
+	})
+
+	t.Run("resolving duplicates must keep the chosen file and move the rest aside", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+		groups, err := fsAgent.FindDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := fsAgent.ResolveDuplicates(sess, groups[0], "c.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+
+		for _, name := range []string{"a.jpg", "e.jpg"} {
+			if _, err := os.Stat(sess.FullDir(domain.SubDirDuplicates, name)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path.Join(sess.BaseDir, name)); !os.IsNotExist(err) {
+				t.Fatalf("expected %s to have been moved", name)
+			}
+		}
+		if _, err := os.Stat(path.Join(sess.BaseDir, "c.png")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := fsAgent.ResolveDuplicates(sess, groups[1], "d.jpg"); err == nil {
+			t.Fatal("expected error, got nil")
+		} else if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+
+	t.Run("scanning for duplicates must report the hashing of each file that shares its size", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+		files, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		progress := &countingJobProgress{}
+		groups, err := fsAgent.ScanDuplicates(context.Background(), files, progress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 2 {
+			t.Fatalf("expected 2 groups, got %d", len(groups))
+		}
+
+		total := domain.CountDuplicateCandidates(files)
+		if total != 6 {
+			t.Fatalf("expected 6 candidates, got %d", total)
+		}
+		if progress.processed != total {
+			t.Fatalf("expected %d processed, got %d", total, progress.processed)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := fsAgent.ScanDuplicates(ctx, files, nil); err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+
+	t.Run("resolving duplicates that have since changed must skip the changed files", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+		groups, err := fsAgent.FindDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(sess.BaseDir, "e.jpg"), []byte("edit"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := fsAgent.ResolveDuplicates(sess, groups[0], "c.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved := domain.CountMoved(results); moved != 1 {
+			t.Fatalf("expected 1 moved, got %d", moved)
+		}
+		if _, err := os.Stat(path.Join(sess.BaseDir, "e.jpg")); err != nil {
+			t.Fatalf("expected changed file to be kept, got %v", err)
+		}
+
+		if _, err := fsAgent.ResolveDuplicates(sess, groups[0], "e.jpg"); err == nil {
+			t.Fatal("expected error, got nil")
+		} else if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+
+	t.Run("saved duplicates must be retrieved for their session only", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+		sessAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{fs: &domain.OsFileSystem{}, store: domain.NewInMemoryKeyValStore()}}
+
+		if _, scanned, err := sessAgent.GetDuplicatesForSession(sess); err != nil || scanned {
+			t.Fatalf("expected nothing found yet, got %v and %v", scanned, err)
+		}
+
+		groups, err := fsAgent.FindDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sessAgent.SaveDuplicatesForSession(sess, groups); err != nil {
+			t.Fatal(err)
+		}
+
+		saved, scanned, err := sessAgent.GetDuplicatesForSession(sess)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !scanned {
+			t.Fatal("expected duplicates to have been found")
+		}
+		if diff := cmp.Diff(groups, saved); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", groups, saved, diff)
+		}
+
+		if _, scanned, err := sessAgent.GetDuplicatesForSession(&models.Session{Token: "other"}); err != nil || scanned {
+			t.Fatalf("expected nothing found for other session, got %v and %v", scanned, err)
+		}
+	})
+
+	t.Run("planning by date with duplicates skipped must only plan the first of each group", func(t *testing.T) {
+		sess, teardown := setup(t)
+		defer teardown()
+
+		fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+		layout, err := domain.ParseDirLayout(domain.DefaultDirLayout)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err := fsAgent.PlanByDate(sess, layout, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var planned []models.File
+		for _, item := range plan.Items {
+			planned = append(planned, item.File)
+		}
+
+		expectedPlanned := []string{"a.jpg", "b.jpg", "d.jpg", "g.jpg"}
+		if diff := cmp.Diff(expectedPlanned, names(planned)); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expectedPlanned, names(planned), diff)
+		}
+
+		expectedDuplicates := []string{"c.png", "e.jpg", "h.jpeg"}
+		if diff := cmp.Diff(expectedDuplicates, names(plan.Duplicates)); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expectedDuplicates, names(plan.Duplicates), diff)
+		}
+	})
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "errors"
+
 // BadRequestError represents an error generated by a bad request
 type BadRequestError struct{ Err error }
 
@@ -14,9 +16,33 @@ func (v ValidationError) Error() string {
 	return v.Err.Error()
 }
 
+// UnsupportedMediaTypeError represents an error generated by a request body of a type that is not supported
+type UnsupportedMediaTypeError struct{ Err error }
+
+func (u UnsupportedMediaTypeError) Error() string {
+	return u.Err.Error()
+}
+
 // NotFoundError represents an error that refers to an entity that cannot be found
 type NotFoundError struct{ Err error }
 
 func (n NotFoundError) Error() string {
 	return n.Err.Error()
 }
+
+// ForbiddenError represents an error that refers to a request that is not permitted
+type ForbiddenError struct{ Err error }
+
+func (f ForbiddenError) Error() string {
+	return f.Err.Error()
+}
+
+// UnavailableError represents an error that refers to a request that cannot be served at the moment
+type UnavailableError struct{ Err error }
+
+func (u UnavailableError) Error() string {
+	return u.Err.Error()
+}
+
+// ErrShuttingDown represents the error returned for operations that are attempted once shutting down has begun
+var ErrShuttingDown = UnavailableError{Err: errors.New("imgnheap is shutting down")}
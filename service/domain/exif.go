@@ -0,0 +1,293 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagMake               = 0x010f
+	exifTagModel              = 0x0110
+	exifTagOrientation        = 0x0112
+	exifTagExifIFDPointer     = 0x8769
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+
+	exifTypeASCII = 2
+	exifTypeShort = 3
+	exifTypeLong  = 4
+
+	// exifMaxIFDEntries guards against reading a nonsensical number of entries from a corrupt file
+	exifMaxIFDEntries = 1024
+	// exifMaxStringLength guards against reading a nonsensical string length from a corrupt file
+	exifMaxStringLength = 256
+)
+
+// ErrNoExif represents the absence of EXIF data from a file
+var ErrNoExif = errors.New("no exif data found")
+
+// ExifData represents the subset of EXIF metadata that we make use of
+type ExifData struct {
+	Make               string
+	Model              string
+	Orientation        int
+	DateTimeOriginal   string
+	OffsetTimeOriginal string
+}
+
+// TakenAt returns the timestamp at which the image was captured, and whether a valid timestamp is present
+func (e ExifData) TakenAt() (time.Time, bool) {
+	if e.DateTimeOriginal == "" {
+		return time.Time{}, false
+	}
+
+	layout := "2006:01:02 15:04:05"
+	value := e.DateTimeOriginal
+
+	if e.OffsetTimeOriginal != "" {
+		// offset takes the form "+01:00"
+		layout += "-07:00"
+		value += e.OffsetTimeOriginal
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// ReadExif reads the EXIF metadata from the provided JPEG or TIFF data
+func ReadExif(r io.ReadSeeker) (ExifData, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return ExifData{}, ErrNoExif
+	}
+
+	switch {
+	case magic[0] == 0xff && magic[1] == 0xd8:
+		// jpeg, so locate the tiff structure within the exif segment
+		if _, err := r.Seek(2, io.SeekStart); err != nil {
+			return ExifData{}, err
+		}
+		payload, err := readJpegExifSegment(r)
+		if err != nil {
+			return ExifData{}, err
+		}
+		return readTiff(bytes.NewReader(payload))
+	case string(magic[:]) == "II*\x00" || string(magic[:]) == "MM\x00*":
+		// tiff, so the file itself is the tiff structure
+		return readTiff(r)
+	}
+
+	return ExifData{}, ErrNoExif
+}
+
+// readJpegExifSegment returns the tiff structure held within the exif segment of the provided jpeg data,
+// which must be positioned immediately after the start of image marker
+func readJpegExifSegment(r io.ReadSeeker) ([]byte, error) {
+	exifHeader := []byte("Exif\x00\x00")
+
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return nil, ErrNoExif
+		}
+		if marker[0] != 0xff {
+			return nil, fmt.Errorf("invalid jpeg marker: %x", marker[0:2])
+		}
+
+		switch marker[1] {
+		case 0xda, 0xd9:
+			// start of scan or end of image, so no more metadata segments to come
+			return nil, ErrNoExif
+		}
+
+		// segment length includes the two length bytes themselves
+		length := int64(binary.BigEndian.Uint16(marker[2:4])) - 2
+		if length < 0 {
+			return nil, fmt.Errorf("invalid jpeg segment length: %d", length)
+		}
+
+		if marker[1] != 0xe1 || length < int64(len(exifHeader)) {
+			// not an exif segment, so skip over it
+			if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(segment, exifHeader) {
+			// app1 is also used for xmp, so keep looking
+			continue
+		}
+
+		return segment[len(exifHeader):], nil
+	}
+}
+
+// tiffReader provides random access to the values of a tiff structure
+type tiffReader struct {
+	r     io.ReadSeeker
+	order binary.ByteOrder
+}
+
+// readAt reads n bytes from the provided offset
+func (t tiffReader) readAt(offset int64, n int) ([]byte, error) {
+	if _, err := t.r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(t.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// tiffEntry represents a single entry within an image file directory
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// readIFD returns the entries of the image file directory at the provided offset
+func (t tiffReader) readIFD(offset int64) ([]tiffEntry, error) {
+	b, err := t.readAt(offset, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(t.order.Uint16(b))
+	if count > exifMaxIFDEntries {
+		return nil, fmt.Errorf("too many ifd entries: %d", count)
+	}
+
+	b, err = t.readAt(offset+2, count*12)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]tiffEntry, count)
+	for idx := range entries {
+		raw := b[idx*12 : (idx+1)*12]
+		entries[idx] = tiffEntry{
+			tag:   t.order.Uint16(raw[0:2]),
+			typ:   t.order.Uint16(raw[2:4]),
+			count: t.order.Uint32(raw[4:8]),
+			value: raw[8:12],
+		}
+	}
+
+	return entries, nil
+}
+
+// stringValue returns the ascii value of the provided entry
+func (t tiffReader) stringValue(entry tiffEntry) string {
+	if entry.typ != exifTypeASCII || entry.count > exifMaxStringLength {
+		return ""
+	}
+
+	b := entry.value[:]
+	if entry.count > 4 {
+		// value doesn't fit within the entry, so it is held at an offset instead
+		var err error
+		b, err = t.readAt(int64(t.order.Uint32(entry.value)), int(entry.count))
+		if err != nil {
+			return ""
+		}
+	} else {
+		b = b[:entry.count]
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+}
+
+// intValue returns the numeric value of the provided entry
+func (t tiffReader) intValue(entry tiffEntry) int64 {
+	switch entry.typ {
+	case exifTypeShort:
+		return int64(t.order.Uint16(entry.value[0:2]))
+	case exifTypeLong:
+		return int64(t.order.Uint32(entry.value))
+	}
+
+	return 0
+}
+
+// readTiff reads the exif data from the provided tiff structure
+func readTiff(r io.ReadSeeker) (ExifData, error) {
+	var data ExifData
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return data, err
+	}
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return data, ErrNoExif
+	}
+
+	t := tiffReader{r: r}
+	switch string(header[0:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return data, fmt.Errorf("invalid tiff byte order: %q", header[0:2])
+	}
+	if t.order.Uint16(header[2:4]) != 42 {
+		return data, errors.New("invalid tiff header")
+	}
+
+	entries, err := t.readIFD(int64(t.order.Uint32(header[4:8])))
+	if err != nil {
+		return data, err
+	}
+
+	var exifOffset int64
+	for _, entry := range entries {
+		switch entry.tag {
+		case exifTagMake:
+			data.Make = t.stringValue(entry)
+		case exifTagModel:
+			data.Model = t.stringValue(entry)
+		case exifTagOrientation:
+			data.Orientation = int(t.intValue(entry))
+		case exifTagExifIFDPointer:
+			exifOffset = t.intValue(entry)
+		}
+	}
+
+	if exifOffset == 0 {
+		// no exif sub-directory, so no capture date
+		return data, nil
+	}
+
+	entries, err = t.readIFD(exifOffset)
+	if err != nil {
+		return data, err
+	}
+
+	for _, entry := range entries {
+		switch entry.tag {
+		case exifTagDateTimeOriginal:
+			data.DateTimeOriginal = t.stringValue(entry)
+		case exifTagOffsetTimeOriginal:
+			data.OffsetTimeOriginal = t.stringValue(entry)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,158 @@
+package domain_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"os"
+	"testing"
+	"time"
+)
+
+// fileSystemInjector provides a FileSystemAgentInjector backed by the provided file system and the default timestamp patterns
+type fileSystemInjector struct{ fs app.FileSystem }
+
+func (f fileSystemInjector) FileSystem() app.FileSystem { return f.fs }
+
+func (f fileSystemInjector) TimestampPatternRegistry() app.TimestampPatternRegistry {
+	registry, _ := domain.NewInMemoryTimestampPatternRegistry(domain.DefaultTimestampPatterns()...)
+	return registry
+}
+
+func TestReadExif(t *testing.T) {
+	t.Run("reading exif from jpeg data must provide the expected result", func(t *testing.T) {
+		f, err := os.Open("testdata/exif-with-offset.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		expectedData := domain.ExifData{
+			Make:               "Google",
+			Model:              "Pixel 4a",
+			Orientation:        6,
+			DateTimeOriginal:   "2018:05:26 14:00:29",
+			OffsetTimeOriginal: "+01:00",
+		}
+
+		actualData, err := domain.ReadExif(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(expectedData, actualData); diff != "" {
+			t.Fatalf("expected %+v, got %+v", expectedData, actualData)
+		}
+	})
+
+	t.Run("reading exif from big-endian tiff data must provide the expected result", func(t *testing.T) {
+		f, err := os.Open("testdata/exif-without-offset.tif")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		expectedData := domain.ExifData{
+			Model:            "DSC-RX100",
+			DateTimeOriginal: "2018:05:26 14:00:29",
+		}
+
+		actualData, err := domain.ReadExif(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(expectedData, actualData); diff != "" {
+			t.Fatalf("expected %+v, got %+v", expectedData, actualData)
+		}
+	})
+
+	t.Run("reading exif from jpeg data without exif segment must return no exif error", func(t *testing.T) {
+		f, err := os.Open("testdata/no-exif.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if _, err := domain.ReadExif(f); err != domain.ErrNoExif {
+			t.Fatalf("expected %+v, got %+v", domain.ErrNoExif, err)
+		}
+	})
+}
+
+func TestExifData_TakenAt(t *testing.T) {
+	t.Run("exif data with offset must provide timestamp in the expected zone", func(t *testing.T) {
+		data := domain.ExifData{DateTimeOriginal: "2018:05:26 14:00:29", OffsetTimeOriginal: "+01:00"}
+		expectedTs := time.Date(2018, 5, 26, 13, 0, 29, 0, time.UTC)
+
+		actualTs, ok := data.TakenAt()
+		if !ok {
+			t.Fatal("expected ok, got not ok")
+		}
+		if !expectedTs.Equal(actualTs) {
+			t.Fatalf("expected %+v, got %+v", expectedTs, actualTs)
+		}
+	})
+
+	t.Run("exif data without valid timestamp must not be ok", func(t *testing.T) {
+		testCases := []domain.ExifData{
+			{},
+			{DateTimeOriginal: "0000:00:00 00:00:00"},
+			{DateTimeOriginal: "2018:05:26 14:00:29", OffsetTimeOriginal: "garbage"},
+		}
+
+		for idx, tc := range testCases {
+			if _, ok := tc.TakenAt(); ok {
+				t.Fatalf("tc %d: expected not ok, got ok", idx)
+			}
+		}
+	})
+}
+
+func TestFileSystemAgent_ResolveMetadata(t *testing.T) {
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("resolving metadata must prefer exif, then filename, then modified time", func(t *testing.T) {
+		testCases := []struct {
+			file           models.File
+			expectedOutput models.FileMetadata
+		}{
+			{
+				file: models.NewFile("exif-with-offset", "jpg", "testdata", &modTime),
+				expectedOutput: models.FileMetadata{
+					Timestamp:       time.Date(2018, 5, 26, 13, 0, 29, 0, time.UTC),
+					TimestampSource: domain.TimestampSourceExif,
+					CameraModel:     "Pixel 4a",
+				},
+			},
+			{
+				file: models.NewFile("20180526_140029", "jpg", "testdata", &modTime),
+				expectedOutput: models.FileMetadata{
+					Timestamp:        time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC),
+					TimestampSource:  domain.TimestampSourceFileName,
+					TimestampPattern: "timestamp-underscore",
+				},
+			},
+			{
+				file: models.NewFile("no-exif", "jpg", "testdata", &modTime),
+				expectedOutput: models.FileMetadata{
+					Timestamp:       modTime,
+					TimestampSource: domain.TimestampSourceModTime,
+				},
+			},
+		}
+
+		for idx, tc := range testCases {
+			actualOutput := fsAgent.ResolveMetadata(tc.file)
+
+			if !tc.expectedOutput.Timestamp.Equal(actualOutput.Timestamp) ||
+				tc.expectedOutput.TimestampSource != actualOutput.TimestampSource ||
+				tc.expectedOutput.TimestampPattern != actualOutput.TimestampPattern ||
+				tc.expectedOutput.CameraModel != actualOutput.CameraModel {
+				t.Fatalf("tc %d: expected %+v, got %+v", idx, tc.expectedOutput, actualOutput)
+			}
+		}
+	})
+}
@@ -1,15 +1,20 @@
 package domain
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"imgnheap/service/app"
 	"imgnheap/service/models"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -19,16 +24,55 @@ const (
 	SubDirByTag  = "by-tag"
 )
 
-var ImgFileExts = []string{
+const (
+	TimestampSourceExif      = "exif"
+	TimestampSourceContainer = "container"
+	TimestampSourceFileName  = "filename"
+	TimestampSourceModTime   = "modified time"
+)
+
+// defaultImgFileExts represents the file extensions that are catalogued unless others are configured
+var defaultImgFileExts = []string{
 	"png",
 	"jpg",
 	"jpeg",
 	"mp4",
+	"mov",
+}
+
+// ImgFileExts represents the file extensions that are catalogued
+var ImgFileExts = append([]string(nil), defaultImgFileExts...)
+
+// exifFileExts represents the file extensions that may contain exif data
+var exifFileExts = []string{
+	"jpg",
+	"jpeg",
+	"tif",
+	"tiff",
 }
 
+// containerFileExts represents the file extensions that may contain iso base media file format metadata
+var containerFileExts = []string{
+	"mp4",
+	"mov",
+	"m4v",
+	"3gp",
+}
+
+// tempFileSuffix represents the suffix of the temporary files to which copies are written before being put in place
+const tempFileSuffix = ".imgnheap-tmp"
+
+// staleTempFileAge represents how long a temporary file must have gone unmodified before it is considered to have been
+// left behind by a copy that was cut short, rather than belonging to a copy in progress
+const staleTempFileAge = time.Hour
+
 // OsFileSystem defines the OS implementation of FileSystem
 type OsFileSystem struct {
 	app.FileSystem
+	// FileOps performs the low-level operations with which files are written, defaulting to OsFileOps if not provided
+	FileOps app.FileOps
+	// VerifyChecksum determines whether each copy is read back and compared with the original before it is put in place
+	VerifyChecksum bool
 }
 
 // IsDirectory implements app.FileSystem.IsDirectory()
@@ -52,18 +96,68 @@ func (o *OsFileSystem) GetFilesInDirectory(dirPath string) ([]models.File, error
 			// an error has already occurred
 			return err
 		}
-		if path.Dir(filePath) != dirPath {
-			// we're beyond the first directory level, so return early
+		if filePath == dirPath {
+			// this is the directory itself
+			return nil
+		}
+		if info.IsDir() {
+			// we're only interested in files at the first directory level, so don't descend
+			return filepath.SkipDir
+		}
+
+		fileName, ext := ParseNameAndExtensionFromFileName(info.Name())
+		modTime := info.ModTime()
+		file := models.NewFile(fileName, ext, dirPath, &modTime)
+		file.Size = info.Size()
+		files = append(files, file)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// GetFilesInDirectoryTree implements app.FileSystem.GetFilesInDirectoryTree()
+// Directories are descended up to the provided maximum depth (or without limit if zero), and any file or directory
+// whose name or path relative to the provided directory matches one of the provided glob patterns is excluded
+func (o *OsFileSystem) GetFilesInDirectoryTree(dirPath string, maxDepth int, excludes []string) ([]models.File, error) {
+	var files []models.File
+
+	dirPath = path.Clean(dirPath)
+
+	if err := filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			// an error has already occurred
+			return err
+		}
+		if filePath == dirPath {
+			// nothing to check for the directory itself
 			return nil
 		}
+
+		relPath := strings.TrimPrefix(filePath, dirPath+"/")
+		if matchesAny(excludes, info.Name(), relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if info.IsDir() {
-			// we're only interested in files, so return early
+			if maxDepth > 0 && strings.Count(relPath, "/")+1 > maxDepth {
+				// we're beyond the maximum depth, so don't descend any further
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
 		fileName, ext := ParseNameAndExtensionFromFileName(info.Name())
 		modTime := info.ModTime()
-		files = append(files, models.NewFile(fileName, ext, dirPath, &modTime))
+		file := models.NewFile(fileName, ext, path.Dir(filePath), &modTime)
+		file.Size = info.Size()
+		files = append(files, file)
 
 		return nil
 	}); err != nil {
@@ -84,8 +178,8 @@ func (o *OsFileSystem) GetDirectoriesInDirectory(dirPath string) ([]models.Direc
 			// an error has already occurred
 			return err
 		}
-		if path.Dir(filePath) != dirPath {
-			// we're beyond the first directory level, so return early
+		if filePath == dirPath {
+			// this is the directory itself
 			return nil
 		}
 		if !info.IsDir() {
@@ -100,7 +194,8 @@ func (o *OsFileSystem) GetDirectoriesInDirectory(dirPath string) ([]models.Direc
 
 		dirs = append(dirs, dir)
 
-		return nil
+		// we're only interested in the first directory level, so don't descend
+		return filepath.SkipDir
 	}); err != nil {
 		return nil, err
 	}
@@ -121,52 +216,245 @@ func (o *OsFileSystem) GetContents(file models.File) ([]byte, error) {
 	return contents, nil
 }
 
+// Open implements app.FileSystem.Open()
+func (o *OsFileSystem) Open(file models.File) (app.ReadSeekCloser, error) {
+	f, err := os.Open(file.FullPath())
+	if err != nil {
+		return nil, NotFoundError{Err: err}
+	}
+
+	return f, nil
+}
+
+// Stat implements app.FileSystem.Stat()
+func (o *OsFileSystem) Stat(file models.File) (os.FileInfo, error) {
+	fi, err := os.Stat(file.FullPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, NotFoundError{Err: err}
+		}
+		return nil, err
+	}
+
+	return fi, nil
+}
+
 // Copy implements app.FileSystem.Copy()
-func (o *OsFileSystem) Copy(file models.File, destDir string) error {
+// The copy is written to a temporary file within the destination directory, which is synced and verified before being
+// renamed into place, so that the destination never holds an incomplete copy. The original is checksummed as it is
+// copied, so that the copy doesn't need to be read again to be journaled
+func (o *OsFileSystem) Copy(file models.File, dest models.File) (string, error) {
+	if file.FullPath() == dest.FullPath() {
+		return "", ValidationError{Err: fmt.Errorf("cannot copy %s onto itself", file.FullPath())}
+	}
+
 	src, err := os.Open(file.FullPath())
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer src.Close()
 
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return err
+	info, err := src.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dest.DirPath, 0755); err != nil {
+		return "", err
 	}
 
-	destPath := path.Join(destDir, file.NameWithExt())
-	dest, err := os.Create(destPath)
+	ops := o.fileOps()
+
+	tmp, err := ops.CreateTemp(dest.DirPath, "."+dest.NameWithExt()+".*"+tempFileSuffix)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	// remove temporary file unless it has been put in place
+	renamed := false
+	defer func() {
+		if !renamed {
+			ops.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	if err := writeTempFile(tmp, io.TeeReader(src, h), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("cannot copy %s: %s", file.FullPath(), err)
+	}
+	checksum := h.Sum(nil)
+
+	if err := verifyTempFile(tmp.Name(), info.Size(), checksum, o.VerifyChecksum); err != nil {
+		return "", fmt.Errorf("cannot copy %s: %s", file.FullPath(), err)
 	}
-	defer dest.Close()
 
-	_, err = io.Copy(dest, src)
-	return err
+	if err := ops.Rename(tmp.Name(), dest.FullPath()); err != nil {
+		return "", err
+	}
+	renamed = true
+
+	// persist the rename, where the file system supports syncing directories
+	syncDir(dest.DirPath)
+
+	return hex.EncodeToString(checksum), nil
 }
 
 // Move implements app.FileSystem.Move()
-func (o *OsFileSystem) Move(file models.File, destDir string) error {
+func (o *OsFileSystem) Move(file models.File, dest models.File) (string, error) {
 	// copy file
-	if err := o.Copy(file, destDir); err != nil {
-		return err
+	checksum, err := o.Copy(file, dest)
+	if err != nil {
+		return "", err
 	}
 
 	// delete original
 	if err := os.Remove(file.FullPath()); err != nil {
+		return "", err
+	}
+
+	return checksum, nil
+}
+
+// Remove implements app.FileSystem.Remove()
+func (o *OsFileSystem) Remove(file models.File) error {
+	if err := os.Remove(file.FullPath()); err != nil {
+		if os.IsNotExist(err) {
+			return NotFoundError{Err: err}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// fileOps returns the low-level operations with which the file system writes files
+func (o *OsFileSystem) fileOps() app.FileOps {
+	if o.FileOps == nil {
+		return OsFileOps{}
+	}
+	return o.FileOps
+}
+
+// writeTempFile writes the contents of the provided reader to the provided temporary file with the provided
+// permissions, then syncs and closes it
+func writeTempFile(tmp app.WritableFile, r io.Reader, perm os.FileMode) error {
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
 		return err
 	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	return tmp.Close()
+}
+
+// verifyTempFile returns an error if the temporary file at the provided path doesn't have the provided size or,
+// if the checksum is to be verified, the provided SHA-256 checksum
+func verifyTempFile(tmpPath string, size int64, checksum []byte, verifyChecksum bool) error {
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		return fmt.Errorf("wrote %d of %d bytes", info.Size(), size)
+	}
+
+	if !verifyChecksum {
+		return nil
+	}
+
+	tmp, err := os.Open(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer tmp.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, tmp); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), checksum) {
+		return errors.New("checksum of copy does not match the original")
+	}
 
 	return nil
 }
 
+// syncDir flushes the entries of the directory at the provided path to disk, ignoring file systems that don't support it
+func syncDir(dirPath string) {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return
+	}
+	defer dir.Close()
+
+	dir.Sync()
+}
+
+// OsFileOps defines the OS implementation of FileOps
+type OsFileOps struct {
+	app.FileOps
+}
+
+// CreateTemp implements app.FileOps.CreateTemp()
+func (o OsFileOps) CreateTemp(dir string, pattern string) (app.WritableFile, error) {
+	return ioutil.TempFile(dir, pattern)
+}
+
+// Rename implements app.FileOps.Rename()
+func (o OsFileOps) Rename(oldPath string, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove implements app.FileOps.Remove()
+func (o OsFileOps) Remove(path string) error {
+	return os.Remove(path)
+}
+
 // FileSystemAgentInjector defines the injector behaviours for our FileSystemAgent
 type FileSystemAgentInjector interface {
 	app.FileSystemInjector
+	app.TimestampPatternRegistryInjector
 }
 
 // FileSystemAgent encapsulates all of our filesystem-related operations
 type FileSystemAgent struct {
 	FileSystemAgentInjector
+	// Journal records the files copied and moved by the agent so that they can be reversed, if provided
+	Journal app.Journal
+	// HashCache stores the perceptual hashes of images so that unchanged images aren't decoded again, if provided
+	HashCache app.KeyValStore
+	// CollisionPolicy determines how a file of the same name at the destination is handled, defaulting to
+	// DefaultCollisionPolicy if not provided
+	CollisionPolicy string
+}
+
+// GetFilesForSession returns the files present within the provided session's directory, filtered by the provided
+// file extensions (if any), which are scanned according to the session's scan options
+func (f *FileSystemAgent) GetFilesForSession(sess *models.Session, exts ...string) ([]models.File, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	if !sess.Scan.Recursive {
+		return f.GetFilesFromDirectoryByExtension(sess.BaseDir, exts...)
+	}
+
+	// never re-ingest the output of this or any previous session
+	excludes := append([]string{sessionDirGlob}, sess.Scan.Excludes...)
+
+	files, err := f.FileSystem().GetFilesInDirectoryTree(sess.BaseDir, sess.Scan.MaxDepth, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterFilesByExtension(files, exts...), nil
 }
 
 // GetFilesFromDirectoryByExtension returns a slice of the files present within the provided directory path
@@ -176,9 +464,52 @@ func (f *FileSystemAgent) GetFilesFromDirectoryByExtension(dir string, exts ...s
 		return nil, err
 	}
 
+	return filterFilesByExtension(files, exts...), nil
+}
+
+// RemoveTempFiles removes the temporary files within the provided directory tree that were last modified before the
+// provided time, which have been left behind by copies that were cut short, and returns the files that were removed
+func (f *FileSystemAgent) RemoveTempFiles(dir string, modifiedBefore time.Time) ([]models.File, error) {
+	if !f.FileSystem().IsDirectory(dir) {
+		// nothing to remove
+		return nil, nil
+	}
+
+	files, err := f.FileSystem().GetFilesInDirectoryTree(dir, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []models.File
+	for _, file := range files {
+		if !isTempFile(file) || !file.CreatedAt.Before(modifiedBefore) {
+			continue
+		}
+
+		if err := f.FileSystem().Remove(file); err != nil {
+			if _, ok := err.(NotFoundError); ok {
+				// already removed or renamed into place in the meantime
+				continue
+			}
+			return removed, err
+		}
+		removed = append(removed, file)
+	}
+
+	return removed, nil
+}
+
+// isTempFile returns true if the provided file is a temporary file to which a copy is written, otherwise false
+func isTempFile(file models.File) bool {
+	name := file.NameWithExt()
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, tempFileSuffix)
+}
+
+// filterFilesByExtension returns the provided files that have one of the provided file extensions, or all of them if none are provided
+func filterFilesByExtension(files []models.File, exts ...string) []models.File {
 	if len(exts) == 0 {
 		// no filtering required
-		return files, nil
+		return files
 	}
 
 	var filtered []models.File
@@ -189,42 +520,173 @@ func (f *FileSystemAgent) GetFilesFromDirectoryByExtension(dir string, exts ...s
 		}
 	}
 
-	return filtered, nil
+	return filtered
 }
 
-// ProcessFileByCopy copies the provided file to the provided destination directory
-func (f *FileSystemAgent) ProcessFileByCopy(file models.File, destDir string) error {
-	if err := f.FileSystem().Copy(file, destDir); err != nil {
-		return err
+// ProcessFileByCopy copies the provided file to the provided destination directory,
+// handling any file of the same name that is already there according to the agent's collision policy
+func (f *FileSystemAgent) ProcessFileByCopy(file models.File, destDir string) (models.ProcessResult, error) {
+	return f.process(models.JournalOperationCopy, file, destDir)
+}
+
+// ProcessFileByMove moves the provided file to the provided destination directory,
+// handling any file of the same name that is already there according to the agent's collision policy
+func (f *FileSystemAgent) ProcessFileByMove(file models.File, destDir string) (models.ProcessResult, error) {
+	return f.process(models.JournalOperationMove, file, destDir)
+}
+
+// process copies or moves the provided file to the provided destination directory.
+// A file whose destination is already identical is left where it is, unless it is being moved with the
+// CollisionPolicyRemoveIdentical policy, in which case it is removed as its contents are already there
+func (f *FileSystemAgent) process(operation string, file models.File, destDir string) (models.ProcessResult, error) {
+	result, err := f.resolveCollision(file, models.NewFile(file.Name, file.Ext, destDir, nil))
+	if err != nil || result.Outcome == ProcessOutcomeSkipped {
+		return result, err
 	}
-	return nil
+
+	if result.Outcome == ProcessOutcomeSkippedIdentical {
+		if operation != models.JournalOperationMove || f.CollisionPolicy != CollisionPolicyRemoveIdentical {
+			return result, nil
+		}
+
+		if err := f.FileSystem().Remove(file); err != nil {
+			return result, err
+		}
+		result.Outcome = ProcessOutcomeRemovedIdentical
+
+		checksum, err := f.Checksum(result.Destination)
+		if err == nil {
+			err = f.record(models.JournalOperationRemove, file, result.Destination, checksum, false)
+		}
+		return f.recorded(result, err), nil
+	}
+
+	var checksum string
+	if operation == models.JournalOperationMove {
+		checksum, err = f.FileSystem().Move(file, result.Destination)
+	} else {
+		checksum, err = f.FileSystem().Copy(file, result.Destination)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if result.Outcome == "" {
+		result.Outcome = ProcessOutcomeCopied
+		if operation == models.JournalOperationMove {
+			result.Outcome = ProcessOutcomeMoved
+		}
+	}
+
+	err = f.record(operation, file, result.Destination, checksum, result.Outcome == ProcessOutcomeOverwritten)
+	return f.recorded(result, err), nil
 }
 
-// ProcessFileByMove moves the provided file to the provided destination directory
-func (f *FileSystemAgent) ProcessFileByMove(file models.File, destDir string) error {
-	if err := f.FileSystem().Move(file, destDir); err != nil {
-		return err
+// recorded returns the provided result of an operation that has already taken place, reporting the provided error
+// from recording it in the journal (if any) as an outcome of its own, since the operation itself has succeeded
+func (f *FileSystemAgent) recorded(result models.ProcessResult, err error) models.ProcessResult {
+	if err != nil {
+		log.Printf("cannot journal %s of %s: %s\n", result.Outcome, result.File.FullPath(), err)
+		result.Outcome = ProcessOutcomeNotJournaled
 	}
-	return nil
+
+	return result
 }
 
-// Stream writes the contents of the provided file to the provided response writer
-func (f *FileSystemAgent) Stream(file models.File, w http.ResponseWriter) error {
-	contents, err := f.FileSystem().GetContents(file)
+// Stream writes the contents of the provided file to the provided response writer without reading it into memory,
+// honouring any Range, If-Modified-Since, If-None-Match and If-Range headers of the provided request
+func (f *FileSystemAgent) Stream(file models.File, w http.ResponseWriter, r *http.Request) error {
+	info, err := f.FileSystem().Stat(file)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NotFoundError{Err: fmt.Errorf("not a file: %s", file.FullPath())}
+	}
+
+	contents, err := f.FileSystem().Open(file)
 	if err != nil {
 		return err
 	}
+	defer contents.Close()
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", http.DetectContentType(contents))
-	w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
-	w.Write(contents)
+	w.Header().Set("ETag", fileETag(info))
+	http.ServeContent(w, r, file.NameWithExt(), info.ModTime(), contents)
 
 	return nil
 }
 
+// fileETag returns an entity tag for the provided file, which changes whenever the file is modified
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// ResolveMetadata returns the metadata of the provided file, with its timestamp resolved from the first of
+// its exif data, its container metadata, its filename or its modified time to yield a result
+func (f *FileSystemAgent) ResolveMetadata(file models.File) models.FileMetadata {
+	var meta models.FileMetadata
+
+	exif, err := f.readExif(file)
+	if err == nil {
+		meta.CameraModel = exif.Model
+		if ts, ok := exif.TakenAt(); ok {
+			meta.Timestamp, meta.TimestampSource = ts, TimestampSourceExif
+			return meta
+		}
+	}
+
+	if ts, ok := f.parseTimestampFromContainer(file); ok {
+		meta.Timestamp, meta.TimestampSource = ts, TimestampSourceContainer
+		return meta
+	}
+
+	if ts, pattern, ok := f.TimestampPatternRegistry().Match(file.Name); ok {
+		meta.Timestamp, meta.TimestampSource, meta.TimestampPattern = ts, TimestampSourceFileName, pattern
+		return meta
+	}
+
+	meta.Timestamp, meta.TimestampSource = file.CreatedAt, TimestampSourceModTime
+	return meta
+}
+
+// readExif reads the exif data of the provided file
+func (f *FileSystemAgent) readExif(file models.File) (ExifData, error) {
+	if !contains(exifFileExts, file.Ext) {
+		return ExifData{}, ErrNoExif
+	}
+
+	r, err := f.FileSystem().Open(file)
+	if err != nil {
+		return ExifData{}, err
+	}
+	defer r.Close()
+
+	return ReadExif(r)
+}
+
+// parseTimestampFromContainer attempts to parse a recording timestamp from the container metadata of the provided file
+func (f *FileSystemAgent) parseTimestampFromContainer(file models.File) (time.Time, bool) {
+	if !contains(containerFileExts, file.Ext) {
+		return time.Time{}, false
+	}
+
+	r, err := f.FileSystem().Open(file)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer r.Close()
+
+	data, err := ReadContainer(r)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return data.RecordedAt()
+}
+
 // GetDirectoriesWithFileCountByExtension returns a slice of the directories present within the provided directory path
-// including the count of files within each one that has one of the provided extensions
+// including the count of files within each one that has one of the provided extensions, which is zero for directories
+// that can't be read
 func (f *FileSystemAgent) GetDirectoriesWithFileCountByExtension(dir string, exts ...string) ([]models.Directory, error) {
 	dirs, err := f.FileSystem().GetDirectoriesInDirectory(dir)
 	if err != nil {
@@ -234,6 +696,10 @@ func (f *FileSystemAgent) GetDirectoriesWithFileCountByExtension(dir string, ext
 	for idx := range dirs {
 		files, err := f.GetFilesFromDirectoryByExtension(dirs[idx].FullPath(), exts...)
 		if err != nil {
+			if os.IsPermission(err) {
+				// can't see inside, so leave uncounted
+				continue
+			}
 			return nil, err
 		}
 
@@ -256,45 +722,11 @@ func ParseNameAndExtensionFromFileName(fileName string) (string, string) {
 	return fileName, ext
 }
 
-// ParseTimestampFromFile attempts to parse a timestamp from the provided file
+// ParseTimestampFromFile attempts to parse a timestamp from the name of the provided file,
+// otherwise defaults to the file's created at timestamp
 func ParseTimestampFromFile(file models.File) time.Time {
-	// define potential date-based file naming patterns
-	tsLayouts := []string{
-		"20060102150405",
-		"20060102_150405",
-		"20060102-150405",
-		"Screenshot_20060102150405",
-		"Screenshot_20060102_150405",
-		"Screenshot_20060102-150405",
-		"Screenshot 2006-01-02 at 15.04.05",
-	}
-
-	var tsFromFileNameVariations = func(fileName string) []string {
-		variations := []string{
-			// original filename
-			fileName,
-		}
-
-		// var1 = "Screenshot_<timestamp>_<some_app_name>"
-		if strings.HasPrefix(fileName, "Screenshot_") {
-			// suffix = "<timestamp>_<some_app_name>"
-			suffix := strings.SplitN(fileName, "Screenshot_", 2)[1]
-			suffixParts := strings.Split(suffix, "_")
-
-			var1 := strings.Join(suffixParts[0:len(suffixParts)-1], "_")
-			variations = append(variations, var1)
-		}
-
-		return variations
-	}
-
-	for _, layout := range tsLayouts {
-		for _, variation := range tsFromFileNameVariations(file.Name) {
-			t, err := time.Parse(layout, variation)
-			if err == nil {
-				return t
-			}
-		}
+	if t, ok := ParseTimestampFromFileName(file.Name); ok {
+		return t
 	}
 
 	// filename could not be parsed by any of the expected patterns
@@ -302,13 +734,27 @@ func ParseTimestampFromFile(file models.File) time.Time {
 	return file.CreatedAt
 }
 
-// GetDestinationDirByDate returns a directory path based on the timestamp parsed from the provided file
-func GetDestinationDirByDate(file models.File, sess *models.Session) string {
+// ParseTimestampFromFileName attempts to parse a timestamp from the provided filename using the default
+// timestamp patterns, and returns whether the filename matched any of them
+func ParseTimestampFromFileName(fileName string) (time.Time, bool) {
+	ts, _, ok := defaultTimestampPatternRegistry.Match(fileName)
+	return ts, ok
+}
+
+// GetDestinationDirByDate returns a directory path based on the provided file and its resolved timestamp,
+// using the default layout
+func GetDestinationDirByDate(file models.File, ts time.Time, sess *models.Session) string {
+	layout, _ := ParseDirLayout(DefaultDirLayout)
+	return GetDestinationDirByLayout(layout, file, models.FileMetadata{Timestamp: ts}, sess)
+}
+
+// GetDestinationDirByLayout returns a directory path based on the provided layout, file and file metadata
+func GetDestinationDirByLayout(layout DirLayout, file models.File, meta models.FileMetadata, sess *models.Session) string {
 	if sess == nil {
 		return ""
 	}
 
-	return path.Join(sess.FullDir(SubDirByDate), file.Ext, ParseTimestampFromFile(file).Format("2006-01-02"))
+	return path.Join(sess.FullDir(SubDirByDate), layout.Render(file, meta))
 }
 
 // GetDestinationDirByTag returns a directory path based on the provided session and tag
@@ -1,9 +1,17 @@
 package domain_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/app"
 	"imgnheap/service/domain"
 	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
 	"time"
 )
@@ -19,6 +27,14 @@ var fileNamesContainingParseableTimestamp = []string{
 	"Screenshot_20180526_140029_MyFaceSpace",
 	"Screenshot_20180526-140029_MyFaceSpace",
 	"Screenshot 2018-05-26 at 14.00.29",
+	"Screen Shot 2018-05-26 at 14.00.29",
+	"IMG_20180526_140029",
+	"VID_20180526_140029",
+	"IMG_20180526_140029_HDR",
+	"PXL_20180526_140029123",
+	"PXL_20180526_140029123.PORTRAIT",
+	"signal-2018-05-26-140029",
+	"signal-2018-05-26-14-00-29-123",
 }
 
 var fileNamesContainingNoParseableTimestamp = []string{
@@ -69,6 +85,9 @@ var fileNamesContainingNoParseableTimestamp = []string{
 	"Screenshot 2006-01-02 at 1555.04.05",
 	"Screenshot 2006-01-02 at 15.0444.05",
 	"Screenshot 2006-01-02 at 15.04.0555",
+	"IMG_20060102_1504",
+	"PXL_20060102_150405",
+	"signal-2006-01-02",
 }
 
 func TestParseNameAndExtensionFromFileName(t *testing.T) {
@@ -145,12 +164,12 @@ func TestGetDestinationDirByDate(t *testing.T) {
 	t.Run("get destination dir by date using a filename that contains a parseable timestamp must return the expected result", func(t *testing.T) {
 		testCases := fileNamesContainingParseableTimestamp
 
-		expectedOutput := "/base/dir/subdir/by-date/2018-05-26"
+		expectedOutput := "/base/dir/subdir/by-date/jpg/2018-05-26"
 
 		for idx, tc := range testCases {
 			file := models.NewFile(tc, "jpg", "/base/dir", nil)
 
-			destDir := domain.GetDestinationDirByDate(file, &sess)
+			destDir := domain.GetDestinationDirByDate(file, domain.ParseTimestampFromFile(file), &sess)
 			if destDir != expectedOutput {
 				t.Fatalf("tc %d: expected %s, got %s", idx, expectedOutput, destDir)
 			}
@@ -160,12 +179,12 @@ func TestGetDestinationDirByDate(t *testing.T) {
 	t.Run("get destination dir by date using a filename that does not contain a parseable timestamp must return the expected result", func(t *testing.T) {
 		testCases := fileNamesContainingNoParseableTimestamp
 
-		expectedOutput := "/base/dir/subdir/by-date/0001-01-01"
+		expectedOutput := "/base/dir/subdir/by-date/jpg/0001-01-01"
 
 		for idx, tc := range testCases {
 			file := models.NewFile(tc, "jpg", "/base/dir", nil)
 
-			destDir := domain.GetDestinationDirByDate(file, &sess)
+			destDir := domain.GetDestinationDirByDate(file, domain.ParseTimestampFromFile(file), &sess)
 			if destDir != expectedOutput {
 				t.Fatalf("tc %d: expected %s, got %s", idx, expectedOutput, destDir)
 			}
@@ -175,7 +194,7 @@ func TestGetDestinationDirByDate(t *testing.T) {
 	t.Run("get destination dir by date using nil session must return blank string", func(t *testing.T) {
 		file := models.NewFile("hello_world", "jpg", "/base/dir", nil)
 
-		destDir := domain.GetDestinationDirByDate(file, nil)
+		destDir := domain.GetDestinationDirByDate(file, time.Now(), nil)
 		if destDir != "" {
 			t.Fatalf("expected empty string, got %s", destDir)
 		}
@@ -215,3 +234,237 @@ func TestGetDestinationDirByTag(t *testing.T) {
 		}
 	})
 }
+
+// faultyFileOps provides the OS file operations, failing at the provided stage of writing a file
+type faultyFileOps struct {
+	domain.OsFileOps
+	fail string
+}
+
+func (f faultyFileOps) CreateTemp(dir string, pattern string) (app.WritableFile, error) {
+	if f.fail == "create" {
+		return nil, errors.New("create failed")
+	}
+
+	file, err := f.OsFileOps.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultyFile{WritableFile: file, fail: f.fail}, nil
+}
+
+func (f faultyFileOps) Rename(oldPath string, newPath string) error {
+	if f.fail == "rename" {
+		return errors.New("rename failed")
+	}
+
+	return f.OsFileOps.Rename(oldPath, newPath)
+}
+
+// faultyFile provides a file that fails at the provided stage of being written
+type faultyFile struct {
+	app.WritableFile
+	fail string
+}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	switch f.fail {
+	case "write":
+		return 0, errors.New("write failed")
+	case "short write":
+		// only write half, but report writing everything
+		if _, err := f.WritableFile.Write(p[:len(p)/2]); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	case "corrupt write":
+		return f.WritableFile.Write(bytes.ToUpper(p))
+	}
+
+	return f.WritableFile.Write(p)
+}
+
+func (f *faultyFile) Sync() error {
+	if f.fail == "sync" {
+		return errors.New("sync failed")
+	}
+
+	return f.WritableFile.Sync()
+}
+
+func (f *faultyFile) Close() error {
+	if err := f.WritableFile.Close(); err != nil {
+		return err
+	}
+	if f.fail == "close" {
+		return errors.New("close failed")
+	}
+
+	return nil
+}
+
+func TestOsFileSystem_Copy(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	file := models.NewFile("a", "jpg", baseDir, nil)
+	if err := ioutil.WriteFile(file.FullPath(), []byte("complete"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// dirContents returns the names of the files within the provided directory
+	dirContents := func(t *testing.T, dir string) []string {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	t.Run("copying a file must put an identical copy in place without leaving a temporary file", func(t *testing.T) {
+		fs := &domain.OsFileSystem{VerifyChecksum: true}
+		dest := models.NewFile("b", "jpg", path.Join(baseDir, "success", "nested"), nil)
+
+		checksum, err := fs.Copy(file, dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("%x", sha256.Sum256([]byte("complete"))); checksum != expected {
+			t.Fatalf("expected checksum %s, got %s", expected, checksum)
+		}
+
+		contents, err := ioutil.ReadFile(dest.FullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "complete" {
+			t.Fatalf("expected complete, got %s", contents)
+		}
+
+		info, err := os.Stat(dest.FullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0640 {
+			t.Fatalf("expected mode 0640, got %s", info.Mode().Perm())
+		}
+
+		expected := []string{"b.jpg"}
+		actual := dirContents(t, dest.DirPath)
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, actual, diff)
+		}
+	})
+
+	t.Run("copying a file that fails to be written must return an error and leave the existing destination untouched", func(t *testing.T) {
+		testCases := []string{"create", "write", "short write", "sync", "close", "rename"}
+
+		for idx, fail := range testCases {
+			fs := &domain.OsFileSystem{FileOps: faultyFileOps{fail: fail}}
+			dest := models.NewFile("b", "jpg", path.Join(baseDir, "fail", fail), nil)
+			if err := os.MkdirAll(dest.DirPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(dest.FullPath(), []byte("existing"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := fs.Copy(file, dest); err == nil {
+				t.Errorf("tc %d: expected error, got nil", idx)
+				continue
+			}
+
+			contents, err := ioutil.ReadFile(dest.FullPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(contents) != "existing" {
+				t.Errorf("tc %d: expected existing, got %s", idx, contents)
+			}
+
+			expected := []string{"b.jpg"}
+			actual := dirContents(t, dest.DirPath)
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Errorf("tc %d: want %+v, got %+v, diff: %s", idx, expected, actual, diff)
+			}
+		}
+	})
+
+	t.Run("copying a file that is corrupted whilst being written must only return an error if verifying the checksum", func(t *testing.T) {
+		unverified := &domain.OsFileSystem{FileOps: faultyFileOps{fail: "corrupt write"}}
+		dest := models.NewFile("unverified", "jpg", path.Join(baseDir, "corrupt"), nil)
+		if _, err := unverified.Copy(file, dest); err != nil {
+			t.Fatal(err)
+		}
+
+		verified := &domain.OsFileSystem{FileOps: faultyFileOps{fail: "corrupt write"}, VerifyChecksum: true}
+		dest = models.NewFile("verified", "jpg", path.Join(baseDir, "corrupt"), nil)
+		if _, err := verified.Copy(file, dest); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		expected := []string{"unverified.jpg"}
+		actual := dirContents(t, dest.DirPath)
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, actual, diff)
+		}
+	})
+}
+
+func TestOsFileSystem_Move(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	file := models.NewFile("a", "jpg", baseDir, nil)
+	if err := ioutil.WriteFile(file.FullPath(), []byte("complete"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("moving a file that fails to be copied must keep the original", func(t *testing.T) {
+		fs := &domain.OsFileSystem{FileOps: faultyFileOps{fail: "sync"}}
+		dest := models.NewFile("b", "jpg", path.Join(baseDir, "dest"), nil)
+
+		if _, err := fs.Move(file, dest); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		if _, err := os.Stat(file.FullPath()); err != nil {
+			t.Fatalf("expected original to be kept, got %v", err)
+		}
+		if _, err := os.Stat(dest.FullPath()); !os.IsNotExist(err) {
+			t.Fatalf("expected no destination, got %v", err)
+		}
+	})
+
+	t.Run("moving a file must remove the original once the copy is in place", func(t *testing.T) {
+		fs := &domain.OsFileSystem{}
+		dest := models.NewFile("c", "jpg", path.Join(baseDir, "dest"), nil)
+
+		if _, err := fs.Move(file, dest); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(file.FullPath()); !os.IsNotExist(err) {
+			t.Fatalf("expected original to be removed, got %v", err)
+		}
+		contents, err := ioutil.ReadFile(dest.FullPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "complete" {
+			t.Fatalf("expected complete, got %s", contents)
+		}
+	})
+}
@@ -0,0 +1,374 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"sync"
+	"time"
+)
+
+const (
+	// maxJobErrors limits the number of error messages (and notices) that are retained by a single job
+	maxJobErrors = 100
+	// jobRetention represents how long a finished job is retained for before it is discarded
+	jobRetention = 24 * time.Hour
+)
+
+// routineOutcomes represents the outcomes of processing an item that are counted but not noted individually
+var routineOutcomes = []string{
+	ProcessOutcomeCopied,
+	ProcessOutcomeMoved,
+}
+
+// InMemoryJobRunner defines an in-memory runner of background jobs
+type InMemoryJobRunner struct {
+	app.JobRunner
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+	jobs         map[string]*runningJob
+	shuttingDown bool
+}
+
+// runningJob represents the state held by the runner for a single job
+type runningJob struct {
+	job         models.Job
+	cancel      context.CancelFunc
+	subscribers map[chan models.Job]struct{}
+}
+
+// Start implements app.JobRunner.Start()
+func (i *InMemoryJobRunner) Start(job models.Job, fn app.JobFunc) (models.Job, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	job.ID = id.String()
+	job.Status = models.JobStatusRunning
+	job.StartedAt = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	i.mu.Lock()
+	if i.shuttingDown {
+		i.mu.Unlock()
+		cancel()
+		return models.Job{}, ErrShuttingDown
+	}
+	i.discardExpiredJobs()
+	i.jobs[job.ID] = &runningJob{
+		job:         job,
+		cancel:      cancel,
+		subscribers: make(map[chan models.Job]struct{}),
+	}
+	i.wg.Add(1)
+	i.mu.Unlock()
+
+	go func() {
+		var err error
+		defer i.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+			i.finish(job.ID, err)
+			cancel()
+		}()
+
+		err = fn(ctx, &jobProgress{runner: i, id: job.ID})
+	}()
+
+	return job, nil
+}
+
+// Get implements app.JobRunner.Get()
+func (i *InMemoryJobRunner) Get(id string) (models.Job, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rj, ok := i.jobs[id]
+	if !ok {
+		return models.Job{}, jobNotFoundError(id)
+	}
+
+	return snapshotJob(rj.job), nil
+}
+
+// Cancel implements app.JobRunner.Cancel()
+func (i *InMemoryJobRunner) Cancel(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rj, ok := i.jobs[id]
+	if !ok {
+		return jobNotFoundError(id)
+	}
+	if rj.job.IsFinished() {
+		return ValidationError{Err: fmt.Errorf("job %s has already finished", id)}
+	}
+
+	rj.cancel()
+	return nil
+}
+
+// Shutdown stops any further jobs from starting and cancels those that are running, then waits for them to finish
+// or for the provided context to be done. Jobs that observe cancellation between items finish their current item first
+func (i *InMemoryJobRunner) Shutdown(ctx context.Context) error {
+	i.mu.Lock()
+	i.shuttingDown = true
+	for _, rj := range i.jobs {
+		if !rj.job.IsFinished() {
+			rj.cancel()
+		}
+	}
+	i.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		i.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe implements app.JobRunner.Subscribe()
+// The returned channel receives the job's current state straight away, then the latest state following each change,
+// and is closed once the job has finished
+func (i *InMemoryJobRunner) Subscribe(id string) (<-chan models.Job, func(), error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rj, ok := i.jobs[id]
+	if !ok {
+		return nil, nil, jobNotFoundError(id)
+	}
+
+	ch := make(chan models.Job, 1)
+	ch <- snapshotJob(rj.job)
+
+	if rj.job.IsFinished() {
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	rj.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		delete(rj.subscribers, ch)
+	}
+
+	return ch, unsubscribe, nil
+}
+
+// update applies the provided function to the job with the provided ID and notifies its subscribers
+func (i *InMemoryJobRunner) update(id string, fn func(job *models.Job)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rj, ok := i.jobs[id]
+	if !ok {
+		return
+	}
+
+	fn(&rj.job)
+	rj.publish()
+}
+
+// finish records the outcome of the job with the provided ID and closes its subscriptions. A job is only cancelled
+// if it stopped because of its cancellation, so a job that finished its work before being cancelled is completed
+func (i *InMemoryJobRunner) finish(id string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rj, ok := i.jobs[id]
+	if !ok {
+		return
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		rj.job.Status = models.JobStatusCancelled
+	case err != nil:
+		rj.job.Status = models.JobStatusFailed
+		rj.job.Errors = append(rj.job.Errors, err.Error())
+	default:
+		rj.job.Status = models.JobStatusCompleted
+	}
+	rj.job.Current = ""
+	rj.job.FinishedAt = time.Now()
+
+	rj.publish()
+	for ch := range rj.subscribers {
+		delete(rj.subscribers, ch)
+		close(ch)
+	}
+}
+
+// discardExpiredJobs removes jobs that finished longer ago than the retention period, and must be called whilst locked
+func (i *InMemoryJobRunner) discardExpiredJobs() {
+	for id, rj := range i.jobs {
+		if rj.job.IsFinished() && time.Since(rj.job.FinishedAt) > jobRetention {
+			delete(i.jobs, id)
+		}
+	}
+}
+
+// publish sends the job's latest state to each of its subscribers, replacing any state that hasn't yet been received
+func (r *runningJob) publish() {
+	snap := snapshotJob(r.job)
+
+	for ch := range r.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snap
+	}
+}
+
+// NewInMemoryJobRunner returns a newly-instantiated InMemoryJobRunner
+func NewInMemoryJobRunner() *InMemoryJobRunner {
+	return &InMemoryJobRunner{
+		jobs: make(map[string]*runningJob),
+	}
+}
+
+// jobProgress reports the progress of a single job to its runner
+type jobProgress struct {
+	runner *InMemoryJobRunner
+	id     string
+}
+
+// Processing implements app.JobProgress.Processing()
+func (j *jobProgress) Processing(item string) {
+	j.runner.update(j.id, func(job *models.Job) {
+		job.Current = item
+	})
+}
+
+// Processed implements app.JobProgress.Processed()
+func (j *jobProgress) Processed(item string, outcome string, err error) {
+	j.runner.update(j.id, func(job *models.Job) {
+		job.Done++
+		if err != nil {
+			job.Failed++
+			if len(job.Errors) < maxJobErrors {
+				job.Errors = append(job.Errors, fmt.Sprintf("%s: %s", item, err))
+			}
+			return
+		}
+		if outcome == "" {
+			return
+		}
+		if job.Outcomes == nil {
+			job.Outcomes = make(map[string]int)
+		}
+		job.Outcomes[outcome]++
+		if !contains(routineOutcomes, outcome) && len(job.Notices) < maxJobErrors {
+			job.Notices = append(job.Notices, fmt.Sprintf("%s: %s", item, outcome))
+		}
+	})
+}
+
+// noopJobProgress discards the progress that is reported to it
+type noopJobProgress struct{}
+
+func (noopJobProgress) Processing(string)               {}
+func (noopJobProgress) Processed(string, string, error) {}
+
+// snapshotJob returns a copy of the provided job that is safe to hand to other goroutines
+func snapshotJob(job models.Job) models.Job {
+	job.Errors = append([]string(nil), job.Errors...)
+	job.Notices = append([]string(nil), job.Notices...)
+	if job.Outcomes != nil {
+		outcomes := make(map[string]int, len(job.Outcomes))
+		for outcome, count := range job.Outcomes {
+			outcomes[outcome] = count
+		}
+		job.Outcomes = outcomes
+	}
+	return job
+}
+
+// jobNotFoundError returns a new NotFoundError based on the provided job ID
+func jobNotFoundError(id string) NotFoundError {
+	return NotFoundError{Err: fmt.Errorf("no job found with id %s", id)}
+}
+
+// JobAgentInjector defines the injector behaviours for our JobAgent
+type JobAgentInjector interface {
+	app.JobRunnerInjector
+}
+
+// JobAgent represents our methods for running and tracking the background jobs of a session
+type JobAgent struct {
+	JobAgentInjector
+}
+
+// StartJobForSession starts a new background job with the provided name and total number of items
+func (j *JobAgent) StartJobForSession(sess *models.Session, name string, total int, fn app.JobFunc) (models.Job, error) {
+	return j.StartScanForSession(sess, name, total, "", fn)
+}
+
+// StartScanForSession starts a new background job with the provided name and total number of items, like
+// StartJobForSession, whose results can be viewed at the provided path once it has completed
+func (j *JobAgent) StartScanForSession(sess *models.Session, name string, total int, resultPath string, fn app.JobFunc) (models.Job, error) {
+	if sess == nil {
+		return models.Job{}, errors.New("session is nil")
+	}
+
+	return j.JobRunner().Start(models.Job{
+		SessionToken: sess.Token,
+		Name:         name,
+		Total:        total,
+		ResultPath:   resultPath,
+	}, fn)
+}
+
+// GetJobForSession retrieves the job with the provided ID, which must belong to the provided session
+func (j *JobAgent) GetJobForSession(id string, sess *models.Session) (models.Job, error) {
+	if sess == nil {
+		return models.Job{}, errors.New("session is nil")
+	}
+
+	job, err := j.JobRunner().Get(id)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	if job.SessionToken != sess.Token {
+		// don't reveal jobs that belong to other sessions
+		return models.Job{}, jobNotFoundError(id)
+	}
+
+	return job, nil
+}
+
+// CancelJobForSession cancels the job with the provided ID, which must belong to the provided session
+func (j *JobAgent) CancelJobForSession(id string, sess *models.Session) error {
+	if _, err := j.GetJobForSession(id, sess); err != nil {
+		return err
+	}
+
+	return j.JobRunner().Cancel(id)
+}
+
+// SubscribeForSession subscribes to updates of the job with the provided ID, which must belong to the provided session
+func (j *JobAgent) SubscribeForSession(id string, sess *models.Session) (<-chan models.Job, func(), error) {
+	if _, err := j.GetJobForSession(id, sess); err != nil {
+		return nil, nil, err
+	}
+
+	return j.JobRunner().Subscribe(id)
+}
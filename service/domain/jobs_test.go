@@ -0,0 +1,215 @@
+package domain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"testing"
+	"time"
+)
+
+// waitForJob returns the final state of the job with the provided ID, received by way of a subscription
+func waitForJob(t *testing.T, runner *domain.InMemoryJobRunner, id string) models.Job {
+	updates, unsubscribe, err := runner.Subscribe(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsubscribe()
+
+	var job models.Job
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return job
+			}
+			job = update
+		case <-timeout:
+			t.Fatal("timed out waiting for job to finish")
+		}
+	}
+}
+
+func TestInMemoryJobRunner(t *testing.T) {
+	t.Run("running job must report progress and complete", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+
+		job, err := runner.Start(models.Job{Name: "test", Total: 3}, func(ctx context.Context, progress app.JobProgress) error {
+			for _, item := range []string{"a", "b", "c"} {
+				progress.Processing(item)
+				var err error
+				if item == "b" {
+					err = errors.New("oops")
+				}
+				progress.Processed(item, domain.ProcessOutcomeCopied, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		final := waitForJob(t, runner, job.ID)
+
+		if final.Status != models.JobStatusCompleted {
+			t.Fatalf("expected %s, got %s", models.JobStatusCompleted, final.Status)
+		}
+		if final.Done != 3 || final.Failed != 1 || len(final.Errors) != 1 || final.Outcomes[domain.ProcessOutcomeCopied] != 2 {
+			t.Fatalf("expected 3 done and 1 failed, got %+v", final)
+		}
+	})
+
+	t.Run("returning an error from job must mark it as failed", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+
+		job, err := runner.Start(models.Job{Name: "test"}, func(ctx context.Context, progress app.JobProgress) error {
+			return errors.New("oops")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if final := waitForJob(t, runner, job.ID); final.Status != models.JobStatusFailed {
+			t.Fatalf("expected %s, got %s", models.JobStatusFailed, final.Status)
+		}
+	})
+
+	t.Run("cancelling running job must mark it as cancelled", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+		started := make(chan struct{})
+
+		job, err := runner.Start(models.Job{Name: "test"}, func(ctx context.Context, progress app.JobProgress) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		<-started
+		if err := runner.Cancel(job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if final := waitForJob(t, runner, job.ID); final.Status != models.JobStatusCancelled {
+			t.Fatalf("expected %s, got %s", models.JobStatusCancelled, final.Status)
+		}
+
+		if err := runner.Cancel(job.ID); err == nil {
+			t.Fatal("expected error cancelling finished job, got nil")
+		}
+	})
+
+	t.Run("cancelling job after it has finished its work must mark it as completed", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+		finishedWork := make(chan struct{})
+		cancelled := make(chan struct{})
+
+		job, err := runner.Start(models.Job{Name: "test"}, func(ctx context.Context, progress app.JobProgress) error {
+			close(finishedWork)
+			<-cancelled
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		<-finishedWork
+		if err := runner.Cancel(job.ID); err != nil {
+			t.Fatal(err)
+		}
+		close(cancelled)
+
+		if final := waitForJob(t, runner, job.ID); final.Status != models.JobStatusCompleted {
+			t.Fatalf("expected %s, got %s", models.JobStatusCompleted, final.Status)
+		}
+	})
+
+	t.Run("returning a wrapped cancellation from job must mark it as cancelled", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+
+		job, err := runner.Start(models.Job{Name: "test"}, func(ctx context.Context, progress app.JobProgress) error {
+			return fmt.Errorf("stopped: %w", context.Canceled)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if final := waitForJob(t, runner, job.ID); final.Status != models.JobStatusCancelled {
+			t.Fatalf("expected %s, got %s", models.JobStatusCancelled, final.Status)
+		}
+	})
+
+	t.Run("shutting down must cancel running jobs and wait for them to finish", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+		started := make(chan struct{})
+		finishedItem := false
+
+		job, err := runner.Start(models.Job{Name: "test", Total: 1}, func(ctx context.Context, progress app.JobProgress) error {
+			close(started)
+			<-ctx.Done()
+
+			// finish current item before stopping
+			time.Sleep(20 * time.Millisecond)
+			finishedItem = true
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		<-started
+		if err := runner.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !finishedItem {
+			t.Fatal("expected shutting down to wait for the job to finish its current item")
+		}
+		if final, _ := runner.Get(job.ID); final.Status != models.JobStatusCancelled {
+			t.Fatalf("expected %s, got %s", models.JobStatusCancelled, final.Status)
+		}
+
+		_, err = runner.Start(models.Job{Name: "late"}, func(ctx context.Context, progress app.JobProgress) error {
+			return nil
+		})
+		if err != domain.ErrShuttingDown {
+			t.Fatalf("expected ErrShuttingDown, got %v", err)
+		}
+	})
+
+	t.Run("shutting down past the deadline must return an error", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+		release := make(chan struct{})
+		defer close(release)
+
+		if _, err := runner.Start(models.Job{Name: "test"}, func(ctx context.Context, progress app.JobProgress) error {
+			<-release
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		if err := runner.Shutdown(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("retrieving unknown job must return not found error", func(t *testing.T) {
+		runner := domain.NewInMemoryJobRunner()
+
+		if _, err := runner.Get("unknown"); err == nil {
+			t.Fatal("expected error, got nil")
+		} else if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected not found error, got %+v", err)
+		}
+	})
+}
@@ -0,0 +1,281 @@
+package domain
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// JournalFileName represents the name of the journal file that is kept within a session's directory
+const JournalFileName = "journal.jsonl"
+
+// journalMu serialises writes to journal files, which may be shared by concurrent requests for the same session
+var journalMu sync.Mutex
+
+// FileJournal defines a journal that is persisted as a file of JSON lines
+type FileJournal struct {
+	app.Journal
+	path string
+}
+
+// Append implements app.Journal.Append()
+func (f *FileJournal) Append(entry models.JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	if err := os.MkdirAll(path.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Entries implements app.Journal.Entries()
+func (f *FileJournal) Entries() ([]models.JournalEntry, error) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// nothing has been recorded yet
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []models.JournalEntry
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry models.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry in %s: %s", f.path, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// Path returns the path of the journal file
+func (f *FileJournal) Path() string {
+	return f.path
+}
+
+// NewFileJournal returns a newly-instantiated FileJournal that is persisted at the provided path
+func NewFileJournal(path string) *FileJournal {
+	return &FileJournal{path: path}
+}
+
+// NewSessionJournal returns a newly-instantiated FileJournal that is persisted within the provided session's directory
+func NewSessionJournal(sess *models.Session) *FileJournal {
+	return NewFileJournal(sess.FullDir(JournalFileName))
+}
+
+// PendingJournalEntries returns the copy and move entries of the provided journal entries that have not been undone,
+// in the order in which they were recorded
+func PendingJournalEntries(entries []models.JournalEntry) []models.JournalEntry {
+	undone := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Operation == models.JournalOperationUndo {
+			undone[entry.UndoneID] = true
+		}
+	}
+
+	var pending []models.JournalEntry
+	for _, entry := range entries {
+		if entry.Operation != models.JournalOperationUndo && !undone[entry.ID] {
+			pending = append(pending, entry)
+		}
+	}
+
+	return pending
+}
+
+// Checksum returns the hex-encoded SHA-256 checksum of the contents of the provided file
+func (f *FileSystemAgent) Checksum(file models.File) (string, error) {
+	r, err := f.FileSystem().Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PendingUndos returns the recorded copies and moves of the agent's journal that have not been undone
+func (f *FileSystemAgent) PendingUndos() ([]models.JournalEntry, error) {
+	if f.Journal == nil {
+		return nil, nil
+	}
+
+	entries, err := f.Journal.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	return PendingJournalEntries(entries), nil
+}
+
+// UndoLast reverses the most recent copy or move recorded by the agent's journal that has not been undone
+func (f *FileSystemAgent) UndoLast() (models.JournalEntry, error) {
+	pending, err := f.PendingUndos()
+	if err != nil {
+		return models.JournalEntry{}, err
+	}
+	if len(pending) == 0 {
+		return models.JournalEntry{}, NotFoundError{Err: errors.New("nothing to undo")}
+	}
+
+	entry := pending[len(pending)-1]
+	if err := f.undo(entry); err != nil {
+		return models.JournalEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// UndoAll reverses every copy and move recorded by the agent's journal that has not been undone, most recent first,
+// and returns the entries that were reversed before any error occurred
+func (f *FileSystemAgent) UndoAll() ([]models.JournalEntry, error) {
+	pending, err := f.PendingUndos()
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []models.JournalEntry
+	for idx := len(pending) - 1; idx >= 0; idx-- {
+		if err := f.undo(pending[idx]); err != nil {
+			return undone, err
+		}
+		undone = append(undone, pending[idx])
+	}
+
+	return undone, nil
+}
+
+// record appends an entry for the provided operation to the agent's journal, if it has one, along with the provided
+// checksum of the destination
+func (f *FileSystemAgent) record(operation string, file, dest models.File, checksum string, overwrote bool) error {
+	if f.Journal == nil {
+		return nil
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+
+	return f.Journal.Append(models.JournalEntry{
+		ID:          id.String(),
+		Operation:   operation,
+		Source:      file.FullPath(),
+		Destination: dest.FullPath(),
+		Checksum:    checksum,
+		Overwrote:   overwrote,
+		Time:        time.Now(),
+	})
+}
+
+// undo reverses the provided journal entry and records that it has been undone
+func (f *FileSystemAgent) undo(entry models.JournalEntry) error {
+	if entry.Overwrote {
+		return ValidationError{Err: fmt.Errorf("cannot undo %s: it overwrote a file that cannot be restored", entry.Destination)}
+	}
+
+	dest := fileFromPath(entry.Destination)
+
+	// make sure that we're not about to discard changes made since the operation
+	checksum, err := f.Checksum(dest)
+	if err != nil {
+		return err
+	}
+	if checksum != entry.Checksum {
+		return ValidationError{Err: fmt.Errorf("cannot undo %s: file has changed since it was processed", entry.Destination)}
+	}
+
+	switch entry.Operation {
+	case models.JournalOperationCopy:
+		if err := f.FileSystem().Remove(dest); err != nil {
+			return err
+		}
+	case models.JournalOperationMove:
+		source := fileFromPath(entry.Source)
+		if _, err := f.FileSystem().Stat(source); err == nil {
+			return ValidationError{Err: fmt.Errorf("cannot undo %s: %s already exists", entry.Destination, entry.Source)}
+		}
+		if _, err := f.FileSystem().Move(dest, source); err != nil {
+			return err
+		}
+	case models.JournalOperationRemove:
+		// restore the removed file from the identical file that was kept
+		source := fileFromPath(entry.Source)
+		if _, err := f.FileSystem().Stat(source); err == nil {
+			return ValidationError{Err: fmt.Errorf("cannot undo removal of %s: it already exists", entry.Source)}
+		}
+		if _, err := f.FileSystem().Copy(dest, source); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot undo operation: %s", entry.Operation)
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return err
+	}
+
+	return f.Journal.Append(models.JournalEntry{
+		ID:        id.String(),
+		Operation: models.JournalOperationUndo,
+		UndoneID:  entry.ID,
+		Time:      time.Now(),
+	})
+}
+
+// fileFromPath returns a file object from the provided full file path
+func fileFromPath(filePath string) models.File {
+	name, ext := ParseNameAndExtensionFromFileName(path.Base(filePath))
+	return models.NewFile(name, ext, path.Dir(filePath), nil)
+}
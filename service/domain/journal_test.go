@@ -0,0 +1,202 @@
+package domain_test
+
+import (
+	"errors"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+// failingJournal provides a journal that cannot be appended to
+type failingJournal struct{}
+
+func (failingJournal) Append(models.JournalEntry) error        { return errors.New("disk full") }
+func (failingJournal) Entries() ([]models.JournalEntry, error) { return nil, nil }
+
+func TestFileSystemAgent_Undo(t *testing.T) {
+	setup := func(t *testing.T) (string, domain.FileSystemAgent, func()) {
+		baseDir, err := ioutil.TempDir("", "imgnheap")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range []string{"a.jpg", "b.jpg"} {
+			if err := ioutil.WriteFile(path.Join(baseDir, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fsAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, "subdir", domain.JournalFileName)),
+		}
+
+		return baseDir, fsAgent, func() { os.RemoveAll(baseDir) }
+	}
+
+	exists := func(filePath string) bool {
+		_, err := os.Stat(filePath)
+		return err == nil
+	}
+
+	t.Run("undoing the last operation must move a moved file back to its source", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		destDir := path.Join(baseDir, "subdir", "tag")
+		if _, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), destDir); err != nil {
+			t.Fatal(err)
+		}
+
+		entry, err := fsAgent.UndoLast()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.Operation != models.JournalOperationMove {
+			t.Fatalf("expected %s, got %s", models.JournalOperationMove, entry.Operation)
+		}
+		if !exists(path.Join(baseDir, "a.jpg")) {
+			t.Fatal("expected source file to exist")
+		}
+		if exists(path.Join(destDir, "a.jpg")) {
+			t.Fatal("expected destination file not to exist")
+		}
+
+		if _, err := fsAgent.UndoLast(); err == nil {
+			t.Fatal("expected error, got nil")
+		} else if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected NotFoundError, got %T", err)
+		}
+	})
+
+	t.Run("processing a file that cannot be journaled must report it as such rather than as failed", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		fsAgent.Journal = failingJournal{}
+		destDir := path.Join(baseDir, "subdir", "tag")
+
+		result, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), destDir)
+		if err != nil {
+			t.Fatalf("expected move to succeed, got %s", err)
+		}
+		if result.Outcome != domain.ProcessOutcomeNotJournaled {
+			t.Fatalf("expected %s, got %s", domain.ProcessOutcomeNotJournaled, result.Outcome)
+		}
+		if exists(path.Join(baseDir, "a.jpg")) || !exists(path.Join(destDir, "a.jpg")) {
+			t.Fatal("expected file to have been moved")
+		}
+	})
+
+	t.Run("processing a file must journal the checksum of its destination", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		if _, err := fsAgent.ProcessFileByCopy(models.NewFile("b", "jpg", baseDir, nil), path.Join(baseDir, "subdir", "by-date")); err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err := fsAgent.Journal.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checksum, err := fsAgent.Checksum(models.NewFile("b", "jpg", path.Join(baseDir, "subdir", "by-date"), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Checksum != checksum {
+			t.Fatalf("expected entry with checksum %s, got %+v", checksum, entries)
+		}
+	})
+
+	t.Run("undoing the session must remove every copied file, most recent first", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		destDir := path.Join(baseDir, "subdir", "tag")
+		for _, name := range []string{"a", "b"} {
+			if _, err := fsAgent.ProcessFileByCopy(models.NewFile(name, "jpg", baseDir, nil), destDir); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		undone, err := fsAgent.UndoAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedDestinations := []string{path.Join(destDir, "b.jpg"), path.Join(destDir, "a.jpg")}
+		if len(undone) != len(expectedDestinations) {
+			t.Fatalf("expected %d undone entries, got %d", len(expectedDestinations), len(undone))
+		}
+		for idx, entry := range undone {
+			if entry.Destination != expectedDestinations[idx] {
+				t.Fatalf("tc %d: expected %s, got %s", idx, expectedDestinations[idx], entry.Destination)
+			}
+			if exists(entry.Destination) {
+				t.Fatalf("tc %d: expected %s not to exist", idx, entry.Destination)
+			}
+			if !exists(entry.Source) {
+				t.Fatalf("tc %d: expected %s to exist", idx, entry.Source)
+			}
+		}
+
+		pending, err := fsAgent.PendingUndos()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 0 {
+			t.Fatalf("expected no pending undos, got %d", len(pending))
+		}
+	})
+
+	t.Run("undoing a file that has changed since it was processed must return a validation error", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		destDir := path.Join(baseDir, "subdir", "tag")
+		if _, err := fsAgent.ProcessFileByCopy(models.NewFile("a", "jpg", baseDir, nil), destDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(destDir, "a.jpg"), []byte("edited"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := fsAgent.UndoLast()
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+		if !exists(path.Join(destDir, "a.jpg")) {
+			t.Fatal("expected edited file to be retained")
+		}
+	})
+
+	t.Run("journal entries must persist across journal instances", func(t *testing.T) {
+		baseDir, fsAgent, teardown := setup(t)
+		defer teardown()
+
+		if _, err := fsAgent.ProcessFileByMove(models.NewFile("a", "jpg", baseDir, nil), path.Join(baseDir, "subdir", "tag")); err != nil {
+			t.Fatal(err)
+		}
+
+		reopened := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}},
+			Journal:                 domain.NewFileJournal(path.Join(baseDir, "subdir", domain.JournalFileName)),
+		}
+
+		pending, err := reopened.PendingUndos()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("expected 1 pending undo, got %d", len(pending))
+		}
+		if pending[0].Source != path.Join(baseDir, "a.jpg") {
+			t.Fatalf("expected %s, got %s", path.Join(baseDir, "a.jpg"), pending[0].Source)
+		}
+	})
+}
@@ -1,35 +1,477 @@
 package domain
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"imgnheap/service/app"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sync"
+	"time"
 )
 
+// DefaultKeyValSweepInterval represents how often expired values are swept from a key/value store by default
+const DefaultKeyValSweepInterval = time.Minute
+
+// keyValEntry represents a single value held by a key/value store
+type keyValEntry struct {
+	val       []byte
+	expiresAt time.Time
+}
+
+// isExpired returns true if the entry has an expiry which is not after the provided time, otherwise false
+func (k keyValEntry) isExpired(now time.Time) bool {
+	return !k.expiresAt.IsZero() && !k.expiresAt.After(now)
+}
+
+// newKeyValEntry returns a keyValEntry for the provided value which expires after the provided ttl,
+// or never expires if the ttl is zero
+func newKeyValEntry(val []byte, ttl time.Duration) keyValEntry {
+	entry := keyValEntry{val: val}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	return entry
+}
+
 // InMemoryKeyValStore defines an in-memory key/value store
 type InMemoryKeyValStore struct {
 	app.KeyValStore
-	mem map[string]interface{}
+	mu  sync.Mutex
+	mem map[string]keyValEntry
 }
 
 // Read implements app.KeyValStore.Read()
-func (i *InMemoryKeyValStore) Read(key string) (interface{}, error) {
-	val, ok := i.mem[key]
-	if !ok {
-		return "", NotFoundError{Err: fmt.Errorf("no value found at key %s", key)}
+func (i *InMemoryKeyValStore) Read(key string) ([]byte, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entry, ok := i.mem[key]
+	if !ok || entry.isExpired(time.Now()) {
+		return nil, NotFoundError{Err: fmt.Errorf("no value found at key %s", key)}
 	}
 
-	return val, nil
+	return entry.val, nil
 }
 
 // Write implements app.KeyValStore.Write()
-func (i *InMemoryKeyValStore) Write(key string, val interface{}) error {
-	i.mem[key] = val
+func (i *InMemoryKeyValStore) Write(key string, val []byte) error {
+	return i.WriteWithTTL(key, val, 0)
+}
+
+// WriteWithTTL implements app.KeyValStore.WriteWithTTL()
+func (i *InMemoryKeyValStore) WriteWithTTL(key string, val []byte, ttl time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.mem[key] = newKeyValEntry(val, ttl)
 	return nil
 }
 
+// CompareAndSwap implements app.KeyValStore.CompareAndSwap()
+func (i *InMemoryKeyValStore) CompareAndSwap(key string, oldVal []byte, newVal []byte, ttl time.Duration) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	entry, ok := i.mem[key]
+	if !ok || entry.isExpired(time.Now()) || !bytes.Equal(entry.val, oldVal) {
+		return false, nil
+	}
+
+	i.mem[key] = newKeyValEntry(newVal, ttl)
+	return true, nil
+}
+
+// Delete implements app.KeyValStore.Delete()
+func (i *InMemoryKeyValStore) Delete(key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.mem, key)
+	return nil
+}
+
+// Sweep removes the values that have expired
+func (i *InMemoryKeyValStore) Sweep() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range i.mem {
+		if entry.isExpired(now) {
+			delete(i.mem, key)
+		}
+	}
+}
+
 // NewInMemoryKeyValStore returns a newly-instantiated InMemoryKeyValStore
 func NewInMemoryKeyValStore() *InMemoryKeyValStore {
 	return &InMemoryKeyValStore{
-		mem: make(map[string]interface{}),
+		mem: make(map[string]keyValEntry),
+	}
+}
+
+// fileKeyValMinCompactLines represents the number of lines below which a FileKeyValStore's log is never compacted
+const fileKeyValMinCompactLines = 100
+
+// fileKeyValRecord represents a single line of a FileKeyValStore's log
+type fileKeyValRecord struct {
+	Key       string     `json:"key"`
+	Val       []byte     `json:"val,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+}
+
+// FileKeyValStore defines a key/value store that is persisted as a log of JSON lines, in which later lines
+// supersede earlier lines with the same key. The log is compacted once it holds mostly superseded lines
+type FileKeyValStore struct {
+	app.KeyValStore
+	path   string
+	mem    map[string]keyValEntry
+	lines  int
+	closed bool
+	mu     sync.Mutex
+}
+
+// Read implements app.KeyValStore.Read()
+func (f *FileKeyValStore) Read(key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.mem[key]
+	if !ok || entry.isExpired(time.Now()) {
+		return nil, NotFoundError{Err: fmt.Errorf("no value found at key %s", key)}
+	}
+
+	return entry.val, nil
+}
+
+// Write implements app.KeyValStore.Write()
+func (f *FileKeyValStore) Write(key string, val []byte) error {
+	return f.WriteWithTTL(key, val, 0)
+}
+
+// WriteWithTTL implements app.KeyValStore.WriteWithTTL()
+func (f *FileKeyValStore) WriteWithTTL(key string, val []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry := newKeyValEntry(val, ttl)
+	if err := f.append(newFileKeyValRecord(key, entry)); err != nil {
+		return err
+	}
+	f.mem[key] = entry
+
+	return f.compactIfSparse()
+}
+
+// CompareAndSwap implements app.KeyValStore.CompareAndSwap()
+func (f *FileKeyValStore) CompareAndSwap(key string, oldVal []byte, newVal []byte, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, ok := f.mem[key]
+	if !ok || current.isExpired(time.Now()) || !bytes.Equal(current.val, oldVal) {
+		return false, nil
+	}
+
+	entry := newKeyValEntry(newVal, ttl)
+	if err := f.append(newFileKeyValRecord(key, entry)); err != nil {
+		return false, err
+	}
+	f.mem[key] = entry
+
+	return true, f.compactIfSparse()
+}
+
+// Delete implements app.KeyValStore.Delete()
+func (f *FileKeyValStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.mem[key]; !ok {
+		// nothing to record
+		return nil
+	}
+
+	if err := f.append(fileKeyValRecord{Key: key, Deleted: true}); err != nil {
+		return err
+	}
+	delete(f.mem, key)
+
+	return f.compactIfSparse()
+}
+
+// Sweep removes the values that have expired, which are discarded from the log when it is next compacted
+func (f *FileKeyValStore) Sweep() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sweep()
+}
+
+// Close sweeps the values that have expired and compacts the log, after which nothing more can be written
+func (f *FileKeyValStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	f.sweep()
+	return f.compact()
+}
+
+// Path returns the path of the log file
+func (f *FileKeyValStore) Path() string {
+	return f.path
+}
+
+// sweep removes the values that have expired, and must be called whilst locked
+func (f *FileKeyValStore) sweep() {
+	now := time.Now()
+	for key, entry := range f.mem {
+		if entry.isExpired(now) {
+			delete(f.mem, key)
+		}
+	}
+}
+
+// append writes the provided record to the end of the log, and must be called whilst locked
+func (f *FileKeyValStore) append(rec fileKeyValRecord) error {
+	if f.closed {
+		return fmt.Errorf("store %s has been closed", f.path)
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	f.lines++
+	return nil
+}
+
+// load reads the log into memory, skipping values that have been deleted or have expired,
+// and must be called whilst locked. An unparseable final line is the remains of a write that was interrupted,
+// so it is discarded by truncating the log, whereas an unparseable line elsewhere means the log is corrupt
+func (f *FileKeyValStore) load() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// nothing has been stored yet
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	now := time.Now()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(line) == 0 {
+			// end of log
+			return nil
+		}
+		isLast := err == io.EOF
+		if !isLast {
+			_, peekErr := reader.Peek(1)
+			isLast = peekErr == io.EOF
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			offset += int64(len(line))
+			continue
+		}
+
+		var rec fileKeyValRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if !isLast {
+				return fmt.Errorf("invalid record in %s at line %d: %s", f.path, lineNum, err)
+			}
+
+			log.Printf("discarding partially-written record at line %d of %s: %s", lineNum, f.path, err)
+			return os.Truncate(f.path, offset)
+		}
+		f.lines++
+
+		if line[len(line)-1] != '\n' {
+			// terminate complete final record, so that the next record is appended on a line of its own
+			if err := f.terminateLastLine(); err != nil {
+				return err
+			}
+		}
+		offset += int64(len(line))
+
+		entry := keyValEntry{val: rec.Val}
+		if rec.ExpiresAt != nil {
+			entry.expiresAt = *rec.ExpiresAt
+		}
+		if rec.Deleted || entry.isExpired(now) {
+			delete(f.mem, rec.Key)
+			continue
+		}
+		f.mem[rec.Key] = entry
+	}
+}
+
+// terminateLastLine appends a line break to the log, and must be called whilst locked
+func (f *FileKeyValStore) terminateLastLine() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write([]byte{'\n'}); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// compactIfSparse compacts the log once it holds mostly superseded lines, and must be called whilst locked
+func (f *FileKeyValStore) compactIfSparse() error {
+	if f.lines >= fileKeyValMinCompactLines && f.lines > 2*len(f.mem) {
+		return f.compact()
+	}
+	return nil
+}
+
+// compact rewrites the log so that it holds only the current value of each key, and must be called whilst locked
+func (f *FileKeyValStore) compact() error {
+	tmpPath := f.path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	for key, entry := range f.mem {
+		line, err := json.Marshal(newFileKeyValRecord(key, entry))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return err
+	}
+	// persist the rename, so that the compacted file can't be lost along with the records it replaced
+	syncDir(path.Dir(f.path))
+
+	f.lines = len(f.mem)
+	return nil
+}
+
+// newFileKeyValRecord returns the record that persists the provided entry at the provided key
+func newFileKeyValRecord(key string, entry keyValEntry) fileKeyValRecord {
+	rec := fileKeyValRecord{Key: key, Val: entry.val}
+	if !entry.expiresAt.IsZero() {
+		expiresAt := entry.expiresAt
+		rec.ExpiresAt = &expiresAt
+	}
+	return rec
+}
+
+// NewFileKeyValStore returns a newly-instantiated FileKeyValStore that is persisted at the provided path,
+// holding the values that have previously been written to it
+func NewFileKeyValStore(filePath string) (*FileKeyValStore, error) {
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+
+	store := &FileKeyValStore{
+		path: filePath,
+		mem:  make(map[string]keyValEntry),
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	if store.lines > len(store.mem) {
+		if err := store.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// KeyValSweeper defines a key/value store whose expired values can be swept
+type KeyValSweeper interface {
+	Sweep()
+}
+
+// StartKeyValSweeper sweeps the provided store at the provided interval in the background,
+// until the returned function is called
+func StartKeyValSweeper(store KeyValSweeper, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				store.Sweep()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
 	}
 }
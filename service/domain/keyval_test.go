@@ -0,0 +1,497 @@
+package domain_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sessionInjector provides a SessionAgentInjector backed by the provided file system, store and roots
+type sessionInjector struct {
+	fs    app.FileSystem
+	store app.KeyValStore
+	roots []string
+}
+
+func (s sessionInjector) FileSystem() app.FileSystem { return s.fs }
+
+func (s sessionInjector) KeyValStore() app.KeyValStore { return s.store }
+
+func (s sessionInjector) Roots() []string { return s.roots }
+
+func TestFileKeyValStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storePath := path.Join(dir, "store", "store.jsonl")
+
+	t.Run("reopening a store must provide the most recently written values", func(t *testing.T) {
+		store, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, val := range []string{"first", "second"} {
+			if err := store.Write("key", []byte(val)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		reopened, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := reopened.Read("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != "second" {
+			t.Fatalf("expected second, got %s", val)
+		}
+	})
+
+	t.Run("reading a missing key must return a not found error", func(t *testing.T) {
+		store, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = store.Read("missing")
+		if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected NotFoundError, got %T", err)
+		}
+	})
+
+	t.Run("overwriting keys repeatedly must compact the log", func(t *testing.T) {
+		store, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 500; i++ {
+			if err := store.Write(fmt.Sprintf("key%d", i%3), []byte(fmt.Sprintf("val%d", i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		contents, err := ioutil.ReadFile(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := bytes.Count(contents, []byte("\n")); lines >= 100 {
+			t.Fatalf("expected compacted log, got %d lines", lines)
+		}
+
+		reopened, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := reopened.Read("key2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != "val497" {
+			t.Fatalf("expected val497, got %s", val)
+		}
+	})
+
+	t.Run("reopening a store whose last record was only partially written must discard it", func(t *testing.T) {
+		tornPath := path.Join(dir, "torn.jsonl")
+		store, err := domain.NewFileKeyValStore(tornPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Write("key", []byte("val")); err != nil {
+			t.Fatal(err)
+		}
+
+		complete, err := ioutil.ReadFile(tornPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		torn := append(append([]byte(nil), complete...), []byte(`{"key":"torn","val":"dm`)...)
+		if err := ioutil.WriteFile(tornPath, torn, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		reopened, err := domain.NewFileKeyValStore(tornPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val, err := reopened.Read("key"); err != nil || string(val) != "val" {
+			t.Fatalf("expected val, got %s (%v)", val, err)
+		}
+		if _, err := reopened.Read("torn"); !isNotFoundError(err) {
+			t.Fatalf("expected NotFoundError, got %T", err)
+		}
+
+		truncated, err := ioutil.ReadFile(tornPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(complete, truncated) {
+			t.Fatalf("expected log to be truncated to %q, got %q", complete, truncated)
+		}
+
+		// log must remain usable
+		if err := reopened.Write("next", []byte("val")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := domain.NewFileKeyValStore(tornPath); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("reopening a store with a corrupt record before its last record must return an error", func(t *testing.T) {
+		corruptPath := path.Join(dir, "corrupt.jsonl")
+		contents := `{"key":"a","val":"dmFs"}` + "\n" + `{"key":` + "\n" + `{"key":"b","val":"dmFs"}` + "\n"
+		if err := ioutil.WriteFile(corruptPath, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := domain.NewFileKeyValStore(corruptPath); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestFileKeyValStore_Close(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storePath := path.Join(dir, "store.jsonl")
+
+	t.Run("closing a store must compact the log and prevent further writes", func(t *testing.T) {
+		store, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, val := range []string{"first", "second", "third"} {
+			if err := store.Write("key", []byte(val)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.WriteWithTTL("expired", []byte("val"), time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		contents, err := ioutil.ReadFile(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := bytes.Count(contents, []byte("\n")); lines != 1 {
+			t.Fatalf("expected 1 line, got %d", lines)
+		}
+
+		if err := store.Write("key", []byte("fourth")); err == nil {
+			t.Fatal("expected error writing to closed store, got nil")
+		}
+
+		reopened, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := reopened.Read("key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != "third" {
+			t.Fatalf("expected third, got %s", val)
+		}
+	})
+}
+
+// sweepableKeyValStore defines a key/value store whose expired values can be swept
+type sweepableKeyValStore interface {
+	app.KeyValStore
+	domain.KeyValSweeper
+}
+
+func TestKeyValStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newStores := func(t *testing.T, name string) []sweepableKeyValStore {
+		fileStore, err := domain.NewFileKeyValStore(path.Join(dir, name+".jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []sweepableKeyValStore{domain.NewInMemoryKeyValStore(), fileStore}
+	}
+
+	t.Run("reading a value after its ttl must return a not found error", func(t *testing.T) {
+		for idx, store := range newStores(t, "ttl") {
+			if err := store.WriteWithTTL("short", []byte("val"), 500*time.Millisecond); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if err := store.WriteWithTTL("long", []byte("val"), time.Hour); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if _, err := store.Read("short"); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			time.Sleep(600 * time.Millisecond)
+
+			if _, err := store.Read("short"); !isNotFoundError(err) {
+				t.Errorf("tc %d: expected NotFoundError, got %T", idx, err)
+			}
+			if _, err := store.Read("long"); err != nil {
+				t.Errorf("tc %d: %s", idx, err)
+			}
+		}
+	})
+
+	t.Run("reading a deleted value must return a not found error", func(t *testing.T) {
+		for idx, store := range newStores(t, "delete") {
+			if err := store.Write("key", []byte("val")); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if err := store.Delete("key"); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if err := store.Delete("missing"); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			if _, err := store.Read("key"); !isNotFoundError(err) {
+				t.Errorf("tc %d: expected NotFoundError, got %T", idx, err)
+			}
+		}
+	})
+
+	t.Run("swapping a value must only succeed if it holds the expected value", func(t *testing.T) {
+		for idx, store := range newStores(t, "swap") {
+			if err := store.Write("key", []byte("old")); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			for _, tc := range []struct {
+				key      string
+				oldVal   string
+				expected bool
+			}{
+				{key: "key", oldVal: "stale", expected: false},
+				{key: "missing", oldVal: "old", expected: false},
+				{key: "key", oldVal: "old", expected: true},
+				{key: "key", oldVal: "old", expected: false},
+			} {
+				swapped, err := store.CompareAndSwap(tc.key, []byte(tc.oldVal), []byte("new"), time.Hour)
+				if err != nil {
+					t.Fatalf("tc %d: %s", idx, err)
+				}
+				if swapped != tc.expected {
+					t.Errorf("tc %d: %s from %s: expected %t, got %t", idx, tc.key, tc.oldVal, tc.expected, swapped)
+				}
+			}
+
+			val, err := store.Read("key")
+			if err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if string(val) != "new" {
+				t.Errorf("tc %d: expected new, got %s", idx, val)
+			}
+		}
+	})
+
+	t.Run("sweeping in the background must not affect values that have not expired", func(t *testing.T) {
+		for idx, store := range newStores(t, "sweep") {
+			stop := domain.StartKeyValSweeper(store, time.Millisecond)
+
+			if err := store.WriteWithTTL("short", []byte("val"), time.Millisecond); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+			if err := store.Write("forever", []byte("val")); err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			stop()
+			stop()
+
+			if _, err := store.Read("short"); !isNotFoundError(err) {
+				t.Errorf("tc %d: expected NotFoundError, got %T", idx, err)
+			}
+			if _, err := store.Read("forever"); err != nil {
+				t.Errorf("tc %d: %s", idx, err)
+			}
+		}
+	})
+
+	t.Run("transacting with a store concurrently must not lose values", func(t *testing.T) {
+		for idx, store := range newStores(t, "concurrent") {
+			stop := domain.StartKeyValSweeper(store, time.Millisecond)
+
+			var wg sync.WaitGroup
+			for worker := 0; worker < 8; worker++ {
+				wg.Add(1)
+				go func(worker int) {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						key := fmt.Sprintf("key%d-%d", worker, i)
+						if err := store.WriteWithTTL(key, []byte(key), time.Hour); err != nil {
+							t.Error(err)
+							return
+						}
+						if _, err := store.Read(key); err != nil {
+							t.Error(err)
+							return
+						}
+						if i%2 == 1 {
+							if err := store.Delete(key); err != nil {
+								t.Error(err)
+								return
+							}
+						}
+					}
+				}(worker)
+			}
+			wg.Wait()
+			stop()
+
+			for worker := 0; worker < 8; worker++ {
+				for i := 0; i < 50; i++ {
+					key := fmt.Sprintf("key%d-%d", worker, i)
+					_, err := store.Read(key)
+					if i%2 == 0 && err != nil {
+						t.Errorf("tc %d: %s: %s", idx, key, err)
+					}
+					if i%2 == 1 && !isNotFoundError(err) {
+						t.Errorf("tc %d: %s: expected NotFoundError, got %T", idx, key, err)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("reopening a store must discard deleted and expired values", func(t *testing.T) {
+		storePath := path.Join(dir, "reopen.jsonl")
+		store, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Write("deleted", []byte("val")); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Delete("deleted"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.WriteWithTTL("expired", []byte("val"), time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.WriteWithTTL("kept", []byte("val"), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		reopened, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, expected := range map[string]bool{"deleted": false, "expired": false, "kept": true} {
+			_, err := reopened.Read(key)
+			if actual := err == nil; actual != expected {
+				t.Errorf("%s: expected found %t, got %t", key, expected, actual)
+			}
+		}
+
+		contents, err := ioutil.ReadFile(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := bytes.Count(contents, []byte("\n")); lines != 1 {
+			t.Fatalf("expected 1 line, got %d", lines)
+		}
+	})
+}
+
+// isNotFoundError returns true if the provided error is a domain.NotFoundError, otherwise false
+func isNotFoundError(err error) bool {
+	_, ok := err.(domain.NotFoundError)
+	return ok
+}
+
+func TestSessionAgent_GetSessionFromToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	storePath := path.Join(dir, "store.jsonl")
+	store, err := domain.NewFileKeyValStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{fs: &domain.OsFileSystem{}, store: store}}
+
+	scan := models.ScanOptions{Recursive: true, MaxDepth: 2, Excludes: []string{".thumbnails"}}
+	sess, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dir, time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC), scan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retrieving a session from a reopened store must provide the stored session", func(t *testing.T) {
+		reopened, err := domain.NewFileKeyValStore(storePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reopenedAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{fs: &domain.OsFileSystem{}, store: reopened}}
+
+		actual, err := reopenedAgent.GetSessionFromToken(sess.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(sess, actual); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", sess, actual, diff)
+		}
+	})
+
+	t.Run("retrieving a session from a value that is not a session must return an error", func(t *testing.T) {
+		if err := store.Write("not-a-session", []byte("[]")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := sessAgent.GetSessionFromToken("not-a-session"); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+	t.Run("retrieving a deleted session must return a not found error", func(t *testing.T) {
+		if err := sessAgent.DeleteSession(sess); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := sessAgent.GetSessionFromToken(sess.Token); !isNotFoundError(err) {
+			t.Fatalf("expected NotFoundError, got %T", err)
+		}
+	})
+}
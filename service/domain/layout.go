@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"imgnheap/service/models"
+	"path"
+	"strings"
+)
+
+// DefaultDirLayout represents the layout used to process files by date when none has been selected
+const DefaultDirLayout = "{ext}/{year}-{month}-{day}"
+
+// unknownCameraModel represents the value of the camera token for files that have no camera model
+const unknownCameraModel = "unknown-camera"
+
+// dirLayoutTokens represents the tokens that may be used within a layout, and how each is rendered
+var dirLayoutTokens = map[string]func(file models.File, meta models.FileMetadata) string{
+	"year":      func(_ models.File, meta models.FileMetadata) string { return meta.Timestamp.Format("2006") },
+	"month":     func(_ models.File, meta models.FileMetadata) string { return meta.Timestamp.Format("01") },
+	"monthname": func(_ models.File, meta models.FileMetadata) string { return meta.Timestamp.Format("January") },
+	"day":       func(_ models.File, meta models.FileMetadata) string { return meta.Timestamp.Format("02") },
+	"week": func(_ models.File, meta models.FileMetadata) string {
+		_, week := meta.Timestamp.ISOWeek()
+		return fmt.Sprintf("%02d", week)
+	},
+	"weekyear": func(_ models.File, meta models.FileMetadata) string {
+		year, _ := meta.Timestamp.ISOWeek()
+		return fmt.Sprintf("%04d", year)
+	},
+	"ext": func(file models.File, _ models.FileMetadata) string { return file.Ext },
+	"camera": func(_ models.File, meta models.FileMetadata) string {
+		if meta.CameraModel == "" {
+			return unknownCameraModel
+		}
+		return sanitiseDirLayoutValue(meta.CameraModel)
+	},
+}
+
+// DirLayoutPresets returns the layouts that are offered for selection
+func DirLayoutPresets() []models.DirLayoutPreset {
+	return []models.DirLayoutPreset{
+		{Name: "Extension, then date", Template: DefaultDirLayout},
+		{Name: "Date only", Template: "{year}-{month}-{day}"},
+		{Name: "Year, month and day", Template: "{year}/{month} - {monthname}/{day}"},
+		{Name: "ISO week", Template: "{weekyear}/W{week}"},
+		{Name: "Camera, then date", Template: "{camera}/{year}-{month}-{day}"},
+	}
+}
+
+// DirLayout represents a validated template for the directory path that a file is processed to,
+// relative to the session's by-date directory
+type DirLayout struct {
+	template string
+}
+
+// String returns the template of the layout
+func (d DirLayout) String() string {
+	return d.template
+}
+
+// Render returns the relative directory path described by the layout for the provided file and its metadata
+func (d DirLayout) Render(file models.File, meta models.FileMetadata) string {
+	var b strings.Builder
+
+	tpl := d.template
+	for {
+		start := strings.Index(tpl, "{")
+		if start < 0 {
+			b.WriteString(tpl)
+			break
+		}
+		end := strings.Index(tpl, "}")
+
+		b.WriteString(tpl[:start])
+		b.WriteString(dirLayoutTokens[tpl[start+1:end]](file, meta))
+		tpl = tpl[end+1:]
+	}
+
+	return path.Clean(b.String())
+}
+
+// ParseDirLayout validates the provided template and returns the layout that it describes
+func ParseDirLayout(tpl string) (DirLayout, error) {
+	tpl = strings.TrimSpace(tpl)
+	if tpl == "" {
+		return DirLayout{}, ValidationError{Err: errors.New("layout is empty")}
+	}
+	if strings.HasPrefix(tpl, "/") {
+		return DirLayout{}, ValidationError{Err: fmt.Errorf("layout must be relative: %s", tpl)}
+	}
+	if strings.ContainsAny(tpl, "\\\x00") {
+		return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains invalid characters: %s", tpl)}
+	}
+
+	for _, segment := range strings.Split(tpl, "/") {
+		switch strings.TrimSpace(segment) {
+		case "":
+			return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains an empty directory: %s", tpl)}
+		case ".", "..":
+			return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains a relative directory: %s", tpl)}
+		}
+	}
+
+	rest := tpl
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			break
+		}
+		if rest[start] == '}' {
+			return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains unopened token: %s", tpl)}
+		}
+
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end < 0 || rest[start+1+end] == '{' {
+			return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains unclosed token: %s", tpl)}
+		}
+
+		token := rest[start+1 : start+1+end]
+		if _, ok := dirLayoutTokens[token]; !ok {
+			return DirLayout{}, ValidationError{Err: fmt.Errorf("layout contains unknown token {%s}: %s", token, tpl)}
+		}
+
+		rest = rest[start+1+end+1:]
+	}
+
+	return DirLayout{template: tpl}, nil
+}
+
+// sanitiseDirLayoutValue returns the provided value with any characters that are unsafe within a directory name replaced
+func sanitiseDirLayoutValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(value))
+
+	if value == "." || value == ".." {
+		return unknownCameraModel
+	}
+
+	return value
+}
@@ -0,0 +1,152 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"testing"
+	"time"
+)
+
+func TestParseDirLayout(t *testing.T) {
+	t.Run("parsing valid layout must succeed", func(t *testing.T) {
+		testCases := []string{
+			domain.DefaultDirLayout,
+			"{year}/{month} - {monthname}/{day}",
+			"{weekyear}/W{week}",
+			"{camera}/{year}-{month}-{day}",
+			"photos/{year}",
+			"no-tokens-at-all",
+		}
+
+		for idx, tc := range testCases {
+			if _, err := domain.ParseDirLayout(tc); err != nil {
+				t.Fatalf("tc %d: expected nil, got %+v", idx, err)
+			}
+		}
+
+		for idx, tc := range domain.DirLayoutPresets() {
+			if _, err := domain.ParseDirLayout(tc.Template); err != nil {
+				t.Fatalf("preset %d: expected nil, got %+v", idx, err)
+			}
+		}
+	})
+
+	t.Run("parsing invalid layout must return validation error", func(t *testing.T) {
+		testCases := []string{
+			"",
+			"   ",
+			"/{year}",
+			"{year}//{month}",
+			"{year}/",
+			"../{year}",
+			"{year}/./{month}",
+			"{year}\\{month}",
+			"{year}\x00",
+			"{yr}",
+			"{year",
+			"year}",
+			"{{year}}",
+			"{year}/{month",
+			"{}",
+		}
+
+		for idx, tc := range testCases {
+			_, err := domain.ParseDirLayout(tc)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Fatalf("tc %d: expected validation error, got %+v", idx, err)
+			}
+		}
+	})
+}
+
+func TestDirLayout_Render(t *testing.T) {
+	file := models.NewFile("IMG_0001", "jpg", "/base/dir", nil)
+
+	t.Run("rendering layout must provide the expected result", func(t *testing.T) {
+		testCases := []struct {
+			layout         string
+			meta           models.FileMetadata
+			expectedOutput string
+		}{
+			{
+				layout:         domain.DefaultDirLayout,
+				meta:           models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)},
+				expectedOutput: "jpg/2018-05-26",
+			},
+			{
+				layout:         "{year}/{month} - {monthname}/{day}",
+				meta:           models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)},
+				expectedOutput: "2018/05 - May/26",
+			},
+			{
+				// iso week belongs to the previous year
+				layout:         "{weekyear}/W{week}",
+				meta:           models.FileMetadata{Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+				expectedOutput: "2020/W53",
+			},
+			{
+				layout:         "{camera}/{year}",
+				meta:           models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC), CameraModel: "EOS 5D/Mark IV"},
+				expectedOutput: "EOS 5D-Mark IV/2018",
+			},
+			{
+				layout:         "{camera}/{year}",
+				meta:           models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC), CameraModel: ".."},
+				expectedOutput: "unknown-camera/2018",
+			},
+			{
+				layout:         "{camera}/{year}",
+				meta:           models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)},
+				expectedOutput: "unknown-camera/2018",
+			},
+		}
+
+		for idx, tc := range testCases {
+			layout, err := domain.ParseDirLayout(tc.layout)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actualOutput := layout.Render(file, tc.meta)
+			if actualOutput != tc.expectedOutput {
+				t.Fatalf("tc %d: expected %s, got %s", idx, tc.expectedOutput, actualOutput)
+			}
+		}
+	})
+}
+
+func TestGetDestinationDirByLayout(t *testing.T) {
+	sess := models.Session{
+		BaseDir: "/base/dir",
+		SubDir:  "subdir",
+	}
+
+	t.Run("get destination dir by layout must return the expected result", func(t *testing.T) {
+		layout, err := domain.ParseDirLayout("{year}/{month} - {monthname}/{day}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := models.NewFile("IMG_0001", "jpg", "/base/dir", nil)
+		meta := models.FileMetadata{Timestamp: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)}
+
+		expectedOutput := "/base/dir/subdir/by-date/2018/05 - May/26"
+
+		destDir := domain.GetDestinationDirByLayout(layout, file, meta, &sess)
+		if destDir != expectedOutput {
+			t.Fatalf("expected %s, got %s", expectedOutput, destDir)
+		}
+	})
+
+	t.Run("get destination dir by layout using nil session must return blank string", func(t *testing.T) {
+		layout, err := domain.ParseDirLayout(domain.DefaultDirLayout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := models.NewFile("IMG_0001", "jpg", "/base/dir", nil)
+
+		destDir := domain.GetDestinationDirByLayout(layout, file, models.FileMetadata{}, nil)
+		if destDir != "" {
+			t.Fatalf("expected empty string, got %s", destDir)
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package domain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoContainerMetadata represents the absence of container metadata from a file
+var ErrNoContainerMetadata = errors.New("no container metadata found")
+
+// containerEpoch represents the epoch from which iso base media file format timestamps are measured
+var containerEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// containerDayZonedLayouts represents the layouts that a ©day value is known to take with a time zone
+var containerDayZonedLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+// containerDayLayouts represents the layouts that a ©day value is known to take without a time zone
+var containerDayLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ContainerData represents the subset of iso base media file format (mp4/mov) metadata that we make use of
+type ContainerData struct {
+	Day          string
+	MovieCreated time.Time
+	TrackCreated time.Time
+}
+
+// RecordedAt returns the timestamp at which the video was recorded, and whether a valid timestamp is present.
+// A ©day value is preferred, which keeps the time zone that it was recorded in (as with the offset of an exif
+// timestamp) or is otherwise taken to be local. The movie header and then the track header follow, which are both
+// UTC, so are converted to local time
+func (c ContainerData) RecordedAt() (time.Time, bool) {
+	for _, layout := range containerDayZonedLayouts {
+		if t, err := time.Parse(layout, c.Day); err == nil {
+			return t, true
+		}
+	}
+
+	for _, layout := range containerDayLayouts {
+		if t, err := time.ParseInLocation(layout, c.Day, time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	if !c.MovieCreated.IsZero() {
+		return c.MovieCreated.Local(), true
+	}
+
+	if !c.TrackCreated.IsZero() {
+		return c.TrackCreated.Local(), true
+	}
+
+	return time.Time{}, false
+}
+
+// ReadContainer reads the metadata from the provided iso base media file format (mp4/mov) data
+func ReadContainer(r io.ReadSeeker) (ContainerData, error) {
+	var data ContainerData
+
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return data, err
+	}
+
+	found := false
+	if err := walkBoxes(r, 0, end, func(b box) error {
+		if b.typ != "moov" {
+			return nil
+		}
+		found = true
+		return readMoovBox(r, b, &data)
+	}); err != nil {
+		return data, err
+	}
+
+	if !found {
+		return data, ErrNoContainerMetadata
+	}
+
+	return data, nil
+}
+
+// box represents the position of a single box (atom) within iso base media file format data
+type box struct {
+	typ   string
+	start int64
+	end   int64
+}
+
+// walkBoxes invokes the provided function for each box found between the provided offsets
+func walkBoxes(r io.ReadSeeker, start, end int64, fn func(b box) error) error {
+	offset := start
+
+	for offset+8 <= end {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			// box extends to the end of its parent
+			size = end - offset
+		case 1:
+			// box size is held as a 64-bit value following the type
+			var large [8]byte
+			if _, err := io.ReadFull(r, large[:]); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(large[:]))
+			headerSize = 16
+		}
+
+		if size < headerSize || offset+size > end {
+			return fmt.Errorf("invalid box size %d at offset %d", size, offset)
+		}
+
+		if err := fn(box{
+			typ:   string(header[4:8]),
+			start: offset + headerSize,
+			end:   offset + size,
+		}); err != nil {
+			return err
+		}
+
+		offset += size
+	}
+
+	return nil
+}
+
+// readMoovBox populates the provided data from the provided movie box and its descendants
+func readMoovBox(r io.ReadSeeker, moov box, data *ContainerData) error {
+	return walkBoxes(r, moov.start, moov.end, func(b box) error {
+		switch b.typ {
+		case "mvhd":
+			t, err := readHeaderCreationTime(r, b)
+			if err != nil {
+				return err
+			}
+			data.MovieCreated = t
+		case "trak":
+			return walkBoxes(r, b.start, b.end, func(b box) error {
+				if b.typ != "tkhd" || !data.TrackCreated.IsZero() {
+					return nil
+				}
+				t, err := readHeaderCreationTime(r, b)
+				if err != nil {
+					return err
+				}
+				data.TrackCreated = t
+				return nil
+			})
+		case "udta":
+			return readUdtaBox(r, b, data)
+		case "meta":
+			return readMetaBox(r, b, data)
+		}
+		return nil
+	})
+}
+
+// readHeaderCreationTime returns the creation time held by the provided movie or track header box
+func readHeaderCreationTime(r io.ReadSeeker, b box) (time.Time, error) {
+	if _, err := r.Seek(b.start, io.SeekStart); err != nil {
+		return time.Time{}, err
+	}
+
+	// version (1 byte) and flags (3 bytes) followed by creation time
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return time.Time{}, err
+	}
+
+	var secs uint64
+	switch header[0] {
+	case 0:
+		secs = uint64(binary.BigEndian.Uint32(header[4:8]))
+	case 1:
+		secs = binary.BigEndian.Uint64(header[4:12])
+	default:
+		return time.Time{}, fmt.Errorf("unsupported %s version: %d", b.typ, header[0])
+	}
+
+	if secs == 0 {
+		// creation time has not been set
+		return time.Time{}, nil
+	}
+
+	return containerEpoch.Add(time.Duration(secs) * time.Second), nil
+}
+
+// readUdtaBox populates the provided data from the provided user data box
+func readUdtaBox(r io.ReadSeeker, udta box, data *ContainerData) error {
+	return walkBoxes(r, udta.start, udta.end, func(b box) error {
+		switch b.typ {
+		case "\xa9day":
+			// quicktime user data text: string length (2 bytes) and language (2 bytes) followed by the string
+			if b.end-b.start < 4 {
+				return nil
+			}
+			raw, err := readBoxBytes(r, b.start, b.end)
+			if err != nil {
+				return err
+			}
+			length := int(binary.BigEndian.Uint16(raw[0:2]))
+			if length > len(raw)-4 {
+				length = len(raw) - 4
+			}
+			data.Day = strings.TrimSpace(strings.TrimRight(string(raw[4:4+length]), "\x00"))
+		case "meta":
+			return readMetaBox(r, b, data)
+		}
+		return nil
+	})
+}
+
+// readMetaBox populates the provided data from the provided metadata box
+func readMetaBox(r io.ReadSeeker, meta box, data *ContainerData) error {
+	start := meta.start
+
+	// iso meta boxes are full boxes with a version and flags preceding their children,
+	// whereas quicktime meta boxes are not, so peek at the first child to tell them apart
+	peek, err := readBoxBytes(r, start, minInt64(start+8, meta.end))
+	if err != nil {
+		return err
+	}
+	if len(peek) == 8 && string(peek[4:8]) != "hdlr" {
+		start += 4
+	}
+
+	return walkBoxes(r, start, meta.end, func(b box) error {
+		if b.typ != "ilst" {
+			return nil
+		}
+		return walkBoxes(r, b.start, b.end, func(b box) error {
+			if b.typ != "\xa9day" {
+				return nil
+			}
+			return walkBoxes(r, b.start, b.end, func(b box) error {
+				// data box: type indicator (4 bytes) and locale (4 bytes) followed by the value
+				if b.typ != "data" || b.end-b.start < 8 {
+					return nil
+				}
+				raw, err := readBoxBytes(r, b.start+8, b.end)
+				if err != nil {
+					return err
+				}
+				data.Day = strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+				return nil
+			})
+		})
+	})
+}
+
+// readBoxBytes reads the bytes between the provided offsets
+func readBoxBytes(r io.ReadSeeker, start, end int64) ([]byte, error) {
+	const maxLength = 1024
+
+	if end-start > maxLength {
+		end = start + maxLength
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, end-start)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// minInt64 returns the lesser of the two provided values
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
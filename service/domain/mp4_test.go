@@ -0,0 +1,148 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadContainer(t *testing.T) {
+	expectedTs := time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)
+
+	t.Run("reading container metadata must provide the expected recorded at timestamp", func(t *testing.T) {
+		testCases := []string{
+			// movie header following media data
+			"testdata/video-mvhd.mp4",
+			// quicktime user data ©day alongside version 1 movie header
+			"testdata/video-day.mov",
+			// iso metadata item list ©day alongside unset movie header
+			"testdata/video-ilst.mp4",
+			// track header alongside unset movie header
+			"testdata/video-tkhd.mp4",
+		}
+
+		for idx, tc := range testCases {
+			f, err := os.Open(tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := domain.ReadContainer(f)
+			f.Close()
+			if err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			actualTs, ok := data.RecordedAt()
+			if !ok {
+				t.Fatalf("tc %d: expected ok, got not ok", idx)
+			}
+			if !expectedTs.Equal(actualTs) {
+				t.Fatalf("tc %d: expected %+v, got %+v", idx, expectedTs, actualTs)
+			}
+		}
+	})
+
+	t.Run("reading container metadata without a time zone must provide the recorded at timestamp in local time", func(t *testing.T) {
+		local := time.Local
+		defer func() { time.Local = local }()
+		time.Local = time.FixedZone("UTC-8", -8*60*60)
+
+		// recorded in the evening of the 25th, local time
+		created := time.Date(2018, 5, 26, 2, 0, 29, 0, time.UTC)
+
+		testCases := []domain.ContainerData{
+			{MovieCreated: created},
+			{TrackCreated: created},
+			{Day: "2018-05-25T18:00:29"},
+		}
+
+		for idx, tc := range testCases {
+			actualTs, ok := tc.RecordedAt()
+			if !ok {
+				t.Fatalf("tc %d: expected ok, got not ok", idx)
+			}
+			if actualTs.Location() != time.Local {
+				t.Fatalf("tc %d: expected local time, got %s", idx, actualTs.Location())
+			}
+			if !created.Equal(actualTs) {
+				t.Fatalf("tc %d: expected %+v, got %+v", idx, created, actualTs)
+			}
+			if actualTs.Day() != 25 {
+				t.Fatalf("tc %d: expected day 25, got %d", idx, actualTs.Day())
+			}
+		}
+	})
+
+	t.Run("reading container metadata with a time zone must provide the recorded at timestamp in that time zone", func(t *testing.T) {
+		local := time.Local
+		defer func() { time.Local = local }()
+		time.Local = time.FixedZone("UTC-8", -8*60*60)
+
+		testCases := []struct {
+			day      string
+			expected string
+		}{
+			// recorded just before midnight on the 25th, which is the afternoon of the 25th in local time
+			{day: "2018-05-25T23:30:00+02:00", expected: "2018-05-25 23:30 +0200"},
+			{day: "2018-05-25T23:30:00+0200", expected: "2018-05-25 23:30 +0200"},
+			// recorded just after midnight on the 26th, which is still the 25th in local time
+			{day: "2018-05-26T00:30:00+02:00", expected: "2018-05-26 00:30 +0200"},
+			{day: "2018-05-26T02:00:29Z", expected: "2018-05-26 02:00 +0000"},
+		}
+
+		for idx, tc := range testCases {
+			actualTs, ok := domain.ContainerData{Day: tc.day}.RecordedAt()
+			if !ok {
+				t.Fatalf("tc %d: expected ok, got not ok", idx)
+			}
+			if actual := actualTs.Format("2006-01-02 15:04 -0700"); actual != tc.expected {
+				t.Fatalf("tc %d: expected %s, got %s", idx, tc.expected, actual)
+			}
+		}
+	})
+
+	t.Run("reading container metadata from file without movie box must return no container metadata error", func(t *testing.T) {
+		f, err := os.Open("testdata/video-no-moov.mp4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if _, err := domain.ReadContainer(f); err != domain.ErrNoContainerMetadata {
+			t.Fatalf("expected %+v, got %+v", domain.ErrNoContainerMetadata, err)
+		}
+	})
+
+	t.Run("reading container metadata from non-container data must return an error", func(t *testing.T) {
+		f, err := os.Open("testdata/no-exif.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if _, err := domain.ReadContainer(f); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestFileSystemAgent_ResolveMetadata_Container(t *testing.T) {
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("resolving metadata of video file must prefer container metadata", func(t *testing.T) {
+		file := models.NewFile("video-mvhd", "mp4", "testdata", &modTime)
+		expectedTs := time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC)
+
+		meta := fsAgent.ResolveMetadata(file)
+		if meta.TimestampSource != domain.TimestampSourceContainer {
+			t.Fatalf("expected %s, got %s", domain.TimestampSourceContainer, meta.TimestampSource)
+		}
+		if !expectedTs.Equal(meta.Timestamp) {
+			t.Fatalf("expected %+v, got %+v", expectedTs, meta.Timestamp)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"imgnheap/service/app"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampPattern represents a named pattern for parsing a timestamp from a filename.
+// If Regex is provided, the filename must match it and its first capture group (or the whole match) is parsed,
+// otherwise if Prefix is provided, the filename must begin with it and the remainder is parsed,
+// otherwise the whole filename is parsed. Parsing uses the Go time layout provided by Layout
+type TimestampPattern struct {
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	Regex  string `json:"regex"`
+	Layout string `json:"layout"`
+
+	re *regexp.Regexp
+}
+
+// parse attempts to parse a timestamp from the provided filename
+func (t TimestampPattern) parse(fileName string) (time.Time, bool) {
+	if !strings.HasPrefix(fileName, t.Prefix) {
+		return time.Time{}, false
+	}
+
+	value := strings.TrimPrefix(fileName, t.Prefix)
+
+	if t.re != nil {
+		matches := t.re.FindStringSubmatch(fileName)
+		switch len(matches) {
+		case 0:
+			return time.Time{}, false
+		case 1:
+			value = matches[0]
+		default:
+			value = matches[1]
+		}
+	}
+
+	ts, err := time.Parse(t.Layout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return ts, true
+}
+
+// DefaultTimestampPatterns returns the timestamp patterns that are registered by default
+func DefaultTimestampPatterns() []TimestampPattern {
+	return []TimestampPattern{
+		{Name: "timestamp", Layout: "20060102150405"},
+		{Name: "timestamp-underscore", Layout: "20060102_150405"},
+		{Name: "timestamp-hyphen", Layout: "20060102-150405"},
+		{Name: "android-screenshot", Regex: `^Screenshot_(\d{14})(?:_.+)?$`, Layout: "20060102150405"},
+		{Name: "android-screenshot-underscore", Regex: `^Screenshot_(\d{8}_\d{6})(?:_.+)?$`, Layout: "20060102_150405"},
+		{Name: "android-screenshot-hyphen", Regex: `^Screenshot_(\d{8}-\d{6})(?:_.+)?$`, Layout: "20060102-150405"},
+		{Name: "macos-screenshot", Prefix: "Screenshot ", Layout: "2006-01-02 at 15.04.05"},
+		{Name: "macos-screen-shot", Prefix: "Screen Shot ", Layout: "2006-01-02 at 15.04.05"},
+		{Name: "android-camera", Regex: `^(?:IMG|VID)_(\d{8}_\d{6})(?:[_~.].*)?$`, Layout: "20060102_150405"},
+		{Name: "pixel", Regex: `^PXL_(\d{8}_\d{6})\d{3}(?:[_~.].*)?$`, Layout: "20060102_150405"},
+		{Name: "whatsapp", Regex: `^(?:IMG|VID)-(\d{8})-WA\d+(?:[ _~(].*)?$`, Layout: "20060102"},
+		{Name: "signal", Regex: `^signal-(\d{4}-\d{2}-\d{2}-\d{6})(?:[-_].*)?$`, Layout: "2006-01-02-150405"},
+		{Name: "signal-separated", Regex: `^signal-(\d{4}-\d{2}-\d{2}-\d{2}-\d{2}-\d{2})(?:[-_].*)?$`, Layout: "2006-01-02-15-04-05"},
+	}
+}
+
+// LoadTimestampPatternsFromFile returns the timestamp patterns defined by the provided JSON config file, which
+// takes the form {"patterns": [{"name": "...", "prefix": "...", "regex": "...", "layout": "..."}]}
+func LoadTimestampPatternsFromFile(path string) ([]TimestampPattern, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Patterns []TimestampPattern `json:"patterns"`
+	}
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, ValidationError{Err: fmt.Errorf("invalid timestamp patterns file %s: %s", path, err)}
+	}
+
+	return config.Patterns, nil
+}
+
+// InMemoryTimestampPatternRegistry defines an in-memory registry of timestamp patterns
+type InMemoryTimestampPatternRegistry struct {
+	app.TimestampPatternRegistry
+	patterns []TimestampPattern
+}
+
+// Register validates the provided pattern and adds it to the registry, after any existing patterns
+func (i *InMemoryTimestampPatternRegistry) Register(pattern TimestampPattern) error {
+	if pattern.Name == "" {
+		return ValidationError{Err: errors.New("timestamp pattern name is empty")}
+	}
+	if pattern.Layout == "" {
+		return ValidationError{Err: fmt.Errorf("timestamp pattern %s: layout is empty", pattern.Name)}
+	}
+	for _, existing := range i.patterns {
+		if existing.Name == pattern.Name {
+			return ValidationError{Err: fmt.Errorf("timestamp pattern %s: already registered", pattern.Name)}
+		}
+	}
+
+	if pattern.Regex != "" {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return ValidationError{Err: fmt.Errorf("timestamp pattern %s: %s", pattern.Name, err)}
+		}
+		pattern.re = re
+	}
+
+	i.patterns = append(i.patterns, pattern)
+	return nil
+}
+
+// Match implements app.TimestampPatternRegistry.Match()
+func (i *InMemoryTimestampPatternRegistry) Match(fileName string) (time.Time, string, bool) {
+	for _, pattern := range i.patterns {
+		if ts, ok := pattern.parse(fileName); ok {
+			return ts, pattern.Name, true
+		}
+	}
+
+	return time.Time{}, "", false
+}
+
+// NewInMemoryTimestampPatternRegistry returns a newly-instantiated InMemoryTimestampPatternRegistry
+// with the provided patterns registered in order
+func NewInMemoryTimestampPatternRegistry(patterns ...TimestampPattern) (*InMemoryTimestampPatternRegistry, error) {
+	registry := &InMemoryTimestampPatternRegistry{}
+
+	for _, pattern := range patterns {
+		if err := registry.Register(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// defaultTimestampPatternRegistry provides the default timestamp patterns to functions that have no registry injected
+var defaultTimestampPatternRegistry = mustNewDefaultTimestampPatternRegistry()
+
+// mustNewDefaultTimestampPatternRegistry returns a registry of the default timestamp patterns, otherwise panics on error
+func mustNewDefaultTimestampPatternRegistry() *InMemoryTimestampPatternRegistry {
+	registry, err := NewInMemoryTimestampPatternRegistry(DefaultTimestampPatterns()...)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
@@ -0,0 +1,119 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"testing"
+	"time"
+)
+
+func TestInMemoryTimestampPatternRegistry_Match(t *testing.T) {
+	registry, err := domain.NewInMemoryTimestampPatternRegistry(domain.DefaultTimestampPatterns()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("matching filename against default patterns must provide the expected timestamp and pattern name", func(t *testing.T) {
+		testCases := []struct {
+			input           string
+			expectedTs      time.Time
+			expectedPattern string
+		}{
+			{input: "20180526140029", expectedTs: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC), expectedPattern: "timestamp"},
+			{input: "Screenshot_20180526-140029_MyFaceSpace", expectedTs: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC), expectedPattern: "android-screenshot-hyphen"},
+			{input: "Screenshot 2018-05-26 at 14.00.29", expectedTs: time.Date(2018, 5, 26, 14, 0, 29, 0, time.UTC), expectedPattern: "macos-screenshot"},
+			{input: "PXL_20200101_123456789", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "pixel"},
+			{input: "IMG-20200101-WA0001", expectedTs: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), expectedPattern: "whatsapp"},
+			{input: "VID-20200101-WA0012", expectedTs: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), expectedPattern: "whatsapp"},
+			{input: "VID_20200101_123456", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "android-camera"},
+			{input: "signal-2020-01-01-123456", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "signal"},
+			{input: "signal-2020-01-01-12-34-56-789", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "signal-separated"},
+		}
+
+		for idx, tc := range testCases {
+			actualTs, actualPattern, ok := registry.Match(tc.input)
+			if !ok {
+				t.Fatalf("tc %d: expected ok, got not ok", idx)
+			}
+			if !tc.expectedTs.Equal(actualTs) {
+				t.Fatalf("tc %d: expected %+v, got %+v", idx, tc.expectedTs, actualTs)
+			}
+			if tc.expectedPattern != actualPattern {
+				t.Fatalf("tc %d: expected %s, got %s", idx, tc.expectedPattern, actualPattern)
+			}
+		}
+	})
+
+	t.Run("matching filename that fits no pattern must not be ok", func(t *testing.T) {
+		for idx, tc := range fileNamesContainingNoParseableTimestamp {
+			if _, _, ok := registry.Match(tc); ok {
+				t.Fatalf("tc %d: expected not ok, got ok", idx)
+			}
+		}
+	})
+}
+
+func TestInMemoryTimestampPatternRegistry_Register(t *testing.T) {
+	t.Run("registering invalid pattern must return validation error", func(t *testing.T) {
+		testCases := []domain.TimestampPattern{
+			{Layout: "20060102"},
+			{Name: "no-layout"},
+			{Name: "bad-regex", Regex: "^(unclosed", Layout: "20060102"},
+		}
+
+		for idx, tc := range testCases {
+			registry, err := domain.NewInMemoryTimestampPatternRegistry()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := registry.Register(tc); err == nil {
+				t.Fatalf("tc %d: expected error, got nil", idx)
+			} else if _, ok := err.(domain.ValidationError); !ok {
+				t.Fatalf("tc %d: expected validation error, got %+v", idx, err)
+			}
+		}
+	})
+
+	t.Run("registering duplicate pattern name must return validation error", func(t *testing.T) {
+		_, err := domain.NewInMemoryTimestampPatternRegistry(
+			domain.TimestampPattern{Name: "dupe", Layout: "20060102"},
+			domain.TimestampPattern{Name: "dupe", Layout: "2006-01-02"},
+		)
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected validation error, got %+v", err)
+		}
+	})
+}
+
+func TestLoadTimestampPatternsFromFile(t *testing.T) {
+	t.Run("loading patterns from file must extend the registry", func(t *testing.T) {
+		patterns, err := domain.LoadTimestampPatternsFromFile("testdata/patterns.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		registry, err := domain.NewInMemoryTimestampPatternRegistry(append(patterns, domain.DefaultTimestampPatterns()...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testCases := []struct {
+			input           string
+			expectedTs      time.Time
+			expectedPattern string
+		}{
+			{input: "DASH_2020_0101_123456", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "dashcam"},
+			{input: "scan-20200101-0042", expectedTs: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), expectedPattern: "scanner"},
+			{input: "20200101_123456", expectedTs: time.Date(2020, 1, 1, 12, 34, 56, 0, time.UTC), expectedPattern: "timestamp-underscore"},
+		}
+
+		for idx, tc := range testCases {
+			actualTs, actualPattern, ok := registry.Match(tc.input)
+			if !ok {
+				t.Fatalf("tc %d: expected ok, got not ok", idx)
+			}
+			if !tc.expectedTs.Equal(actualTs) || tc.expectedPattern != actualPattern {
+				t.Fatalf("tc %d: expected %+v (%s), got %+v (%s)", idx, tc.expectedTs, tc.expectedPattern, actualTs, actualPattern)
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"time"
+)
+
+// planTTL represents how long a plan is retained for after it has been saved
+const planTTL = 24 * time.Hour
+
+// PlanAgentInjector defines the injector behaviours for our PlanAgent
+type PlanAgentInjector interface {
+	app.KeyValStoreInjector
+}
+
+// PlanAgent represents our methods for storing and retrieving plans
+type PlanAgent struct {
+	PlanAgentInjector
+}
+
+// SavePlan stores the provided plan, assigning it an ID if it does not have one
+func (p *PlanAgent) SavePlan(plan *models.Plan) error {
+	if plan == nil {
+		return errors.New("plan is nil")
+	}
+
+	if plan.ID == "" {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return err
+		}
+		plan.ID = id.String()
+	}
+
+	val, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+
+	return p.KeyValStore().WriteWithTTL(planKey(plan.ID), val, planTTL)
+}
+
+// GetPlanForSession retrieves the plan with the provided ID, which must belong to the provided session
+func (p *PlanAgent) GetPlanForSession(planID string, sess *models.Session) (*models.Plan, error) {
+	plan, _, err := p.readPlanForSession(planID, sess)
+	return plan, err
+}
+
+// ClaimPlanForSession retrieves the plan with the provided ID, which must belong to the provided session
+// and not have been executed already, and marks it as executed so that it cannot be claimed again.
+// The plan is only marked as executed if it is unchanged since being retrieved, so that concurrent claims
+// of the same plan cannot both succeed
+func (p *PlanAgent) ClaimPlanForSession(planID string, sess *models.Session) (*models.Plan, error) {
+	plan, val, err := p.readPlanForSession(planID, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	alreadyExecuted := ValidationError{Err: fmt.Errorf("plan %s has already been executed", plan.ID)}
+	if plan.Executed {
+		return nil, alreadyExecuted
+	}
+
+	plan.Executed = true
+	claimed, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped, err := p.KeyValStore().CompareAndSwap(planKey(plan.ID), val, claimed, planTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !swapped {
+		// plan has been claimed in the meantime
+		return nil, alreadyExecuted
+	}
+
+	return plan, nil
+}
+
+// readPlanForSession retrieves the plan with the provided ID, which must belong to the provided session,
+// along with the value from which it was parsed
+func (p *PlanAgent) readPlanForSession(planID string, sess *models.Session) (*models.Plan, []byte, error) {
+	if sess == nil {
+		return nil, nil, errors.New("session is nil")
+	}
+
+	val, err := p.KeyValStore().Read(planKey(planID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plan := &models.Plan{}
+	if err := json.Unmarshal(val, plan); err != nil {
+		return nil, nil, fmt.Errorf("error key %s does not represent plan object: %s", planKey(planID), err)
+	}
+
+	if plan.SessionToken != sess.Token {
+		// don't reveal plans that belong to other sessions
+		return nil, nil, NotFoundError{Err: fmt.Errorf("no plan found with id %s", planID)}
+	}
+
+	return plan, val, nil
+}
+
+// planKey returns the key at which the plan with the provided ID is stored
+func planKey(planID string) string {
+	return fmt.Sprintf("plan:%s", planID)
+}
+
+// PlanByDate returns a plan for copying the files within the provided session's directory
+// into directories described by the provided layout. If skipDuplicates is true, only the first of each set
+// of files with identical contents is planned, and the rest are recorded by the plan as duplicates
+func (f *FileSystemAgent) PlanByDate(sess *models.Session, layout DirLayout, skipDuplicates bool) (*models.Plan, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	files, err := f.GetFilesForSession(sess, ImgFileExts...)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := ParseCollisionPolicy(f.CollisionPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.Plan{
+		SessionToken:    sess.Token,
+		Layout:          layout.String(),
+		CollisionPolicy: policy,
+	}
+
+	skip := make(map[string]bool)
+	if skipDuplicates {
+		groups, err := f.FindDuplicates(files)
+		if err != nil {
+			return nil, err
+		}
+		skip = duplicatesToSkip(groups)
+	}
+
+	for _, file := range files {
+		if skip[file.FullPath()] {
+			plan.Duplicates = append(plan.Duplicates, file)
+			continue
+		}
+
+		meta := f.ResolveMetadata(file)
+		plan.Items = append(plan.Items, models.PlanItem{
+			File:     file,
+			Metadata: meta,
+			DestDir:  GetDestinationDirByLayout(layout, file, meta, sess),
+		})
+	}
+
+	return plan, nil
+}
+
+// ExecutePlan copies each file within the provided plan to its planned destination directory using the plan's
+// collision policy, reporting its progress to the provided progress (if any) and stopping early if the provided context is done
+func (f *FileSystemAgent) ExecutePlan(ctx context.Context, plan *models.Plan, progress app.JobProgress) error {
+	if plan == nil {
+		return errors.New("plan is nil")
+	}
+	if progress == nil {
+		progress = noopJobProgress{}
+	}
+
+	f.CollisionPolicy = plan.CollisionPolicy
+
+	for _, item := range plan.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := item.File.NameWithExt()
+		progress.Processing(name)
+		result, err := f.ProcessFileByCopy(item.File, item.DestDir)
+		progress.Processed(name, result.Outcome, err)
+	}
+
+	return nil
+}
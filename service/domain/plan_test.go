@@ -0,0 +1,205 @@
+package domain_test
+
+import (
+	"context"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+)
+
+// keyValStoreInjector provides a PlanAgentInjector backed by the provided store
+type keyValStoreInjector struct{ store app.KeyValStore }
+
+func (k keyValStoreInjector) KeyValStore() app.KeyValStore { return k.store }
+
+func TestFileSystemAgent_PlanByDate(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	for _, name := range []string{"20180526_140029.jpg", "20180526_150000.png", "20190101_101010.jpg", "notes.txt"} {
+		if err := ioutil.WriteFile(path.Join(baseDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sess := &models.Session{Token: "token", BaseDir: baseDir, SubDir: "subdir"}
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	layout, err := domain.ParseDirLayout("{year}-{month}-{day}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := fsAgent.PlanByDate(sess, layout, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("planning by date must group files by the expected destination directories", func(t *testing.T) {
+		groups := plan.GroupByDestDir()
+
+		expectedDestDirs := []string{
+			path.Join(baseDir, "subdir/by-date/2018-05-26"),
+			path.Join(baseDir, "subdir/by-date/2019-01-01"),
+		}
+		if len(groups) != len(expectedDestDirs) {
+			t.Fatalf("expected %d groups, got %d", len(expectedDestDirs), len(groups))
+		}
+		for idx, group := range groups {
+			if group.DestDir != expectedDestDirs[idx] {
+				t.Fatalf("group %d: expected %s, got %s", idx, expectedDestDirs[idx], group.DestDir)
+			}
+		}
+		if len(groups[0].Items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(groups[0].Items))
+		}
+	})
+
+	t.Run("planning by date must not touch the file system", func(t *testing.T) {
+		if _, err := os.Stat(sess.FullDir()); !os.IsNotExist(err) {
+			t.Fatalf("expected %s not to exist, got %+v", sess.FullDir(), err)
+		}
+	})
+
+	t.Run("executing plan with done context must not copy any files", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := fsAgent.ExecutePlan(ctx, plan, nil); err != context.Canceled {
+			t.Fatalf("expected %+v, got %+v", context.Canceled, err)
+		}
+		if _, err := os.Stat(sess.FullDir()); !os.IsNotExist(err) {
+			t.Fatalf("expected %s not to exist, got %+v", sess.FullDir(), err)
+		}
+	})
+
+	t.Run("executing plan must copy each file to its planned destination directory and report progress", func(t *testing.T) {
+		progress := &countingJobProgress{}
+
+		if err := fsAgent.ExecutePlan(context.Background(), plan, progress); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, item := range plan.Items {
+			if _, err := os.Stat(path.Join(item.DestDir, item.File.NameWithExt())); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if progress.processed != len(plan.Items) || progress.failed != 0 {
+			t.Fatalf("expected %d processed and 0 failed, got %d and %d", len(plan.Items), progress.processed, progress.failed)
+		}
+	})
+
+	t.Run("executing plan whose source files are missing must report failures", func(t *testing.T) {
+		missing := &models.Plan{Items: []models.PlanItem{
+			{File: models.NewFile("missing", "jpg", baseDir, nil), DestDir: sess.FullDir("missing")},
+		}}
+		progress := &countingJobProgress{}
+
+		if err := fsAgent.ExecutePlan(context.Background(), missing, progress); err != nil {
+			t.Fatal(err)
+		}
+		if progress.processed != 1 || progress.failed != 1 {
+			t.Fatalf("expected 1 processed and 1 failed, got %d and %d", progress.processed, progress.failed)
+		}
+	})
+}
+
+// countingJobProgress counts the progress that is reported to it
+type countingJobProgress struct {
+	processed int
+	failed    int
+	outcomes  map[string]int
+}
+
+func (c *countingJobProgress) Processing(string) {}
+
+func (c *countingJobProgress) Processed(_ string, outcome string, err error) {
+	c.processed++
+	if err != nil {
+		c.failed++
+		return
+	}
+	if c.outcomes == nil {
+		c.outcomes = make(map[string]int)
+	}
+	c.outcomes[outcome]++
+}
+
+func TestPlanAgent_GetPlanForSession(t *testing.T) {
+	planAgent := domain.PlanAgent{PlanAgentInjector: keyValStoreInjector{store: domain.NewInMemoryKeyValStore()}}
+
+	plan := &models.Plan{SessionToken: "owner"}
+	if err := planAgent.SavePlan(plan); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retrieving plan for owning session must succeed", func(t *testing.T) {
+		actualPlan, err := planAgent.GetPlanForSession(plan.ID, &models.Session{Token: "owner"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actualPlan.ID != plan.ID {
+			t.Fatalf("expected %s, got %s", plan.ID, actualPlan.ID)
+		}
+	})
+
+	t.Run("retrieving plan for another session must return not found error", func(t *testing.T) {
+		_, err := planAgent.GetPlanForSession(plan.ID, &models.Session{Token: "intruder"})
+		if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected not found error, got %+v", err)
+		}
+	})
+
+	t.Run("claiming plan twice must return validation error", func(t *testing.T) {
+		if _, err := planAgent.ClaimPlanForSession(plan.ID, &models.Session{Token: "owner"}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := planAgent.ClaimPlanForSession(plan.ID, &models.Session{Token: "owner"})
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected validation error, got %+v", err)
+		}
+	})
+
+	t.Run("claiming plan concurrently must only succeed once", func(t *testing.T) {
+		concurrentPlan := &models.Plan{SessionToken: "owner"}
+		if err := planAgent.SavePlan(concurrentPlan); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var claimed int
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := planAgent.ClaimPlanForSession(concurrentPlan.ID, &models.Session{Token: "owner"})
+				if _, ok := err.(domain.ValidationError); err != nil && !ok {
+					t.Error(err)
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					claimed++
+				}
+			}()
+		}
+		wg.Wait()
+
+		if claimed != 1 {
+			t.Fatalf("expected 1 claim, got %d", claimed)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WriterJobProgress reports the progress of a long-running operation as lines of text written to the provided writer,
+// and keeps count of the outcomes and failures that have been reported
+type WriterJobProgress struct {
+	w        io.Writer
+	total    int
+	done     int
+	failed   int
+	outcomes map[string]int
+	mu       sync.Mutex
+}
+
+// Processing implements app.JobProgress.Processing()
+func (p *WriterJobProgress) Processing(item string) {}
+
+// Processed implements app.JobProgress.Processed()
+func (p *WriterJobProgress) Processed(item string, outcome string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	if err != nil {
+		p.failed++
+		fmt.Fprintf(p.w, "[%d/%d] %s: failed: %s\n", p.done, p.total, item, err)
+		return
+	}
+
+	if outcome != "" {
+		p.outcomes[outcome]++
+	}
+	fmt.Fprintf(p.w, "[%d/%d] %s: %s\n", p.done, p.total, item, outcome)
+}
+
+// Failed returns the number of items that have failed
+func (p *WriterJobProgress) Failed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.failed
+}
+
+// Summary returns a single line that describes the outcomes and failures that have been reported
+func (p *WriterJobProgress) Summary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var outcomes []string
+	for outcome, count := range p.outcomes {
+		outcomes = append(outcomes, fmt.Sprintf("%d %s", count, outcome))
+	}
+	sort.Strings(outcomes)
+	outcomes = append(outcomes, fmt.Sprintf("%d failed", p.failed))
+
+	return fmt.Sprintf("processed %d of %d: %s", p.done, p.total, strings.Join(outcomes, ", "))
+}
+
+// NewWriterJobProgress returns a newly-instantiated WriterJobProgress for the provided total number of items
+func NewWriterJobProgress(w io.Writer, total int) *WriterJobProgress {
+	return &WriterJobProgress{
+		w:        w,
+		total:    total,
+		outcomes: make(map[string]int),
+	}
+}
@@ -0,0 +1,37 @@
+package domain_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"testing"
+)
+
+func TestWriterJobProgress(t *testing.T) {
+	t.Run("reporting progress must write a line per item and summarise the outcomes", func(t *testing.T) {
+		var buf bytes.Buffer
+		progress := domain.NewWriterJobProgress(&buf, 3)
+
+		progress.Processing("a.jpg")
+		progress.Processed("a.jpg", "copied", nil)
+		progress.Processing("b.jpg")
+		progress.Processed("b.jpg", "", errors.New("permission denied"))
+		progress.Processing("c.jpg")
+		progress.Processed("c.jpg", "copied", nil)
+
+		expectedOutput := "[1/3] a.jpg: copied\n[2/3] b.jpg: failed: permission denied\n[3/3] c.jpg: copied\n"
+		if diff := cmp.Diff(expectedOutput, buf.String()); diff != "" {
+			t.Fatalf("want %q, got %q, diff: %s", expectedOutput, buf.String(), diff)
+		}
+
+		if progress.Failed() != 1 {
+			t.Fatalf("expected 1 failed, got %d", progress.Failed())
+		}
+
+		expectedSummary := "processed 3 of 3: 2 copied, 1 failed"
+		if progress.Summary() != expectedSummary {
+			t.Fatalf("expected %s, got %s", expectedSummary, progress.Summary())
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package domain
+
+import (
+	"fmt"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"os"
+	"path/filepath"
+)
+
+// RestrictedFileSystem defines a file system that only permits operations on paths within its root directories,
+// once any symbolic links have been resolved, and otherwise defers to the file system that it wraps
+type RestrictedFileSystem struct {
+	app.FileSystem
+	roots []string
+}
+
+// IsDirectory implements app.FileSystem.IsDirectory()
+func (r *RestrictedFileSystem) IsDirectory(path string) bool {
+	if err := r.permit(path); err != nil {
+		return false
+	}
+
+	return r.FileSystem.IsDirectory(path)
+}
+
+// GetFilesInDirectory implements app.FileSystem.GetFilesInDirectory()
+func (r *RestrictedFileSystem) GetFilesInDirectory(path string) ([]models.File, error) {
+	if err := r.permit(path); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.GetFilesInDirectory(path)
+}
+
+// GetFilesInDirectoryTree implements app.FileSystem.GetFilesInDirectoryTree()
+func (r *RestrictedFileSystem) GetFilesInDirectoryTree(path string, maxDepth int, excludes []string) ([]models.File, error) {
+	if err := r.permit(path); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.GetFilesInDirectoryTree(path, maxDepth, excludes)
+}
+
+// GetDirectoriesInDirectory implements app.FileSystem.GetDirectoriesInDirectory()
+func (r *RestrictedFileSystem) GetDirectoriesInDirectory(path string) ([]models.Directory, error) {
+	if err := r.permit(path); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.GetDirectoriesInDirectory(path)
+}
+
+// GetContents implements app.FileSystem.GetContents()
+func (r *RestrictedFileSystem) GetContents(file models.File) ([]byte, error) {
+	if err := r.permit(file.FullPath()); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.GetContents(file)
+}
+
+// Open implements app.FileSystem.Open()
+func (r *RestrictedFileSystem) Open(file models.File) (app.ReadSeekCloser, error) {
+	if err := r.permit(file.FullPath()); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.Open(file)
+}
+
+// Stat implements app.FileSystem.Stat()
+func (r *RestrictedFileSystem) Stat(file models.File) (os.FileInfo, error) {
+	if err := r.permit(file.FullPath()); err != nil {
+		return nil, err
+	}
+
+	return r.FileSystem.Stat(file)
+}
+
+// Copy implements app.FileSystem.Copy()
+func (r *RestrictedFileSystem) Copy(file models.File, dest models.File) (string, error) {
+	if err := r.permit(file.FullPath(), dest.FullPath()); err != nil {
+		return "", err
+	}
+
+	return r.FileSystem.Copy(file, dest)
+}
+
+// Move implements app.FileSystem.Move()
+func (r *RestrictedFileSystem) Move(file models.File, dest models.File) (string, error) {
+	if err := r.permit(file.FullPath(), dest.FullPath()); err != nil {
+		return "", err
+	}
+
+	return r.FileSystem.Move(file, dest)
+}
+
+// Remove implements app.FileSystem.Remove()
+func (r *RestrictedFileSystem) Remove(file models.File) error {
+	if err := r.permit(file.FullPath()); err != nil {
+		return err
+	}
+
+	return r.FileSystem.Remove(file)
+}
+
+// permit returns a ValidationError if any of the provided paths is outside of the root directories
+func (r *RestrictedFileSystem) permit(paths ...string) error {
+	return permitPaths(r.roots, paths...)
+}
+
+// permitPaths returns a ValidationError if any of the provided paths is outside of the provided root directories,
+// which must have had their symbolic links resolved already
+func permitPaths(roots []string, paths ...string) error {
+	for _, p := range paths {
+		resolved, err := resolvePath(p)
+		if err != nil {
+			return ValidationError{Err: fmt.Errorf("cannot resolve path %s: %s", p, err)}
+		}
+		if _, ok := rootOf(roots, resolved); !ok {
+			return ValidationError{Err: fmt.Errorf("path is outside of the permitted directories: %s", p)}
+		}
+	}
+
+	return nil
+}
+
+// NewRestrictedFileSystem returns a newly-instantiated RestrictedFileSystem that wraps the provided file system,
+// and permits operations within the provided root directories only
+func NewRestrictedFileSystem(fs app.FileSystem, roots []string) (*RestrictedFileSystem, error) {
+	resolved, err := resolveRoots(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RestrictedFileSystem{
+		FileSystem: fs,
+		roots:      resolved,
+	}, nil
+}
+
+// CheckPathWithinRoots returns a ValidationError if the provided path is outside of all of the provided root directories,
+// once any symbolic links have been resolved. No path is outside of an empty set of roots
+func CheckPathWithinRoots(roots []string, p string) error {
+	if len(roots) == 0 {
+		return nil
+	}
+
+	resolvedRoots, err := resolveRoots(roots)
+	if err != nil {
+		return err
+	}
+
+	return permitPaths(resolvedRoots, p)
+}
+
+// resolveRoots returns the provided root directories with any symbolic links resolved
+func resolveRoots(roots []string) ([]string, error) {
+	var resolved []string
+
+	for _, root := range roots {
+		r, err := resolvePath(root)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve root directory %s: %s", root, err)
+		}
+		resolved = append(resolved, r)
+	}
+
+	return resolved, nil
+}
+
+// resolvePath returns the provided path as an absolute path with any symbolic links resolved. If the path doesn't
+// exist yet, the closest ancestor that does exist is resolved instead, so that destinations can be checked before
+// they are created
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, lerr := os.Lstat(abs); lerr == nil {
+			// exists, but links to somewhere that doesn't, which could be created outside of the roots
+			return "", err
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(abs)}, missing...)
+		abs = parent
+	}
+}
@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestRestrictedFileSystem(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	root := path.Join(baseDir, "root")
+	outside := path.Join(baseDir, "outside")
+	for _, dir := range []string{root, outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "a.jpg"), []byte(dir), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, path.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(path.Join(outside, "missing.jpg"), path.Join(root, "dangling.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := domain.NewRestrictedFileSystem(&domain.OsFileSystem{}, []string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("operating within the roots must succeed", func(t *testing.T) {
+		if !fs.IsDirectory(root) {
+			t.Fatalf("expected %s to be a directory", root)
+		}
+		if _, err := fs.GetFilesInDirectory(root); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.Copy(models.NewFile("a", "jpg", root, nil), models.NewFile("a", "jpg", path.Join(root, "new", "dir"), nil)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fs.Stat(models.NewFile("a", "jpg", path.Join(root, "new", "dir"), nil)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("operating outside of the roots must return a validation error", func(t *testing.T) {
+		testCases := []func() error{
+			func() error {
+				_, err := fs.GetFilesInDirectory(outside)
+				return err
+			},
+			func() error {
+				_, err := fs.GetFilesInDirectoryTree(path.Join(root, ".."), 0, nil)
+				return err
+			},
+			func() error {
+				_, err := fs.GetDirectoriesInDirectory(path.Join(root, "escape"))
+				return err
+			},
+			func() error {
+				_, err := fs.Open(models.NewFile("a", "jpg", path.Join(root, "escape"), nil))
+				return err
+			},
+			func() error {
+				_, err := fs.Stat(models.NewFile("a", "jpg", outside, nil))
+				return err
+			},
+			func() error {
+				_, err := fs.GetContents(models.NewFile("a", "jpg", outside, nil))
+				return err
+			},
+			func() error {
+				_, err := fs.Copy(models.NewFile("a", "jpg", root, nil), models.NewFile("a", "jpg", path.Join(root, "escape", "new"), nil))
+				return err
+			},
+			func() error {
+				_, err := fs.Copy(models.NewFile("a", "jpg", root, nil), models.NewFile("dangling", "jpg", root, nil))
+				return err
+			},
+			func() error {
+				_, err := fs.Move(models.NewFile("a", "jpg", outside, nil), models.NewFile("b", "jpg", root, nil))
+				return err
+			},
+			func() error {
+				return fs.Remove(models.NewFile("a", "jpg", path.Join(root, "escape"), nil))
+			},
+		}
+
+		for idx, tc := range testCases {
+			err := tc()
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+
+		if fs.IsDirectory(path.Join(root, "escape")) {
+			t.Error("expected escaping directory not to be a directory")
+		}
+		if _, err := os.Stat(path.Join(outside, "a.jpg")); err != nil {
+			t.Errorf("expected file outside of the roots to remain: %s", err)
+		}
+	})
+
+	t.Run("creating a session outside of the roots must return a validation error", func(t *testing.T) {
+		sessAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{
+			fs:    fs,
+			store: domain.NewInMemoryKeyValStore(),
+			roots: []string{root},
+		}}
+
+		if _, err := sessAgent.NewSessionFromDirectoryAndTimestamp(root, time.Now(), models.ScanOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		for idx, dirPath := range []string{outside, path.Join(root, "escape"), path.Join(root, "..")} {
+			_, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dirPath, time.Now(), models.ScanOptions{})
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"imgnheap/service/models"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetSessionRuns returns the output directories of previous sessions within the provided directory path,
+// including the count of image files within each one, most recent first
+func (f *FileSystemAgent) GetSessionRuns(dirPath string) ([]models.SessionRun, error) {
+	if !f.FileSystem().IsDirectory(dirPath) {
+		return nil, ValidationError{Err: fmt.Errorf("not a directory: %s", dirPath)}
+	}
+
+	dirs, err := f.FileSystem().GetDirectoriesInDirectory(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []models.SessionRun
+	for _, dir := range dirs {
+		if ok, _ := path.Match(sessionDirGlob, dir.Name); !ok {
+			continue
+		}
+
+		startedAt, err := time.ParseInLocation(sessionDirTimestampFormat, strings.TrimPrefix(dir.Name, sessionDirPrefix), time.Local)
+		if err != nil {
+			continue
+		}
+
+		files, err := f.FileSystem().GetFilesInDirectoryTree(dir.FullPath(), 0, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, models.SessionRun{
+			SubDir:    dir.Name,
+			StartedAt: startedAt,
+			FileCount: len(filterFilesByExtension(files, ImgFileExts...)),
+		})
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+
+	return runs, nil
+}
+
+// RemoveStaleTempFilesFromSessionRuns removes the temporary files that have been left behind within the output
+// directories of previous sessions within the provided directory path by copies that were cut short, such as by
+// exiting, and returns the files that were removed. Only those that have gone unmodified for long enough to no longer
+// belong to a copy in progress are removed
+func (f *FileSystemAgent) RemoveStaleTempFilesFromSessionRuns(dirPath string) ([]models.File, error) {
+	dirs, err := f.FileSystem().GetDirectoriesInDirectory(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []models.File
+	for _, dir := range dirs {
+		if ok, _ := path.Match(sessionDirGlob, dir.Name); !ok {
+			continue
+		}
+
+		files, err := f.RemoveTempFiles(dir.FullPath(), time.Now().Add(-staleTempFileAge))
+		removed = append(removed, files...)
+		if err != nil {
+			return removed, err
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,162 @@
+package domain_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestFileSystemAgent_GetSessionRuns(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	for _, relPath := range []string{
+		"a.jpg",
+		"imgnheap20190101101010/by-date/2019/01/01/b.jpg",
+		"imgnheap20190101101010/journal.jsonl",
+		"imgnheap20200102150405/by-tag/holiday/c.jpg",
+		"imgnheap20200102150405/by-tag/holiday/d.png",
+		"imgnheapish/e.jpg",
+	} {
+		fullPath := path.Join(baseDir, relPath)
+		if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	t.Run("getting session runs must provide previous output directories most recent first", func(t *testing.T) {
+		runs, err := fsAgent.GetSessionRuns(baseDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []models.SessionRun{
+			{SubDir: "imgnheap20200102150405", StartedAt: time.Date(2020, 1, 2, 15, 4, 5, 0, time.Local), FileCount: 2},
+			{SubDir: "imgnheap20190101101010", StartedAt: time.Date(2019, 1, 1, 10, 10, 10, 0, time.Local), FileCount: 1},
+		}
+		if diff := cmp.Diff(expected, runs); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, runs, diff)
+		}
+	})
+
+	t.Run("getting session runs for a missing directory must return a validation error", func(t *testing.T) {
+		_, err := fsAgent.GetSessionRuns(path.Join(baseDir, "missing"))
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+
+	sessAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{fs: &domain.OsFileSystem{}, store: domain.NewInMemoryKeyValStore()}}
+
+	t.Run("resuming a session run must write to its output directory", func(t *testing.T) {
+		sess, err := sessAgent.ResumeSessionFromDirectory(baseDir, "imgnheap20190101101010", models.ScanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := sessAgent.GetSessionFromToken(sess.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.FullDir() != path.Join(baseDir, "imgnheap20190101101010") {
+			t.Fatalf("expected %s, got %s", path.Join(baseDir, "imgnheap20190101101010"), actual.FullDir())
+		}
+	})
+
+	t.Run("resuming anything other than a session run must return a validation error", func(t *testing.T) {
+		for idx, subDir := range []string{"imgnheapish", "imgnheap20210101101010", "../imgnheap20190101101010", ""} {
+			_, err := sessAgent.ResumeSessionFromDirectory(baseDir, subDir, models.ScanOptions{})
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
+
+func TestFileSystemAgent_RemoveStaleTempFilesFromSessionRuns(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	stale := time.Now().Add(-2 * time.Hour)
+	for relPath, modTime := range map[string]time.Time{
+		".a.jpg.123.imgnheap-tmp":                                       stale,
+		"imgnheap20190101101010/by-date/2019/.b.jpg.123.imgnheap-tmp":   stale,
+		"imgnheap20190101101010/by-date/2019/.c.jpg.456.imgnheap-tmp":   time.Now(),
+		"imgnheap20190101101010/by-date/2019/c.jpg":                     stale,
+		"imgnheap20190101101010/by-date/2019/.d.jpg":                    stale,
+		"imgnheap20200102150405/by-tag/holiday/.e.jpg.789.imgnheap-tmp": stale,
+		"imgnheapish/.f.jpg.123.imgnheap-tmp":                           stale,
+	} {
+		fullPath := path.Join(baseDir, relPath)
+		if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(fullPath, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	t.Run("removing stale temporary files must only remove those within session runs that are no longer being written", func(t *testing.T) {
+		removed, err := fsAgent.RemoveStaleTempFilesFromSessionRuns(baseDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var removedPaths []string
+		for _, file := range removed {
+			removedPaths = append(removedPaths, file.RelativePath(baseDir))
+		}
+		expected := []string{
+			"imgnheap20190101101010/by-date/2019/.b.jpg.123.imgnheap-tmp",
+			"imgnheap20200102150405/by-tag/holiday/.e.jpg.789.imgnheap-tmp",
+		}
+		if diff := cmp.Diff(expected, removedPaths); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, removedPaths, diff)
+		}
+
+		for _, relPath := range expected {
+			if _, err := os.Stat(path.Join(baseDir, relPath)); !os.IsNotExist(err) {
+				t.Errorf("expected %s to be removed, got %v", relPath, err)
+			}
+		}
+		for _, relPath := range []string{
+			".a.jpg.123.imgnheap-tmp",
+			"imgnheap20190101101010/by-date/2019/.c.jpg.456.imgnheap-tmp",
+			"imgnheap20190101101010/by-date/2019/c.jpg",
+			"imgnheap20190101101010/by-date/2019/.d.jpg",
+			"imgnheapish/.f.jpg.123.imgnheap-tmp",
+		} {
+			if _, err := os.Stat(path.Join(baseDir, relPath)); err != nil {
+				t.Errorf("expected %s to be kept, got %v", relPath, err)
+			}
+		}
+	})
+
+	t.Run("removing temporary files from a missing directory must remove nothing", func(t *testing.T) {
+		removed, err := fsAgent.RemoveTempFiles(path.Join(baseDir, "missing"), time.Now())
+		if err != nil || len(removed) != 0 {
+			t.Fatalf("expected nothing removed, got %+v (%v)", removed, err)
+		}
+	})
+}
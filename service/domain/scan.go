@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"imgnheap/service/models"
+	"path"
+	"strings"
+)
+
+// sessionDirGlob matches the name of the directory that a session writes its output to, e.g. imgnheap20200102150405
+var sessionDirGlob = sessionDirPrefix + strings.Repeat("[0-9]", 14)
+
+// ParseScanOptions validates the provided options and returns the scan options that they describe.
+// The provided excludes are a comma-separated list of glob patterns
+func ParseScanOptions(recursive bool, maxDepth int, excludes string) (models.ScanOptions, error) {
+	if maxDepth < 0 {
+		return models.ScanOptions{}, ValidationError{Err: fmt.Errorf("max depth must not be negative: %d", maxDepth)}
+	}
+
+	opts := models.ScanOptions{
+		Recursive: recursive,
+		MaxDepth:  maxDepth,
+	}
+
+	for _, pattern := range strings.Split(excludes, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return models.ScanOptions{}, ValidationError{Err: fmt.Errorf("invalid exclude pattern %s: %s", pattern, err)}
+		}
+		opts.Excludes = append(opts.Excludes, pattern)
+	}
+
+	return opts, nil
+}
+
+// FileFromRelativePath returns a file object from the provided path, which must be relative to the provided base directory
+// and must not step outside of it
+func FileFromRelativePath(baseDir, relPath string) (models.File, error) {
+	cleaned := path.Clean(relPath)
+
+	if relPath == "" || cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return models.File{}, ValidationError{Err: fmt.Errorf("invalid file path: %s", relPath)}
+	}
+	if err := ValidateRelativePath(cleaned); err != nil {
+		return models.File{}, err
+	}
+
+	name, ext := ParseNameAndExtensionFromFileName(path.Base(cleaned))
+	return models.NewFile(name, ext, path.Join(baseDir, path.Dir(cleaned)), nil), nil
+}
+
+// matchesAny returns true if any of the provided names matches any of the provided glob patterns, otherwise false
+func matchesAny(patterns []string, names ...string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
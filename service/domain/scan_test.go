@@ -0,0 +1,151 @@
+package domain_test
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"testing"
+)
+
+func TestFileSystemAgent_GetFilesForSession(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	for _, relPath := range []string{
+		"a.jpg",
+		"notes.txt",
+		"DCIM/100APPLE/b.jpg",
+		"DCIM/100APPLE/deeper/c.png",
+		"DCIM/.thumbnails/d.jpg",
+		"imgnheap20200102150405/by-tag/holiday/e.jpg",
+		"imgnheapish/f.jpg",
+	} {
+		fullPath := path.Join(baseDir, relPath)
+		if err := os.MkdirAll(path.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	t.Run("getting files for a session must scan according to its scan options", func(t *testing.T) {
+		testCases := []struct {
+			scan          models.ScanOptions
+			expectedPaths []string
+		}{
+			{
+				scan:          models.ScanOptions{},
+				expectedPaths: []string{"a.jpg"},
+			},
+			{
+				scan: models.ScanOptions{Recursive: true},
+				expectedPaths: []string{
+					"DCIM/.thumbnails/d.jpg",
+					"DCIM/100APPLE/b.jpg",
+					"DCIM/100APPLE/deeper/c.png",
+					"a.jpg",
+					"imgnheapish/f.jpg",
+				},
+			},
+			{
+				scan: models.ScanOptions{Recursive: true, MaxDepth: 2},
+				expectedPaths: []string{
+					"DCIM/.thumbnails/d.jpg",
+					"DCIM/100APPLE/b.jpg",
+					"a.jpg",
+					"imgnheapish/f.jpg",
+				},
+			},
+			{
+				scan: models.ScanOptions{Recursive: true, Excludes: []string{".thumbnails", "imgnheapish/*", "deeper"}},
+				expectedPaths: []string{
+					"DCIM/100APPLE/b.jpg",
+					"a.jpg",
+				},
+			},
+		}
+
+		for idx, tc := range testCases {
+			sess := &models.Session{BaseDir: baseDir, SubDir: "imgnheap20200102150405", Scan: tc.scan}
+
+			files, err := fsAgent.GetFilesForSession(sess, domain.ImgFileExts...)
+			if err != nil {
+				t.Fatalf("tc %d: %s", idx, err)
+			}
+
+			var actualPaths []string
+			for _, file := range files {
+				actualPaths = append(actualPaths, file.RelativePath(baseDir))
+			}
+			sort.Strings(actualPaths)
+
+			if diff := cmp.Diff(tc.expectedPaths, actualPaths); diff != "" {
+				t.Errorf("tc %d: want %+v, got %+v, diff: %s", idx, tc.expectedPaths, actualPaths, diff)
+			}
+		}
+	})
+}
+
+func TestParseScanOptions(t *testing.T) {
+	t.Run("parsing scan options must split and trim exclude patterns", func(t *testing.T) {
+		opts, err := domain.ParseScanOptions(true, 3, " .thumbnails, ,*.tmp ")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := models.ScanOptions{Recursive: true, MaxDepth: 3, Excludes: []string{".thumbnails", "*.tmp"}}
+		if diff := cmp.Diff(expected, opts); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, opts, diff)
+		}
+	})
+
+	t.Run("parsing invalid scan options must return a validation error", func(t *testing.T) {
+		testCases := []struct {
+			maxDepth int
+			excludes string
+		}{
+			{maxDepth: -1},
+			{excludes: "[unclosed"},
+		}
+
+		for idx, tc := range testCases {
+			_, err := domain.ParseScanOptions(true, tc.maxDepth, tc.excludes)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
+
+func TestFileFromRelativePath(t *testing.T) {
+	t.Run("file from relative path must resolve paths within the base directory", func(t *testing.T) {
+		file, err := domain.FileFromRelativePath("/heap", "DCIM/./100APPLE/IMG_0001.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := models.NewFile("IMG_0001", "jpg", "/heap/DCIM/100APPLE", nil)
+		if diff := cmp.Diff(expected, file); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", expected, file, diff)
+		}
+	})
+
+	t.Run("file from relative path must reject paths outside of the base directory", func(t *testing.T) {
+		for idx, relPath := range []string{"", ".", "..", "../etc/passwd", "DCIM/../../etc/passwd", "/etc/passwd", "a.jpg\x00.png", "．．/etc/passwd", `..\etc\passwd`} {
+			_, err := domain.FileFromRelativePath("/heap", relPath)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
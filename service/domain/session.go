@@ -1,21 +1,38 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"imgnheap/service/app"
 	"imgnheap/service/models"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 )
 
-const cookieName = "SESS_ID"
+const (
+	cookieName          = "SESS_ID"
+	accessCookieName    = "ACCESS_TOKEN"
+	indexCSRFCookieName = "INDEX_CSRF"
+)
+
+// sessionDirPrefix represents the prefix with which a session's output directory is named
+const sessionDirPrefix = "imgnheap"
+
+// sessionDirTimestampFormat represents the format of the timestamp with which a session's output directory is named
+const sessionDirTimestampFormat = "20060102150405"
+
+// sessionTTL represents how long a session is retained for after it has been created
+const sessionTTL = 7 * 24 * time.Hour
 
 // SessionAgentInjector defines the injector behaviours for our SessionAgent
 type SessionAgentInjector interface {
 	app.FileSystemInjector
 	app.KeyValStoreInjector
+	app.RootsInjector
 }
 
 // SessionAgent represents our methods for interacting with sessions
@@ -23,13 +40,45 @@ type SessionAgent struct {
 	SessionAgentInjector
 }
 
-// NewSessionFromDirectoryAndTimestamp generates a new session based on the provided directory path and timestamp, and returns the session
-func (s *SessionAgent) NewSessionFromDirectoryAndTimestamp(dirPath string, ts time.Time) (*models.Session, error) {
+// NewSessionFromDirectoryAndTimestamp generates a new session based on the provided directory path, timestamp and scan options,
+// and returns the session
+func (s *SessionAgent) NewSessionFromDirectoryAndTimestamp(dirPath string, ts time.Time, scan models.ScanOptions) (*models.Session, error) {
+	// is directory permitted?
+	if err := CheckPathWithinRoots(s.Roots(), dirPath); err != nil {
+		return nil, err
+	}
+
 	// does directory exist?
 	if !s.FileSystem().IsDirectory(dirPath) {
 		return nil, ValidationError{Err: fmt.Errorf("not a directory: %s", dirPath)}
 	}
 
+	return s.newSession(dirPath, sessionDirPrefix+ts.Format(sessionDirTimestampFormat), scan)
+}
+
+// ResumeSessionFromDirectory generates a new session based on the provided directory path and scan options,
+// which continues writing to the provided output directory of a previous session, and returns the session
+func (s *SessionAgent) ResumeSessionFromDirectory(dirPath string, subDir string, scan models.ScanOptions) (*models.Session, error) {
+	// is directory permitted?
+	if err := CheckPathWithinRoots(s.Roots(), dirPath); err != nil {
+		return nil, err
+	}
+
+	// does directory exist?
+	if !s.FileSystem().IsDirectory(dirPath) {
+		return nil, ValidationError{Err: fmt.Errorf("not a directory: %s", dirPath)}
+	}
+
+	// is sub-directory the output of a previous session?
+	if ok, _ := path.Match(sessionDirGlob, subDir); !ok || !s.FileSystem().IsDirectory(path.Join(dirPath, subDir)) {
+		return nil, ValidationError{Err: fmt.Errorf("not a previous session directory: %s", subDir)}
+	}
+
+	return s.newSession(dirPath, subDir, scan)
+}
+
+// newSession stores and returns a session with a new token, based on the provided directory path, sub-directory and scan options
+func (s *SessionAgent) newSession(dirPath string, subDir string, scan models.ScanOptions) (*models.Session, error) {
 	// generate new session token
 	id, err := uuid.NewRandom()
 	if err != nil {
@@ -37,13 +86,25 @@ func (s *SessionAgent) NewSessionFromDirectoryAndTimestamp(dirPath string, ts ti
 	}
 	sessToken := id.String()
 
+	// generate token that the session's forms must carry
+	csrfToken, err := NewSecretToken()
+	if err != nil {
+		return nil, err
+	}
+
 	// create session object
 	sess := &models.Session{
-		Token:   sessToken,
-		BaseDir: dirPath,
-		SubDir:  fmt.Sprintf("imgnheap%s", ts.Format("20060102150405")),
+		Token:     sessToken,
+		CSRFToken: csrfToken,
+		BaseDir:   dirPath,
+		SubDir:    subDir,
+		Scan:      scan,
 	}
-	if err := s.KeyValStore().Write(sessToken, sess); err != nil {
+	val, err := json.Marshal(sess)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.KeyValStore().WriteWithTTL(sessToken, val, sessionTTL); err != nil {
 		return nil, err
 	}
 
@@ -57,12 +118,114 @@ func (s *SessionAgent) GetSessionFromToken(sessToken string) (*models.Session, e
 		return nil, err
 	}
 
-	sess, ok := val.(*models.Session)
-	if !ok {
-		return nil, fmt.Errorf("error token %s does not represents session object", sessToken)
+	var sess models.Session
+	if err := json.Unmarshal(val, &sess); err != nil {
+		return nil, fmt.Errorf("error token %s does not represent session object: %s", sessToken, err)
 	}
 
-	return sess, nil
+	return &sess, nil
+}
+
+// VerifyCSRFToken returns a ForbiddenError if the provided token is not the provided session's CSRF token
+func (s *SessionAgent) VerifyCSRFToken(sess *models.Session, token string) error {
+	if sess == nil {
+		return errors.New("session is nil")
+	}
+
+	if !SecretTokensMatch(sess.CSRFToken, token) {
+		return ForbiddenError{Err: errors.New("invalid csrf token")}
+	}
+
+	return nil
+}
+
+// DeleteSession removes the provided session, so that its token can no longer be used
+func (s *SessionAgent) DeleteSession(sess *models.Session) error {
+	if sess == nil {
+		return errors.New("session is nil")
+	}
+
+	for _, key := range []string{skippedFilesKey(sess.Token), duplicatesKey(sess.Token), similarKey(sess.Token)} {
+		if err := s.KeyValStore().Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return s.KeyValStore().Delete(sess.Token)
+}
+
+// SkipFileForSession records that the file at the provided path, relative to the provided session's base directory,
+// has been skipped, so that it is no longer offered for processing by the session
+func (s *SessionAgent) SkipFileForSession(sess *models.Session, relPath string) error {
+	skipped, err := s.GetSkippedFilesForSession(sess)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range skipped {
+		if existing == relPath {
+			// already skipped
+			return nil
+		}
+	}
+
+	val, err := json.Marshal(append(skipped, relPath))
+	if err != nil {
+		return err
+	}
+
+	return s.KeyValStore().WriteWithTTL(skippedFilesKey(sess.Token), val, sessionTTL)
+}
+
+// GetSkippedFilesForSession returns the paths, relative to the provided session's base directory,
+// of the files that have been skipped by the session
+func (s *SessionAgent) GetSkippedFilesForSession(sess *models.Session) ([]string, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	val, err := s.KeyValStore().Read(skippedFilesKey(sess.Token))
+	if err != nil {
+		if _, ok := err.(NotFoundError); ok {
+			// nothing skipped yet
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var skipped []string
+	if err := json.Unmarshal(val, &skipped); err != nil {
+		return nil, fmt.Errorf("error key %s does not represent skipped files: %s", skippedFilesKey(sess.Token), err)
+	}
+
+	return skipped, nil
+}
+
+// skippedFilesKey returns the key at which the files skipped by the session with the provided token are stored
+func skippedFilesKey(sessToken string) string {
+	return fmt.Sprintf("skipped:%s", sessToken)
+}
+
+// ExcludeSkippedFiles returns the provided files of the provided session, without those at the provided paths
+// relative to the session's base directory
+func ExcludeSkippedFiles(sess *models.Session, files []models.File, skipped []string) []models.File {
+	if len(skipped) == 0 {
+		return files
+	}
+
+	isSkipped := make(map[string]bool)
+	for _, relPath := range skipped {
+		isSkipped[relPath] = true
+	}
+
+	var remaining []models.File
+	for _, file := range files {
+		if !isSkipped[file.RelativePath(sess.BaseDir)] {
+			remaining = append(remaining, file)
+		}
+	}
+
+	return remaining
 }
 
 // WriteCookie writes the provided session as a cookie to the provided writer
@@ -72,9 +235,12 @@ func (s *SessionAgent) WriteCookie(sess *models.Session, w http.ResponseWriter)
 	}
 
 	http.SetCookie(w, &http.Cookie{
-		Name:  cookieName,
-		Value: sess.Token,
-		Path:  "/",
+		Name:     cookieName,
+		Value:    sess.Token,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
 	})
 
 	return nil
@@ -83,9 +249,11 @@ func (s *SessionAgent) WriteCookie(sess *models.Session, w http.ResponseWriter)
 // DeleteCookie writes the removal of the provided session as a cookie to the provided writer
 func (s *SessionAgent) DeleteCookie(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
-		Name:   cookieName,
-		Path:   "/",
-		MaxAge: -1,
+		Name:     cookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
 	})
 }
 
@@ -98,3 +266,75 @@ func (s *SessionAgent) GetTokenFromCookie(r *http.Request) string {
 
 	return cookie.Value
 }
+
+// GetTokenFromAuthorizationHeader returns the bearer token of the Authorization header, or empty string if missing
+func (s *SessionAgent) GetTokenFromAuthorizationHeader(r *http.Request) string {
+	scheme, token := splitAuthorizationHeader(r.Header.Get("Authorization"))
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return token
+}
+
+// splitAuthorizationHeader returns the scheme and credentials of the provided Authorization header value
+func splitAuthorizationHeader(header string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// WriteAccessCookie writes the provided access token as a cookie to the provided writer
+func (s *SessionAgent) WriteAccessCookie(token string, w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// WriteIndexCSRFCookie writes the provided token, which the index page's forms must carry in order to start a session,
+// as a cookie to the provided writer
+func (s *SessionAgent) WriteIndexCSRFCookie(token string, w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     indexCSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// GetIndexCSRFTokenFromCookie returns string value of index page's csrf token cookie, or empty string if missing
+func (s *SessionAgent) GetIndexCSRFTokenFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(indexCSRFCookieName)
+	if err != nil || cookie == nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// VerifyIndexCSRFToken returns a ForbiddenError if the provided token is not the provided index page's CSRF token
+func (s *SessionAgent) VerifyIndexCSRFToken(expected string, token string) error {
+	if !SecretTokensMatch(expected, token) {
+		return ForbiddenError{Err: errors.New("invalid csrf token, reload the page and try again")}
+	}
+
+	return nil
+}
+
+// GetAccessTokenFromCookie returns string value of access token cookie, or empty string if missing
+func (s *SessionAgent) GetAccessTokenFromCookie(r *http.Request) string {
+	cookie, err := r.Cookie(accessCookieName)
+	if err != nil || cookie == nil {
+		return ""
+	}
+
+	return cookie.Value
+}
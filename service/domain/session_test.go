@@ -0,0 +1,149 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSessionAgent_VerifyCSRFToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sessAgent := domain.SessionAgent{SessionAgentInjector: sessionInjector{fs: &domain.OsFileSystem{}, store: domain.NewInMemoryKeyValStore()}}
+
+	sess, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dir, time.Now(), models.ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sessAgent.NewSessionFromDirectoryAndTimestamp(dir, time.Now(), models.ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("new sessions must have distinct csrf tokens", func(t *testing.T) {
+		if len(sess.CSRFToken) != 64 {
+			t.Fatalf("expected 64 character token, got %q", sess.CSRFToken)
+		}
+		if sess.CSRFToken == other.CSRFToken {
+			t.Fatalf("expected distinct tokens, got %s twice", sess.CSRFToken)
+		}
+	})
+
+	t.Run("verifying the session's own csrf token must succeed", func(t *testing.T) {
+		if err := sessAgent.VerifyCSRFToken(sess, sess.CSRFToken); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("verifying any other csrf token must return a forbidden error", func(t *testing.T) {
+		testCases := []struct {
+			sess  *models.Session
+			token string
+		}{
+			{sess: sess, token: ""},
+			{sess: sess, token: other.CSRFToken},
+			{sess: sess, token: sess.CSRFToken[:32]},
+			{sess: &models.Session{}, token: ""},
+		}
+
+		for idx, tc := range testCases {
+			err := sessAgent.VerifyCSRFToken(tc.sess, tc.token)
+			if _, ok := err.(domain.ForbiddenError); !ok {
+				t.Errorf("tc %d: expected ForbiddenError, got %T", idx, err)
+			}
+		}
+	})
+}
+
+func TestSessionAgent_WriteCookie(t *testing.T) {
+	sessAgent := domain.SessionAgent{}
+
+	t.Run("writing cookies must hide them from scripts and other sites", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if err := sessAgent.WriteCookie(&models.Session{Token: "token"}, w); err != nil {
+			t.Fatal(err)
+		}
+		sessAgent.WriteAccessCookie("access", w)
+		sessAgent.DeleteCookie(w)
+
+		cookies := w.Result().Cookies()
+		if len(cookies) != 3 {
+			t.Fatalf("expected 3 cookies, got %d", len(cookies))
+		}
+		for idx, cookie := range cookies {
+			if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+				t.Errorf("tc %d: expected http only and same site strict, got %+v", idx, cookie)
+			}
+		}
+	})
+
+	t.Run("reading a written access cookie must provide its token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		sessAgent.WriteAccessCookie("access", w)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, cookie := range w.Result().Cookies() {
+			r.AddCookie(cookie)
+		}
+
+		if token := sessAgent.GetAccessTokenFromCookie(r); token != "access" {
+			t.Fatalf("expected access, got %s", token)
+		}
+	})
+}
+
+func TestSessionAgent_GetTokenFromAuthorizationHeader(t *testing.T) {
+	sessAgent := domain.SessionAgent{}
+
+	t.Run("reading an authorization header must only provide bearer tokens", func(t *testing.T) {
+		testCases := []struct {
+			header   string
+			expected string
+		}{
+			{header: "Bearer token", expected: "token"},
+			{header: "bearer  token ", expected: "token"},
+			{header: "Basic dXNlcjpwYXNz"},
+			{header: "Bearer"},
+			{header: ""},
+		}
+
+		for idx, tc := range testCases {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", tc.header)
+
+			if actual := sessAgent.GetTokenFromAuthorizationHeader(r); actual != tc.expected {
+				t.Errorf("tc %d: expected %q, got %q", idx, tc.expected, actual)
+			}
+		}
+	})
+}
+
+func TestSecretTokensMatch(t *testing.T) {
+	t.Run("matching secret tokens must only match identical non-empty tokens", func(t *testing.T) {
+		testCases := []struct {
+			expected, provided string
+			match              bool
+		}{
+			{expected: "secret", provided: "secret", match: true},
+			{expected: "secret", provided: "Secret"},
+			{expected: "secret", provided: "secret2"},
+			{expected: "secret", provided: ""},
+			{expected: "", provided: ""},
+		}
+
+		for idx, tc := range testCases {
+			if actual := domain.SecretTokensMatch(tc.expected, tc.provided); actual != tc.match {
+				t.Errorf("tc %d: expected %t, got %t", idx, tc.match, actual)
+			}
+		}
+	})
+}
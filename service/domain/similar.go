@@ -0,0 +1,372 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"log"
+	"math/bits"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultSimilarityDistance represents the maximum hamming distance between the perceptual hashes of
+	// two images for them to be considered similar, when none has been provided
+	DefaultSimilarityDistance = 10
+	// MaxSimilarityDistance represents the largest meaningful hamming distance between two 64-bit hashes
+	MaxSimilarityDistance = 64
+	// similarHashTTL represents how long the perceptual hash of an unchanged image is cached for
+	similarHashTTL = 7 * 24 * time.Hour
+)
+
+// SimilarFileExts represents the file extensions that can be decoded in order to be perceptually hashed
+var SimilarFileExts = []string{
+	"jpg",
+	"jpeg",
+	"png",
+}
+
+// dHashSamples represents the number of pixels sampled along each axis of a single cell when computing a dHash
+const dHashSamples = 8
+
+// DHash returns the 64-bit difference hash of the provided image, which compares the brightness of
+// horizontally adjacent cells of a 9x8 grid so that resized and re-compressed copies produce similar hashes
+func DHash(img image.Image) uint64 {
+	const cols, rows = 9, 8
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 0
+	}
+
+	var grid [rows][cols]uint64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			// average the brightness of a sample of the pixels within the cell
+			var total, count uint64
+			for sy := 0; sy < dHashSamples; sy++ {
+				y := bounds.Min.Y + (row*dHashSamples+sy)*height/(rows*dHashSamples)
+				for sx := 0; sx < dHashSamples; sx++ {
+					x := bounds.Min.X + (col*dHashSamples+sx)*width/(cols*dHashSamples)
+					r, g, b, _ := img.At(x, y).RGBA()
+					total += (299*uint64(r) + 587*uint64(g) + 114*uint64(b)) / 1000
+					count++
+				}
+			}
+			grid[row][col] = total / count
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols-1; col++ {
+			hash <<= 1
+			if grid[row][col] > grid[row][col+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// HammingDistance returns the number of bits that differ between the two provided hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// ParseSimilarityDistance validates the provided hamming distance, returning the default distance if none is provided
+func ParseSimilarityDistance(distance int) (int, error) {
+	if distance == 0 {
+		return DefaultSimilarityDistance, nil
+	}
+	if distance < 0 || distance > MaxSimilarityDistance {
+		return 0, ValidationError{Err: fmt.Errorf("distance must be between 1 and %d: %d", MaxSimilarityDistance, distance)}
+	}
+
+	return distance, nil
+}
+
+// HashImage returns the perceptual hash and the dimensions of the provided image file, which are taken from the agent's
+// hash cache (if any) while the file is unchanged
+func (f *FileSystemAgent) HashImage(file models.File) (models.SimilarItem, error) {
+	info, err := f.FileSystem().Stat(file)
+	if err != nil {
+		return models.SimilarItem{}, err
+	}
+
+	key := similarHashKey(file, info)
+	if item, ok := f.cachedHash(key); ok {
+		item.File = file
+		return item, nil
+	}
+
+	r, err := f.FileSystem().Open(file)
+	if err != nil {
+		return models.SimilarItem{}, err
+	}
+	defer r.Close()
+
+	img, err := decodeImage(r, file)
+	if err != nil {
+		return models.SimilarItem{}, err
+	}
+
+	item := models.SimilarItem{
+		File:   file,
+		Hash:   DHash(img),
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	if f.HashCache != nil {
+		val, err := json.Marshal(cachedSimilarHash{Hash: item.Hash, Width: item.Width, Height: item.Height})
+		if err == nil {
+			err = f.HashCache.WriteWithTTL(key, val, similarHashTTL)
+		}
+		if err != nil {
+			// the hash is still of use without being cached
+			log.Printf("cannot cache hash of %s: %s\n", file.FullPath(), err)
+		}
+	}
+
+	return item, nil
+}
+
+// cachedHash returns the perceptual hash and the dimensions at the provided key of the agent's hash cache (if any),
+// and whether they were found
+func (f *FileSystemAgent) cachedHash(key string) (models.SimilarItem, bool) {
+	if f.HashCache == nil {
+		return models.SimilarItem{}, false
+	}
+
+	val, err := f.HashCache.Read(key)
+	if err != nil {
+		return models.SimilarItem{}, false
+	}
+
+	var cached cachedSimilarHash
+	if err := json.Unmarshal(val, &cached); err != nil {
+		return models.SimilarItem{}, false
+	}
+
+	return models.SimilarItem{Hash: cached.Hash, Width: cached.Width, Height: cached.Height}, true
+}
+
+// cachedSimilarHash represents the perceptual hash and the dimensions of an image, as held by a hash cache
+type cachedSimilarHash struct {
+	Hash   uint64
+	Width  int
+	Height int
+}
+
+// similarHashKey returns the hash cache key of the provided file in its current state
+func similarHashKey(file models.File, info os.FileInfo) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", file.FullPath(), info.ModTime().UnixNano(), info.Size())))
+	return fmt.Sprintf("dhash:%s", hex.EncodeToString(h[:]))
+}
+
+// FindSimilar returns the clusters of images within the provided files whose perceptual hashes are within the provided
+// hamming distance of another member of the cluster, in order of first appearance. Files that can't be decoded are ignored
+func (f *FileSystemAgent) FindSimilar(files []models.File, distance int) ([]models.SimilarGroup, error) {
+	items, err := f.ScanSimilar(context.Background(), files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return GroupSimilar(items, distance), nil
+}
+
+// ScanSimilar returns the perceptual hashes and dimensions of the images within the provided files, reporting the
+// hashing of each image to the provided progress (if any) and stopping early if the provided context is done.
+// Files that can't be decoded are ignored
+func (f *FileSystemAgent) ScanSimilar(ctx context.Context, files []models.File, progress app.JobProgress) ([]models.SimilarItem, error) {
+	if progress == nil {
+		progress = noopJobProgress{}
+	}
+
+	var items []models.SimilarItem
+	for _, file := range files {
+		if !contains(SimilarFileExts, file.Ext) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name := file.NameWithExt()
+		progress.Processing(name)
+		item, err := f.HashImage(file)
+		if err != nil {
+			if _, ok := err.(ValidationError); ok {
+				// not a decodable image, so it can't be compared
+				progress.Processed(name, "", nil)
+				continue
+			}
+			progress.Processed(name, "", err)
+			return nil, err
+		}
+		progress.Processed(name, "", nil)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// GroupSimilar returns the clusters of the provided images whose perceptual hashes are within the provided hamming
+// distance of another member of the cluster, in order of first appearance
+func GroupSimilar(items []models.SimilarItem, distance int) []models.SimilarGroup {
+	// join items into clusters via union-find
+	parents := make([]int, len(items))
+	for idx := range parents {
+		parents[idx] = idx
+	}
+	var root func(idx int) int
+	root = func(idx int) int {
+		if parents[idx] != idx {
+			parents[idx] = root(parents[idx])
+		}
+		return parents[idx]
+	}
+	for a := range items {
+		for b := a + 1; b < len(items); b++ {
+			if HammingDistance(items[a].Hash, items[b].Hash) <= distance {
+				parents[root(b)] = root(a)
+			}
+		}
+	}
+
+	var groups []models.SimilarGroup
+	idxByRoot := make(map[int]int)
+	for idx, item := range items {
+		r := root(idx)
+		groupIdx, ok := idxByRoot[r]
+		if !ok {
+			groupIdx = len(groups)
+			idxByRoot[r] = groupIdx
+			groups = append(groups, models.SimilarGroup{ID: fmt.Sprintf("%016x", item.Hash)})
+		}
+		groups[groupIdx].Items = append(groups[groupIdx].Items, item)
+	}
+
+	var similar []models.SimilarGroup
+	for _, group := range groups {
+		if len(group.Items) < 2 {
+			continue
+		}
+		group.Best = bestSimilarItem(group.Items)
+		similar = append(similar, group)
+	}
+
+	return similar
+}
+
+// FindSimilarForSession returns the clusters of similar images within the provided session's directory
+func (f *FileSystemAgent) FindSimilarForSession(sess *models.Session, distance int) ([]models.SimilarGroup, error) {
+	files, err := f.GetFilesForSession(sess, SimilarFileExts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.FindSimilar(files, distance)
+}
+
+// SaveSimilarForSession stores the provided hashed images as those found within the provided session's directory
+func (s *SessionAgent) SaveSimilarForSession(sess *models.Session, items []models.SimilarItem) error {
+	if sess == nil {
+		return errors.New("session is nil")
+	}
+
+	val, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	return s.KeyValStore().WriteWithTTL(similarKey(sess.Token), val, sessionTTL)
+}
+
+// GetSimilarForSession returns the hashed images that were last found within the provided session's directory,
+// and whether they have been looked for at all
+func (s *SessionAgent) GetSimilarForSession(sess *models.Session) ([]models.SimilarItem, bool, error) {
+	if sess == nil {
+		return nil, false, errors.New("session is nil")
+	}
+
+	val, err := s.KeyValStore().Read(similarKey(sess.Token))
+	if err != nil {
+		if _, ok := err.(NotFoundError); ok {
+			// not looked for yet
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var items []models.SimilarItem
+	if err := json.Unmarshal(val, &items); err != nil {
+		return nil, false, fmt.Errorf("error key %s does not represent similar images: %s", similarKey(sess.Token), err)
+	}
+
+	return items, true, nil
+}
+
+// similarKey returns the key at which the images hashed by the session with the provided token are stored
+func similarKey(sessToken string) string {
+	return fmt.Sprintf("similar:%s", sessToken)
+}
+
+// ResolveSimilar keeps the file of the provided group with the provided path relative to the session's directory,
+// and moves the rest of the group into the provided session's duplicates directory. As the group may have been found
+// some time ago, images whose perceptual hashes have changed since are skipped
+func (f *FileSystemAgent) ResolveSimilar(sess *models.Session, group models.SimilarGroup, keep string) ([]models.ProcessResult, error) {
+	if sess == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	var files []models.File
+	var results []models.ProcessResult
+	for _, item := range group.Items {
+		current, err := f.HashImage(item.File)
+		if err != nil {
+			switch err.(type) {
+			case NotFoundError, ValidationError:
+				// no longer the image that was hashed
+			default:
+				return nil, err
+			}
+		}
+
+		if err == nil && current.Hash == item.Hash {
+			files = append(files, item.File)
+			continue
+		}
+		if item.File.RelativePath(sess.BaseDir) == keep {
+			return nil, ValidationError{Err: fmt.Errorf("image %s has changed since its similar images were found", keep)}
+		}
+		results = append(results, models.ProcessResult{File: item.File, Outcome: ProcessOutcomeSkipped})
+	}
+
+	moved, err := f.moveDuplicates(sess, files, keep)
+	return append(results, moved...), err
+}
+
+// bestSimilarItem returns the index of the provided item with the highest resolution, favouring the largest file on a tie
+func bestSimilarItem(items []models.SimilarItem) int {
+	best := 0
+	for idx, item := range items {
+		pixels, bestPixels := item.Width*item.Height, items[best].Width*items[best].Height
+		if pixels > bestPixels || (pixels == bestPixels && item.File.Size > items[best].File.Size) {
+			best = idx
+		}
+	}
+	return best
+}
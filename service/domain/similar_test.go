@@ -0,0 +1,241 @@
+package domain_test
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// newTestImage returns an image of the provided dimensions, drawn by the provided function of relative position
+func newTestImage(width, height int, fn func(x, y float64) uint8) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := fn(float64(x)/float64(width), float64(y)/float64(height))
+			img.Set(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+// openCountingFileSystem provides a file system that counts the files it opens
+type openCountingFileSystem struct {
+	app.FileSystem
+	opened int
+}
+
+func (o *openCountingFileSystem) Open(file models.File) (app.ReadSeekCloser, error) {
+	o.opened++
+	return o.FileSystem.Open(file)
+}
+
+func TestFileSystemAgent_FindSimilar(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	shot := func(x, y float64) uint8 {
+		if (x-0.3)*(x-0.3)+(y-0.6)*(y-0.6) < 0.04 {
+			return 250
+		}
+		return uint8(x * 200)
+	}
+	other := func(x, y float64) uint8 {
+		if int(x*4)%2 == int(y*4)%2 {
+			return 240
+		}
+		return 10
+	}
+
+	writeImage := func(name string, img image.Image) {
+		f, err := os.Create(path.Join(baseDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if path.Ext(name) == ".png" {
+			err = png.Encode(f, img)
+		} else {
+			err = jpeg.Encode(f, img, &jpeg.Options{Quality: 40})
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeImage("original.png", newTestImage(400, 300, shot))
+	writeImage("resized.jpg", newTestImage(120, 90, shot))
+	writeImage("other.jpg", newTestImage(400, 300, other))
+	if err := ioutil.WriteFile(path.Join(baseDir, "broken.jpg"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sess := &models.Session{Token: "token", BaseDir: baseDir, SubDir: "subdir"}
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	t.Run("finding similar images must cluster resized copies and keep the best resolution by default", func(t *testing.T) {
+		groups, err := fsAgent.FindSimilarForSession(sess, domain.DefaultSimilarityDistance)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+		if len(groups[0].Items) != 2 {
+			t.Fatalf("expected 2 items, got %d", len(groups[0].Items))
+		}
+
+		best := groups[0].Items[groups[0].Best]
+		if best.File.NameWithExt() != "original.png" {
+			t.Fatalf("expected original.png, got %s", best.File.NameWithExt())
+		}
+		if best.Width != 400 || best.Height != 300 {
+			t.Fatalf("expected 400x300, got %dx%d", best.Width, best.Height)
+		}
+	})
+
+	t.Run("finding similar images must not cluster images that are dissimilar", func(t *testing.T) {
+		groups, err := fsAgent.FindSimilar([]models.File{
+			models.NewFile("other", "jpg", baseDir, nil),
+			models.NewFile("original", "png", baseDir, nil),
+		}, domain.MaxSimilarityDistance/4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 0 {
+			t.Fatalf("expected 0 groups, got %d", len(groups))
+		}
+	})
+
+	t.Run("scanning for similar images must report the hashing of each image", func(t *testing.T) {
+		files, err := fsAgent.GetFilesForSession(sess, domain.SimilarFileExts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		progress := &countingJobProgress{}
+		items, err := fsAgent.ScanSimilar(context.Background(), files, progress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.processed != 4 || progress.failed != 0 {
+			t.Fatalf("expected 4 processed and 0 failed, got %d and %d", progress.processed, progress.failed)
+		}
+		if len(items) != 3 {
+			t.Fatalf("expected 3 decodable images, got %d", len(items))
+		}
+		if groups := domain.GroupSimilar(items, domain.DefaultSimilarityDistance); len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+	})
+
+	t.Run("hashing an unchanged image must take its hash from the cache", func(t *testing.T) {
+		fs := &openCountingFileSystem{FileSystem: &domain.OsFileSystem{}}
+		cachingAgent := domain.FileSystemAgent{
+			FileSystemAgentInjector: fileSystemInjector{fs: fs},
+			HashCache:               domain.NewInMemoryKeyValStore(),
+		}
+		file := models.NewFile("original", "png", baseDir, nil)
+
+		first, err := cachingAgent.HashImage(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := cachingAgent.HashImage(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fs.opened != 1 {
+			t.Fatalf("expected image to be opened once, got %d", fs.opened)
+		}
+		if diff := cmp.Diff(first, second); diff != "" {
+			t.Fatalf("want %+v, got %+v, diff: %s", first, second, diff)
+		}
+
+		// a modified image must be hashed again
+		later := time.Now().Add(time.Hour)
+		if err := os.Chtimes(file.FullPath(), later, later); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cachingAgent.HashImage(file); err != nil {
+			t.Fatal(err)
+		}
+		if fs.opened != 2 {
+			t.Fatalf("expected modified image to be opened again, got %d", fs.opened)
+		}
+	})
+
+	t.Run("resolving similar images that have since changed must skip the changed images", func(t *testing.T) {
+		groups, err := fsAgent.FindSimilarForSession(sess, domain.DefaultSimilarityDistance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 1 {
+			t.Fatalf("expected 1 group, got %d", len(groups))
+		}
+
+		writeImage("resized.jpg", newTestImage(120, 90, other))
+
+		results, err := fsAgent.ResolveSimilar(sess, groups[0], "original.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved := domain.CountMoved(results); moved != 0 {
+			t.Fatalf("expected 0 moved, got %d", moved)
+		}
+		if _, err := os.Stat(path.Join(baseDir, "resized.jpg")); err != nil {
+			t.Fatalf("expected changed image to be kept, got %v", err)
+		}
+
+		_, err = fsAgent.ResolveSimilar(sess, groups[0], "resized.jpg")
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+}
+
+func TestHammingDistance(t *testing.T) {
+	t.Run("hamming distance must count the differing bits", func(t *testing.T) {
+		testCases := []struct {
+			a, b     uint64
+			expected int
+		}{
+			{a: 0, b: 0, expected: 0},
+			{a: 0, b: 1, expected: 1},
+			{a: 0xff, b: 0x0f, expected: 4},
+			{a: 0, b: ^uint64(0), expected: 64},
+		}
+
+		for idx, tc := range testCases {
+			if actual := domain.HammingDistance(tc.a, tc.b); actual != tc.expected {
+				t.Errorf("tc %d: expected %d, got %d", idx, tc.expected, actual)
+			}
+		}
+	})
+}
+
+func TestParseSimilarityDistance(t *testing.T) {
+	t.Run("parsing a distance out of range must return a validation error", func(t *testing.T) {
+		for idx, distance := range []int{-1, 65} {
+			_, err := domain.ParseSimilarityDistance(distance)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: expected ValidationError, got %T", idx, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestFileSystemAgent_Stream(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	if err := ioutil.WriteFile(path.Join(baseDir, "video.mp4"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path.Join(baseDir, "video.mp4"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	file := models.NewFile("video", "mp4", baseDir, nil)
+	fsAgent := domain.FileSystemAgent{FileSystemAgentInjector: fileSystemInjector{fs: &domain.OsFileSystem{}}}
+
+	stream := func(t *testing.T, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/file/video.mp4", nil)
+		for key, val := range headers {
+			r.Header.Set(key, val)
+		}
+		w := httptest.NewRecorder()
+
+		if err := fsAgent.Stream(file, w, r); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	t.Run("streaming a file must provide its full contents and validators", func(t *testing.T) {
+		w := stream(t, nil)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Body.String() != "0123456789" {
+			t.Fatalf("expected 0123456789, got %s", w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "video/mp4" {
+			t.Fatalf("expected video/mp4, got %s", w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("ETag") == "" || w.Header().Get("Last-Modified") == "" {
+			t.Fatalf("expected validators, got %+v", w.Header())
+		}
+	})
+
+	t.Run("streaming a range of a file must provide only that range", func(t *testing.T) {
+		w := stream(t, map[string]string{"Range": "bytes=2-5"})
+
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("expected %d, got %d", http.StatusPartialContent, w.Code)
+		}
+		if w.Body.String() != "2345" {
+			t.Fatalf("expected 2345, got %s", w.Body.String())
+		}
+		if w.Header().Get("Content-Range") != "bytes 2-5/10" {
+			t.Fatalf("expected bytes 2-5/10, got %s", w.Header().Get("Content-Range"))
+		}
+	})
+
+	t.Run("streaming an unchanged file must respond as not modified", func(t *testing.T) {
+		etag := stream(t, nil).Header().Get("ETag")
+
+		testCases := []map[string]string{
+			{"If-None-Match": etag},
+			{"If-Modified-Since": modTime.Add(time.Minute).Format(http.TimeFormat)},
+		}
+
+		for idx, headers := range testCases {
+			w := stream(t, headers)
+			if w.Code != http.StatusNotModified {
+				t.Errorf("tc %d: expected %d, got %d", idx, http.StatusNotModified, w.Code)
+			}
+		}
+	})
+
+	t.Run("streaming a missing file must return a not found error", func(t *testing.T) {
+		err := fsAgent.Stream(models.NewFile("missing", "mp4", baseDir, nil), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if _, ok := err.(domain.NotFoundError); !ok {
+			t.Fatalf("expected NotFoundError, got %T", err)
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"imgnheap/service/app"
+	"imgnheap/service/models"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultThumbnailSize represents the thumbnail size used when none has been selected
+const DefaultThumbnailSize = "medium"
+
+// thumbnailJpegQuality represents the quality with which thumbnails are encoded
+const thumbnailJpegQuality = 80
+
+// ThumbnailSizes represents the longest edge, in pixels, of each size of thumbnail that can be generated
+var ThumbnailSizes = map[string]int{
+	"small":  160,
+	"medium": 480,
+	"large":  1024,
+}
+
+// maxDecodePixels represents the largest number of pixels that an image may have in order to be decoded,
+// so that a single enormous image cannot exhaust memory
+const maxDecodePixels = 64 * 1000 * 1000
+
+// thumbnailFileExts represents the file extensions that can be decoded in order to generate a thumbnail
+var thumbnailFileExts = SimilarFileExts
+
+// IsThumbnailable returns true if a thumbnail can be generated for the provided file, otherwise false
+func IsThumbnailable(file models.File) bool {
+	return contains(thumbnailFileExts, file.Ext)
+}
+
+// DiskThumbnailCache defines a cache of thumbnails that is persisted within a directory, and which discards
+// the least recently used thumbnails once their combined size exceeds its budget
+type DiskThumbnailCache struct {
+	app.ThumbnailCache
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+// Get implements app.ThumbnailCache.Get()
+func (d *DiskThumbnailCache) Get(key string) ([]byte, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	filePath := d.path(key)
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	// mark as recently used
+	now := time.Now()
+	if err := os.Chtimes(filePath, now, now); err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+// Put implements app.ThumbnailCache.Put()
+func (d *DiskThumbnailCache) Put(key string, data []byte) error {
+	if int64(len(data)) > d.maxBytes {
+		// would never fit, so don't bother
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(d.path(key), data, 0644); err != nil {
+		return err
+	}
+
+	return d.evict()
+}
+
+// path returns the path of the file that holds the thumbnail with the provided key
+func (d *DiskThumbnailCache) path(key string) string {
+	return path.Join(d.dir, key+".jpg")
+}
+
+// evict removes the least recently used thumbnails until the cache is within its budget, and must be called whilst locked
+func (d *DiskThumbnailCache) evict() error {
+	infos, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	var cached []os.FileInfo
+	var total int64
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".jpg") {
+			continue
+		}
+		cached = append(cached, info)
+		total += info.Size()
+	}
+
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].ModTime().Before(cached[j].ModTime())
+	})
+
+	for _, info := range cached {
+		if total <= d.maxBytes {
+			break
+		}
+		if err := os.Remove(path.Join(d.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= info.Size()
+	}
+
+	return nil
+}
+
+// NewDiskThumbnailCache returns a newly-instantiated DiskThumbnailCache persisted within the provided directory,
+// whose thumbnails may take up to the provided number of bytes
+func NewDiskThumbnailCache(dir string, maxBytes int64) *DiskThumbnailCache {
+	return &DiskThumbnailCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+	}
+}
+
+// ThumbnailAgentInjector defines the injector behaviours for our ThumbnailAgent
+type ThumbnailAgentInjector interface {
+	app.FileSystemInjector
+	app.ThumbnailCacheInjector
+}
+
+// ThumbnailAgent represents our methods for generating and caching thumbnails
+type ThumbnailAgent struct {
+	ThumbnailAgentInjector
+}
+
+// Thumbnail returns the JPEG-encoded thumbnail of the provided size for the provided file,
+// from the cache if a thumbnail of the file's current state has already been generated
+func (t *ThumbnailAgent) Thumbnail(file models.File, size string) ([]byte, error) {
+	maxEdge, ok := ThumbnailSizes[size]
+	if !ok {
+		return nil, ValidationError{Err: fmt.Errorf("invalid thumbnail size: %s", size)}
+	}
+	if !IsThumbnailable(file) {
+		return nil, ValidationError{Err: fmt.Errorf("cannot generate thumbnail for %s", file.NameWithExt())}
+	}
+
+	info, err := t.FileSystem().Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	key := thumbnailKey(file, info, size)
+	if data, ok, err := t.ThumbnailCache().Get(key); err != nil || ok {
+		return data, err
+	}
+
+	data, err := t.generate(file, maxEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.ThumbnailCache().Put(key, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// generate decodes, orients, downsizes and encodes a thumbnail for the provided file
+func (t *ThumbnailAgent) generate(file models.File, maxEdge int) ([]byte, error) {
+	r, err := t.FileSystem().Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	orientation := 1
+	if exif, err := ReadExif(r); err == nil && exif.Orientation != 0 {
+		orientation = exif.Orientation
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, err := decodeImage(r, file)
+	if err != nil {
+		return nil, err
+	}
+
+	thumb := OrientImage(ResizeImage(img, maxEdge), orientation)
+
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, thumb, &jpeg.Options{Quality: thumbnailJpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// decodeImage decodes the provided file's image from the provided reader, having first checked from its header
+// that it is small enough to decode
+func decodeImage(r io.ReadSeeker, file models.File) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, ValidationError{Err: fmt.Errorf("cannot decode %s: %s", file.NameWithExt(), err)}
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxDecodePixels {
+		return nil, ValidationError{Err: fmt.Errorf("cannot decode %s: %dx%d is too large", file.NameWithExt(), cfg.Width, cfg.Height)}
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, ValidationError{Err: fmt.Errorf("cannot decode %s: %s", file.NameWithExt(), err)}
+	}
+
+	return img, nil
+}
+
+// thumbnailKey returns the cache key of the thumbnail of the provided size for the provided file in its current state
+func thumbnailKey(file models.File, info os.FileInfo, size string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%s", file.FullPath(), info.ModTime().UnixNano(), info.Size(), size)))
+	return hex.EncodeToString(h[:])
+}
+
+// ResizeImage returns the provided image scaled down by averaging its pixels, so that its longest edge
+// is no longer than the provided number of pixels. Images that are already small enough are returned as they are
+func ResizeImage(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxEdge && srcH <= maxEdge {
+		return img
+	}
+
+	dstW, dstH := maxEdge, srcH*maxEdge/srcW
+	if srcH > srcW {
+		dstW, dstH = srcW*maxEdge/srcH, maxEdge
+	}
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0, y1 := bounds.Min.Y+y*srcH/dstH, bounds.Min.Y+(y+1)*srcH/dstH
+		for x := 0; x < dstW; x++ {
+			x0, x1 := bounds.Min.X+x*srcW/dstW, bounds.Min.X+(x+1)*srcW/dstW
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count >> 8),
+				G: uint8(g / count >> 8),
+				B: uint8(b / count >> 8),
+				A: uint8(a / count >> 8),
+			})
+		}
+	}
+
+	return dst
+}
+
+// OrientImage returns the provided image transformed so that it displays upright according to the provided
+// EXIF orientation, where 1 is already upright, 2-4 are flips and rotations by 180 degrees,
+// and 5-8 are transpositions and rotations by 90 degrees
+func OrientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			// find the source pixel that is displayed at this position
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
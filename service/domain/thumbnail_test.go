@@ -0,0 +1,207 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"imgnheap/service/app"
+	"imgnheap/service/domain"
+	"imgnheap/service/models"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thumbnailInjector provides a ThumbnailAgentInjector backed by the provided file system and cache
+type thumbnailInjector struct {
+	fs    app.FileSystem
+	cache app.ThumbnailCache
+}
+
+func (t thumbnailInjector) FileSystem() app.FileSystem { return t.fs }
+
+func (t thumbnailInjector) ThumbnailCache() app.ThumbnailCache { return t.cache }
+
+func TestResizeImage(t *testing.T) {
+	t.Run("resizing an image must fit its longest edge within the provided size", func(t *testing.T) {
+		testCases := []struct {
+			width, height                 int
+			expectedWidth, expectedHeight int
+		}{
+			{width: 800, height: 600, expectedWidth: 160, expectedHeight: 120},
+			{width: 600, height: 800, expectedWidth: 120, expectedHeight: 160},
+			{width: 100, height: 50, expectedWidth: 100, expectedHeight: 50},
+		}
+
+		for idx, tc := range testCases {
+			img := domain.ResizeImage(image.NewRGBA(image.Rect(0, 0, tc.width, tc.height)), 160)
+			if img.Bounds().Dx() != tc.expectedWidth || img.Bounds().Dy() != tc.expectedHeight {
+				t.Errorf("tc %d: expected %dx%d, got %dx%d", idx, tc.expectedWidth, tc.expectedHeight, img.Bounds().Dx(), img.Bounds().Dy())
+			}
+		}
+	})
+}
+
+func TestOrientImage(t *testing.T) {
+	// a 2x1 image with a red pixel on the left and a blue pixel on the right
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	t.Run("orienting an image must transform it to display upright", func(t *testing.T) {
+		testCases := []struct {
+			orientation    int
+			expectedPixels [][]color.RGBA
+		}{
+			{orientation: 1, expectedPixels: [][]color.RGBA{{red, blue}}},
+			{orientation: 2, expectedPixels: [][]color.RGBA{{blue, red}}},
+			{orientation: 3, expectedPixels: [][]color.RGBA{{blue, red}}},
+			{orientation: 6, expectedPixels: [][]color.RGBA{{red}, {blue}}},
+			{orientation: 8, expectedPixels: [][]color.RGBA{{blue}, {red}}},
+		}
+
+		for idx, tc := range testCases {
+			oriented := domain.OrientImage(img, tc.orientation)
+
+			if oriented.Bounds().Dy() != len(tc.expectedPixels) || oriented.Bounds().Dx() != len(tc.expectedPixels[0]) {
+				t.Errorf("tc %d: expected %dx%d, got %dx%d", idx, len(tc.expectedPixels[0]), len(tc.expectedPixels), oriented.Bounds().Dx(), oriented.Bounds().Dy())
+				continue
+			}
+			for y, row := range tc.expectedPixels {
+				for x, expected := range row {
+					if actual := color.RGBAModel.Convert(oriented.At(x, y)); actual != expected {
+						t.Errorf("tc %d: pixel %d,%d: expected %v, got %v", idx, x, y, expected, actual)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestDiskThumbnailCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := domain.NewDiskThumbnailCache(dir, 25)
+
+	t.Run("caching thumbnails beyond the budget must evict the least recently used", func(t *testing.T) {
+		for _, key := range []string{"a", "b"} {
+			if err := cache.Put(key, bytes.Repeat([]byte(key), 10)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// make sure that the modified times differ, then use "a" so that "b" is the least recently used
+		past := time.Now().Add(-time.Hour)
+		for _, key := range []string{"a", "b"} {
+			if err := os.Chtimes(path.Join(dir, key+".jpg"), past, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, ok, err := cache.Get("a"); err != nil || !ok {
+			t.Fatalf("expected a to be cached, got %t, %v", ok, err)
+		}
+
+		if err := cache.Put("c", bytes.Repeat([]byte("c"), 10)); err != nil {
+			t.Fatal(err)
+		}
+
+		for key, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+			if _, ok, err := cache.Get(key); err != nil || ok != expected {
+				t.Errorf("%s: expected cached %t, got %t, %v", key, expected, ok, err)
+			}
+		}
+	})
+}
+
+func TestThumbnailAgent_Thumbnail(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imgnheap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+
+	f, err := os.Create(path.Join(baseDir, "photo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 800, 600))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cacheDir := path.Join(baseDir, "cache")
+	thumbAgent := domain.ThumbnailAgent{ThumbnailAgentInjector: thumbnailInjector{
+		fs:    &domain.OsFileSystem{},
+		cache: domain.NewDiskThumbnailCache(cacheDir, 1024*1024),
+	}}
+
+	t.Run("generating a thumbnail must provide a downsized jpeg and cache it", func(t *testing.T) {
+		data, err := thumbAgent.Thumbnail(models.NewFile("photo", "png", baseDir, nil), "small")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != 160 || img.Bounds().Dy() != 120 {
+			t.Fatalf("expected 160x120, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+		}
+
+		cached, err := ioutil.ReadDir(cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cached) != 1 {
+			t.Fatalf("expected 1 cached thumbnail, got %d", len(cached))
+		}
+	})
+
+	t.Run("generating a thumbnail of an enormous image must return a validation error without decoding it", func(t *testing.T) {
+		// png signature and header declaring 100000x100000 pixels, without any image data
+		ihdr := make([]byte, 17)
+		copy(ihdr, "IHDR")
+		binary.BigEndian.PutUint32(ihdr[4:8], 100000)
+		binary.BigEndian.PutUint32(ihdr[8:12], 100000)
+		ihdr[12], ihdr[13] = 8, 6 // 8-bit rgba
+
+		var b bytes.Buffer
+		b.WriteString("\x89PNG\r\n\x1a\n")
+		binary.Write(&b, binary.BigEndian, uint32(len(ihdr)-4))
+		b.Write(ihdr)
+		binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+		if err := ioutil.WriteFile(path.Join(baseDir, "enormous.png"), b.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := thumbAgent.Thumbnail(models.NewFile("enormous", "png", baseDir, nil), "small")
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T: %v", err, err)
+		}
+		if !strings.Contains(err.Error(), "too large") {
+			t.Fatalf("expected error to mention too large, got %s", err)
+		}
+	})
+
+	t.Run("generating a thumbnail of an invalid size must return a validation error", func(t *testing.T) {
+		_, err := thumbAgent.Thumbnail(models.NewFile("photo", "png", baseDir, nil), "huge")
+		if _, ok := err.(domain.ValidationError); !ok {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// secretTokenBytes represents the number of random bytes from which a secret token is generated
+const secretTokenBytes = 32
+
+// NewSecretToken returns a newly-generated, hex-encoded random token that is infeasible to guess
+func NewSecretToken() (string, error) {
+	b := make([]byte, secretTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// SecretTokensMatch returns true if the provided token matches the expected token, in constant time so as not to
+// reveal how much of it matches, otherwise false. No token matches an empty expected token
+func SecretTokensMatch(expected, provided string) bool {
+	if expected == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) == 1
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFileNameBytes represents the longest file name, in bytes, that common file systems support
+const maxFileNameBytes = 255
+
+// lookalikeRunes represents characters that are commonly rendered or normalised as path separators or dots,
+// and so could disguise a name that steps outside of its directory
+var lookalikeRunes = []rune{
+	'⁄', // fraction slash
+	'∕', // division slash
+	'⧸', // big solidus
+	'／', // fullwidth solidus
+	'∖', // set minus
+	'⧵', // reverse solidus operator
+	'⧹', // big reverse solidus
+	'﹨', // small reverse solidus
+	'＼', // fullwidth reverse solidus
+	'․', // one dot leader
+	'‥', // two dot leader
+	'…', // horizontal ellipsis
+	'﹒', // small full stop
+	'．', // fullwidth full stop
+}
+
+// ValidateFileName returns a ValidationError if the provided name is not safe to use as a single file or directory name,
+// which must not be empty, refer to the current or parent directory, or contain separators, control characters,
+// invisible formatting characters or lookalikes of separators and dots
+func ValidateFileName(name string) error {
+	if err := validateFileName(name); err != nil {
+		return ValidationError{Err: fmt.Errorf("invalid file name %q: %s", name, err)}
+	}
+
+	return nil
+}
+
+// ValidateTagName returns a ValidationError if the provided tag is not safe to use as the name of a tag's directory,
+// which must be a valid file name that has no surrounding whitespace and isn't hidden
+func ValidateTagName(tag string) error {
+	err := validateFileName(tag)
+	switch {
+	case err != nil:
+	case strings.TrimSpace(tag) != tag:
+		err = fmt.Errorf("must not begin or end with whitespace")
+	case strings.HasPrefix(tag, "."):
+		err = fmt.Errorf("must not begin with a dot")
+	}
+
+	if err != nil {
+		return ValidationError{Err: fmt.Errorf("invalid tag %q: %s", tag, err)}
+	}
+
+	return nil
+}
+
+// ValidateRelativePath returns a ValidationError if the provided path is not a relative path whose every element
+// is a valid file name, once any references to the current directory have been discarded
+func ValidateRelativePath(relPath string) error {
+	if strings.HasPrefix(relPath, "/") {
+		return ValidationError{Err: fmt.Errorf("invalid file path %q: must be relative", relPath)}
+	}
+
+	for _, elem := range strings.Split(relPath, "/") {
+		if elem == "." {
+			continue
+		}
+		if err := validateFileName(elem); err != nil {
+			return ValidationError{Err: fmt.Errorf("invalid file path %q: %s", relPath, err)}
+		}
+	}
+
+	return nil
+}
+
+// validateFileName returns an error describing why the provided name is not a valid file name, if it isn't
+func validateFileName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("must not be empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("must not refer to a directory")
+	case len(name) > maxFileNameBytes:
+		return fmt.Errorf("must not be longer than %d bytes", maxFileNameBytes)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("must be valid UTF-8")
+	}
+
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '\\':
+			return fmt.Errorf("must not contain a path separator")
+		case unicode.IsControl(r):
+			return fmt.Errorf("must not contain control characters")
+		case unicode.Is(unicode.Cf, r):
+			return fmt.Errorf("must not contain invisible formatting characters")
+		case containsRune(lookalikeRunes, r):
+			return fmt.Errorf("must not contain %q, which resembles a path separator or dot", r)
+		}
+	}
+
+	return nil
+}
+
+// containsRune returns true if the provided needle exists within the provided haystack, otherwise false
+func containsRune(haystack []rune, needle rune) bool {
+	for _, r := range haystack {
+		if r == needle {
+			return true
+		}
+	}
+
+	return false
+}
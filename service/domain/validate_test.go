@@ -0,0 +1,106 @@
+package domain_test
+
+import (
+	"imgnheap/service/domain"
+	"strings"
+	"testing"
+)
+
+func TestValidateFileName(t *testing.T) {
+	t.Run("validating a safe file name must succeed", func(t *testing.T) {
+		for idx, name := range []string{
+			"IMG_0001.jpg",
+			"holiday photo (1).JPG",
+			"..hidden",
+			"a..b.jpg",
+			"café.jpg",
+			"写真.png",
+			"😀.jpg",
+			strings.Repeat("a", 255),
+		} {
+			if err := domain.ValidateFileName(name); err != nil {
+				t.Errorf("tc %d: expected nil, got %s", idx, err)
+			}
+		}
+	})
+
+	t.Run("validating an unsafe file name must return a validation error", func(t *testing.T) {
+		for idx, name := range []string{
+			"",
+			".",
+			"..",
+			"../secret.jpg",
+			"dir/file.jpg",
+			"/etc/passwd",
+			`..\secret.jpg`,
+			"C:\\Windows",
+			"file.jpg\x00.png",
+			"file\n.jpg",
+			"file\x7f.jpg",
+			"\xff\xfe.jpg",
+			"..／secret.jpg",
+			"..∕secret.jpg",
+			"..⁄secret.jpg",
+			"..＼secret.jpg",
+			"．．",
+			"‥",
+			"gpj.\u202eexe",
+			"file\u200b.jpg",
+			"\ufeff.jpg",
+			strings.Repeat("a", 256),
+		} {
+			err := domain.ValidateFileName(name)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: %q: expected ValidationError, got %T", idx, name, err)
+			}
+		}
+	})
+}
+
+func TestValidateTagName(t *testing.T) {
+	t.Run("validating a safe tag must succeed", func(t *testing.T) {
+		for idx, tag := range []string{"holiday", "Summer 2019", "bébé", "a.b"} {
+			if err := domain.ValidateTagName(tag); err != nil {
+				t.Errorf("tc %d: expected nil, got %s", idx, err)
+			}
+		}
+	})
+
+	t.Run("validating an unsafe tag must return a validation error", func(t *testing.T) {
+		for idx, tag := range []string{"", "..", "../../etc", "hello/world", ".hidden", " padded ", "tab\t", "nul\x00", "．．"} {
+			err := domain.ValidateTagName(tag)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: %q: expected ValidationError, got %T", idx, tag, err)
+			}
+		}
+	})
+}
+
+func TestValidateRelativePath(t *testing.T) {
+	t.Run("validating a safe relative path must succeed", func(t *testing.T) {
+		for idx, relPath := range []string{"a.jpg", "DCIM/100APPLE/IMG_0001.jpg", "./a.jpg", "DCIM/./a.jpg"} {
+			if err := domain.ValidateRelativePath(relPath); err != nil {
+				t.Errorf("tc %d: expected nil, got %s", idx, err)
+			}
+		}
+	})
+
+	t.Run("validating an unsafe relative path must return a validation error", func(t *testing.T) {
+		for idx, relPath := range []string{
+			"",
+			"/etc/passwd",
+			"../a.jpg",
+			"DCIM/../../a.jpg",
+			"DCIM//a.jpg",
+			"DCIM/",
+			"DCIM/\x00/a.jpg",
+			"DCIM/．．/a.jpg",
+			`DCIM\..\a.jpg`,
+		} {
+			err := domain.ValidateRelativePath(relPath)
+			if _, ok := err.(domain.ValidationError); !ok {
+				t.Errorf("tc %d: %q: expected ValidationError, got %T", idx, relPath, err)
+			}
+		}
+	})
+}
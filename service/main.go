@@ -1,15 +1,22 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"html/template"
 	"imgnheap/service/app"
 	"imgnheap/service/app/handlers"
 	"imgnheap/service/domain"
+	"imgnheap/service/models"
 	"imgnheap/service/views"
+	"io"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -17,30 +24,202 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	rand.Seed(time.Now().UnixNano())
 
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// serveCommand runs the web server with the config described by the provided flags until it fails,
+// or until it is interrupted, in which case it shuts down gracefully
+func serveCommand(args []string) int {
+	cfg, err := domain.LoadConfig(flag.NewFlagSet("serve", flag.ContinueOnError), args, os.Environ())
+	if err != nil {
+		return exitCodeFromError(err)
+	}
+
+	// catalog the configured extensions throughout
+	domain.ImgFileExts = cfg.Extensions
+
+	store, stopSweeper := mustNewKeyValStore(cfg)
+	restricted := mustNewRestrictedFileSystem(cfg.Roots, cfg.VerifyCopies)
+	fs := domain.NewDrainableFileSystem(restricted)
+	jobs := domain.NewInMemoryJobRunner()
+
 	c := container{
 		templates: views.MustParseTemplates(),
-		store:     domain.NewInMemoryKeyValStore(),
-		fs:        &domain.OsFileSystem{},
+		store:     store,
+		fs:        fs,
+		patterns:  mustNewTimestampPatternRegistry(cfg.PatternsPath),
+		jobs:      jobs,
+		thumbs:    domain.NewDiskThumbnailCache(cfg.ThumbCacheDir, cfg.ThumbCacheSize*1024*1024),
+		roots:     cfg.Roots,
+		access:    mustNewAccessToken(cfg.RequireAccessToken),
+		config:    cfg,
 	}
 
-	port := 8080
 	router := handlers.RegisterRouter(c)
 
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
+		Addr:         cfg.ListenAddr,
 		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	log.Printf("listening on %s...\n", cfg.ListenAddr)
+	if c.access != "" {
+		log.Printf("access token required, open %s/?access_token=%s\n", localURL(cfg.ListenAddr), c.access)
+	}
+
+	select {
+	case err := <-serveErr:
+		log.Println(err)
+		return exitFailure
+	case sig := <-signals:
+		// a second signal exits straight away
+		signal.Stop(signals)
+		log.Printf("received %s, shutting down within %s (repeat to exit straight away)...\n", sig, cfg.ShutdownTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	code := exitOK
+
+	// stop accepting requests whilst running jobs finish their current file
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(ctx)
+	}()
+	if err := jobs.Shutdown(ctx); err != nil {
+		log.Printf("cannot finish running jobs: %s\n", err)
+		code = exitFailure
+	}
+	if err := <-shutdownErr; err != nil {
+		log.Printf("cannot finish requests: %s\n", err)
+		code = exitFailure
+	}
+
+	// let file operations in progress finish, otherwise abandon moves so that they keep their originals
+	if err := fs.Drain(ctx); err != nil {
+		log.Printf("cannot finish file operations, unfinished moves have kept their originals: %s\n", err)
+		code = exitFailure
+	}
+
+	// remove the temporary files of any copies that were cut short, bypassing the drained file system
+	sweeper := domain.FileSystemAgent{FileSystemAgentInjector: container{fs: restricted}}
+	for _, dir := range fs.DestinationDirs() {
+		if _, err := sweeper.RemoveTempFiles(dir, time.Now()); err != nil {
+			log.Printf("cannot remove temporary files: %s\n", err)
+			code = exitFailure
+		}
+	}
+
+	stopSweeper()
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("cannot flush session store: %s\n", err)
+			code = exitFailure
+		}
+	}
+
+	log.Println("shut down")
+	return code
+}
+
+// localURL returns the base URL at which the server listening on the provided address can be opened locally
+func localURL(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil || host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// mustNewTimestampPatternRegistry returns a registry of the patterns held by the provided file path (if any)
+// followed by the default patterns, otherwise fails on error
+func mustNewTimestampPatternRegistry(patternsPath string) app.TimestampPatternRegistry {
+	var patterns []domain.TimestampPattern
+
+	if patternsPath != "" {
+		filePatterns, err := domain.LoadTimestampPatternsFromFile(patternsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		patterns = append(patterns, filePatterns...)
 	}
 
-	log.Printf("listening on port %d...\n", port)
-	log.Fatal(server.ListenAndServe())
+	registry, err := domain.NewInMemoryTimestampPatternRegistry(append(patterns, domain.DefaultTimestampPatterns()...)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return registry
+}
+
+// mustNewRestrictedFileSystem returns a file system that only permits operations within the provided roots,
+// and verifies the checksum of each copy if required, otherwise fails on error
+func mustNewRestrictedFileSystem(roots []string, verifyCopies bool) app.FileSystem {
+	if len(roots) == 0 {
+		log.Fatal("at least one root directory is required")
+	}
+
+	fs, err := domain.NewRestrictedFileSystem(&domain.OsFileSystem{VerifyChecksum: verifyCopies}, roots)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return fs
+}
+
+// mustNewAccessToken returns a newly-generated access token if one is required, otherwise an empty string,
+// and fails on error
+func mustNewAccessToken(required bool) string {
+	if !required {
+		return ""
+	}
+
+	token, err := domain.NewSecretToken()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return token
+}
+
+// mustNewKeyValStore returns a store of the configured backend, whose expired values are swept in the background
+// until the returned function is called, otherwise fails on error
+func mustNewKeyValStore(cfg models.Config) (app.KeyValStore, func()) {
+	if cfg.Store == models.StoreBackendMemory {
+		store := domain.NewInMemoryKeyValStore()
+		return store, domain.StartKeyValSweeper(store, domain.DefaultKeyValSweepInterval)
+	}
+
+	store, err := domain.NewFileKeyValStore(cfg.StorePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return store, domain.StartKeyValSweeper(store, domain.DefaultKeyValSweepInterval)
 }
 
 type container struct {
 	templates *template.Template
 	store     app.KeyValStore
 	fs        app.FileSystem
+	patterns  app.TimestampPatternRegistry
+	jobs      app.JobRunner
+	thumbs    app.ThumbnailCache
+	roots     []string
+	access    string
+	config    models.Config
 }
 
 func (c container) Templates() *template.Template {
@@ -54,3 +233,27 @@ func (c container) KeyValStore() app.KeyValStore {
 func (c container) FileSystem() app.FileSystem {
 	return c.fs
 }
+
+func (c container) TimestampPatternRegistry() app.TimestampPatternRegistry {
+	return c.patterns
+}
+
+func (c container) JobRunner() app.JobRunner {
+	return c.jobs
+}
+
+func (c container) ThumbnailCache() app.ThumbnailCache {
+	return c.thumbs
+}
+
+func (c container) Roots() []string {
+	return c.roots
+}
+
+func (c container) AccessToken() string {
+	return c.access
+}
+
+func (c container) Config() models.Config {
+	return c.config
+}
@@ -3,14 +3,31 @@ package models
 import (
 	"fmt"
 	"path"
+	"strings"
 	"time"
 )
 
 // Session defines a basic session
 type Session struct {
-	Token   string
-	BaseDir string
-	SubDir  string
+	Token     string
+	CSRFToken string
+	BaseDir   string
+	SubDir    string
+	Scan      ScanOptions
+}
+
+// ScanOptions represents how a session's directory is scanned for files
+type ScanOptions struct {
+	Recursive bool
+	MaxDepth  int
+	Excludes  []string
+}
+
+// SessionRun represents the output directory of a previous session within a session's base directory
+type SessionRun struct {
+	SubDir    string
+	StartedAt time.Time
+	FileCount int
 }
 
 // FullDir returns the full directory stored by the Session
@@ -24,6 +41,7 @@ type File struct {
 	Ext       string
 	DirPath   string
 	CreatedAt time.Time
+	Size      int64
 }
 
 // NameWithExt returns the filename and extension of the associated file
@@ -36,6 +54,18 @@ func (f File) FullPath() string {
 	return path.Join(f.DirPath, f.NameWithExt())
 }
 
+// RelativePath returns the path of the associated file relative to the provided base directory
+func (f File) RelativePath(baseDir string) string {
+	dirPath := path.Clean(f.DirPath)
+	baseDir = path.Clean(baseDir)
+
+	if dirPath == baseDir {
+		return f.NameWithExt()
+	}
+
+	return strings.TrimPrefix(f.FullPath(), baseDir+"/")
+}
+
 // NewFile returns a new file object from the provided field values
 func NewFile(name, ext, directory string, createdAt *time.Time) File {
 	file := File{
@@ -49,6 +79,55 @@ func NewFile(name, ext, directory string, createdAt *time.Time) File {
 	return file
 }
 
+// FileMetadata represents the metadata resolved for a single file
+type FileMetadata struct {
+	Timestamp        time.Time
+	TimestampSource  string
+	TimestampPattern string
+	CameraModel      string
+}
+
+// DirLayoutPreset represents a named layout that can be selected for processing files by date
+type DirLayoutPreset struct {
+	Name     string
+	Template string
+}
+
+// CollisionPolicyOption represents a named policy that can be selected for handling name collisions
+type CollisionPolicyOption struct {
+	Name   string
+	Policy string
+}
+
+// ProcessResult represents the outcome of processing a single file
+type ProcessResult struct {
+	File        File
+	Destination File
+	Outcome     string
+}
+
+// DuplicateGroup represents a set of files whose contents are identical
+type DuplicateGroup struct {
+	Checksum string
+	Size     int64
+	Files    []File
+}
+
+// SimilarItem represents a single image and its perceptual hash
+type SimilarItem struct {
+	File   File
+	Hash   uint64
+	Width  int
+	Height int
+}
+
+// SimilarGroup represents a set of images whose perceptual hashes are similar
+type SimilarGroup struct {
+	ID    string
+	Items []SimilarItem
+	Best  int
+}
+
 // Directory represents a single directory
 type Directory struct {
 	Name      string
@@ -60,3 +139,136 @@ type Directory struct {
 func (d Directory) FullPath() string {
 	return path.Join(d.DirPath, d.Name)
 }
+
+// DirectoryListing represents the directories that can be browsed from a single directory, or from the browsable roots
+// if the directory's path is empty
+type DirectoryListing struct {
+	Path        string
+	Parent      string
+	FileCount   int
+	Directories []Directory
+}
+
+// Plan represents a set of file operations that have been computed ahead of being executed
+type Plan struct {
+	ID              string
+	SessionToken    string
+	Layout          string
+	CollisionPolicy string
+	Items           []PlanItem
+	Duplicates      []File
+	Executed        bool
+}
+
+// PlanItem represents a single file operation within a plan
+type PlanItem struct {
+	File     File
+	Metadata FileMetadata
+	DestDir  string
+}
+
+// PlanGroup represents the items of a plan that share a destination directory
+type PlanGroup struct {
+	DestDir string
+	Items   []PlanItem
+}
+
+// GroupByDestDir returns the items of the plan grouped by destination directory, in order of first appearance
+func (p Plan) GroupByDestDir() []PlanGroup {
+	var groups []PlanGroup
+	idxByDestDir := make(map[string]int)
+
+	for _, item := range p.Items {
+		idx, ok := idxByDestDir[item.DestDir]
+		if !ok {
+			idx = len(groups)
+			idxByDestDir[item.DestDir] = idx
+			groups = append(groups, PlanGroup{DestDir: item.DestDir})
+		}
+		groups[idx].Items = append(groups[idx].Items, item)
+	}
+
+	return groups
+}
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job represents the progress of a long-running operation
+type Job struct {
+	ID           string
+	SessionToken string `json:"-"`
+	Name         string
+	Status       string
+	Total        int
+	Done         int
+	Failed       int
+	Current      string
+	ResultPath   string
+	Outcomes     map[string]int
+	Notices      []string
+	Errors       []string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+}
+
+// IsFinished returns true if the job is no longer running, otherwise false
+func (j Job) IsFinished() bool {
+	return j.Status != JobStatusRunning
+}
+
+// Percent returns the proportion of the job's items that have been processed, as a percentage
+func (j Job) Percent() int {
+	if j.Total == 0 {
+		return 100
+	}
+	return j.Done * 100 / j.Total
+}
+
+const (
+	JournalOperationCopy = "copy"
+	JournalOperationMove = "move"
+	// JournalOperationRemove represents the removal of a file that was identical to its destination, which was kept
+	JournalOperationRemove = "remove"
+	JournalOperationUndo   = "undo"
+)
+
+// JournalEntry represents a single recorded file operation
+type JournalEntry struct {
+	ID          string    `json:"id"`
+	Operation   string    `json:"operation"`
+	Source      string    `json:"source,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	Overwrote   bool      `json:"overwrote,omitempty"`
+	UndoneID    string    `json:"undone_id,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+const (
+	StoreBackendMemory = "memory"
+	StoreBackendFile   = "file"
+)
+
+// Config represents the settings with which imgnheap is served
+type Config struct {
+	ListenAddr         string        `yaml:"listen_addr"`
+	ReadTimeout        time.Duration `yaml:"read_timeout"`
+	WriteTimeout       time.Duration `yaml:"write_timeout"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout"`
+	Roots              []string      `yaml:"roots"`
+	Extensions         []string      `yaml:"extensions"`
+	Layout             string        `yaml:"layout"`
+	CollisionPolicy    string        `yaml:"collision"`
+	Store              string        `yaml:"store"`
+	StorePath          string        `yaml:"store_path"`
+	PatternsPath       string        `yaml:"patterns"`
+	ThumbCacheDir      string        `yaml:"thumb_cache_dir"`
+	ThumbCacheSize     int64         `yaml:"thumb_cache_size"`
+	VerifyCopies       bool          `yaml:"verify_copies"`
+	RequireAccessToken bool          `yaml:"require_access_token"`
+}
@@ -9,4 +9,4 @@ import (
 	"github.com/markbates/pkger/pkging/mem"
 )
 
-var _ = pkger.Apply(mem.UnmarshalEmbed([]byte(`1f8b08000000000000ffec7c6b8fa2caf6f7573921cfbb71a6ab0a50cae4bc106d118fda3676733b39d9e1d68016601ac4cbce7cf72785771bd4993d7372fed93d938e8aab2eeb52bf5a6bd52aff64c2f82d4999e69f4c18f971e05973fabe13be334de6fff59e868f0fedc4f51e5aae15f527cec381a8c6c8d13c79cfc65616304de6e4f9c88a3ca6c94456183335a693384c93616acc8bf5ee7b19ed557a1ab75e7a0f76189f76a724497673cca1953901d3fc37f38df94f8d996416f19866f6bef0761f14cf4a93986932f62224ee3fe4ce3fa2308d8a4635464aba21f152da7c3ef3bdf76f7e427bd9f29132cd7841488de978f382248c33ef3db6c883bdce3c8bf84cedf8c8992f981af3be88b330f28eef1e0e04569644a153f64d44e555f23c5da74c8d59c4a9f5e6d159bd78697698f07666f4d1c5648789bba022f893f9a8872155c04e38f7e8534a86897b9deac14fbe45895b10abde7b1a16b286df20c77cfffebdc6bc6da77bb4a5e643eabde7a1e33de4a1b74c1f822c22c5f7f15b425f5d2fb342523489b7665350d49834dc784c13f1f51a13252e7d0bb90627709083c5933f0ac937190410f80ae157085e60bd096013816f2c64058ec7f5ba497596fee152e6b772a052a6e6ede54c13d61b8d0642a090a2473f0b8d468d1991309e314da1c6c871c23421cb3658b6ced598d7d0659a751670acc035708d91e86781432c270001d518c5a5bd821a33b6dc3f1c3ff90330cd7f835af1ff3f35a645679cce3d874e60425f601df0007140606bcc28a54fea88431ca8e3ef3566584d0ef6e40746bfd798f60fd28be17b1694b581b801303c4c0901aed16059b6f1bdc64c8e4a1149e2ccd2825d91ccb6dae200aed7982eb1fced17921717af83746ebd7bc5dbe7dd5b2a97ff14261378ef9f80f309383f033835665ecce14f663cf34b4da20c7dbed718d7caac3d43d41ee3ecd8c9b17131c235207b70accc2289ffd55e7fcd2cffdb6d702b6b70c03a4017dd16ec388a4ab751ae81588187a728f76691f406ccb12c148403ccc103cc093c1238fcbb604e686001ee3185e7308410b1f5fb716ecbeafd3877a0afc439c4091c0087365080a0f877c4b942217ba013fe5b405766b317d877b4d1ddb71f31ef886f4710db1afc0ec37696720e62a798b5a52ec5aa233afc0d00a374951f4084f1d86c666ae6dc8e5e7d391a05aed44d6de464463ccb5ca91bcb31c4edb0e5cb6db1e1b10ab17531357485c8edfed2d0fbc0d2bae9200a88a129c409657f70a06f09a644902c8d52431f6dc693fed4463c30351ec852c93853ee5f72bb68b71d0b91486ef31d1bc1a52da9c0d2f0e24553370eeac6a67e18e3f8d7cb1aaea4668eb40a5ce9d59763317062656e68cb8581307d4e5c89e4369de36c94533e4c5d99db881f99fa6863682e79d3c1c51cd4d4412a7e7b4ecec76ab704a72d4e6da9bb71362034229c9a6df98bc7a60b4522eb57a90bac1ec4e3365e8efd92b61dbee1adf9bead7563f3d59cdb92ba794618dab182df26e2bc783e11234b5b917ff58673595a11337ef65d49f09d5e3f3790ba71d6dc62305de64ee7546edbbf71afe51b681538ec10cb513fb7a5e7701c678dc18c64944f2522a9f9ca07b6a6e2b7978a3e247f359eb973377af52dfdd93726223034673108396180fc55195f17faa6f49981f0c295ba733b52d7e73a3ef9ebac66966e1ce71c2b81b986ac1375978ea4ae071a86ae74a5fd5e5f6b657e22d327438344ee24586e9fc9ba787eaeeb92bed87e60472e91db0ae52395dbcad466d585db6ed5c793d64295bab103ddb92b059de279afc4267f90cfebfc9df513d96c3f9325485c29c8cd0e081d096fdcb6fce5e61cce744c164e4f05724fe11de915cb5130372585d0356247ddcc7c01a1496d45c58b4296a1880c6d05e9738f4d6796060313a912b5d3174aaf432c87adbcc436aef132b76311ba6d1178ba48c6937e6269caccd4b85096a88dbeeee424fb6ed44ddde273d6e8f794c0647f6abcd0d5156023ce777b644979715835b43502e4f0644db34a204fe93aa5fa77e9bab429cfc67e9daa4018a03e747b4a6edfadb7656e46786dbfdca61fb731b595abfaf4d88cd89152864ff7f451ba5e5db61f383d9138a13875f511b01108afcaf7afd8e23dba8f8a35fb56d8e3e4dc064af16c22fb83cd1df29594b97ba26f4be397aefe7c8e573d8578bde7ebfc5f5b4ff1b67da51d87b24ff72b03a9898d56335397b13c1b418755727bc22d06e19dbcdc612ba5f8136de73bd046b91d2b81a5f154effbf5f1037cefd64178d0e3c6d5fa99a553de8ffbbf2b3d8663a204e666991bb10a5c092fee1843182065ee5e5f63c20099b913814a5bafeaa352761f6555d8dab9ac2a6d5c28f64bbd1f9bfa3396090e6da9bbb0d6b26ff5fac49c827080a85dbfe6d4679894e1e80d7e2d0dc6726fb436367fa1afce32374ab0a84c269758734e73cd3734739b3df10de3ac616afc8ceebfccaf095f232f0b12f76bea11cfc9c224fe8140b6bce93ea41584faf588167c85e805349aa8dee4c0372840c8018cbf00d804e0d744b55c83e3d06f4ade15112477886aeb8063311660a32aaafd187116ec82aaa8f60a7d75540b20acf3c73690bd886a0b9d7c06b59f41edb5057c3bbcb5351558129e0df411b12575ba0d0dfb0778f3d80c981a0d3dbbc09cb44247eaae5d8904769b4f4cad4bb7ea506ef34738bc7027b66e040d17c4a9a12b812de178a0ed5ca436dc98da8a182c0d3f2fb6d95ed6b034c33f736df4517588d1592d4fb6d890ba115bb7957fb4f4feb3a12bc99b0e8401db2adbe296e39999bb1a3fa3e3568d294bdbe7b2546c3389b39e9d87a29b6b216490bb6b91b5593535db226fb3afbe2d91777322027b7d948ddc23b9abcbfe2ea4d88c37cbdcea3c96cdb9caddf40d6d54a409c6133937501718da2a37d738f42670566c4bd5e1e78f84046d6f223e16fd4964213f16ee69111e8ddb78df4fc9bcab5dffb1f478e2161c6c343fa446cae7d0d9b9e77e118a6e2eb7f2ebe98b0bd79f38713f77e2a1bf4f6554b935bbd4c56ddb3ad23fd9eb5d4a431ffa6684a11d5da6325af57f75ca6de860a7444c4dadbb3127e26eed3cfb321951170bc88fddd8d0c8420ec580f6edb022311089bc8918d8f4594f214ec407ae449039116796dea76b2f77e259c5ba58e6543e97eb72efb694d15cc18bc846181cf1624ef5b230e95af6fff9cfbfe80279efefc9fb1dfece09dd215fcf71b79c1b20bc20d4e4eb4d96fbd68040801073bfd8bb8100b0bfc9bb819807e8700e085916400e63b6d2bb81988398dbd31ff8adf46eaae97fdebb2994f2e9ddfcddbd9b93e55ae5caa86b27c2ebdf939ddff57dbe5d0a743b2e32dc7a7f6db3f2e219e1198d2ec76d9c0c5f2e69e533dad3cc7d417fd1f76e5be916db50c83f9a3a8db057e7d9e9ff0dc80d63d75bdd01b927747bc8e5f08d13d24bc8e501571700fb2b21976db0acf09b8e493f422e04758484bb2177c7efdd907ba4bf5e0e821a8772109e872c842c7780dc42299f90fb7787dc93e55a05b964614a2af77b20b788ac325717531b756765d0aba28038d1ab6fe87d72192dc9bd11b0d93e313bc2c7c3b922ca8381855e7d8755d7e6449cbb6d7723f794c49c8881118f72bba70273222ea9472ff7947cf7ddd16b97c8dad48a317859c28bfdf8b684a786b6f46dad3bb524b23027dc078f7a2c1589597f1f718f27fda5cd8e686415ec22602c874259b259703a9cb04bcc1f22335752b922da3b24668bfeb789fb5e3fb7db278757a7f30e5bf9b8fac0f4cb0f25b1677d6222b2388df83e1cf65e467cff1bdbd7dc7acf42ebc6deb527da6f5cb00eeead63842f103639bec9c16f184016b102467fa58e913fd9b978041abfabc007f30593db5d026316f3026ca0ea021fccc363eaf4c06975814f25fdb59d0b35d0614e886d0818d48ba2a0c9512b9f858c9f858cff3777dc3dc8fcc282c65d970f4e12cd8977ef59d005f1214102f99bf58ce768875083c5f8d7d4337210f20d8c7e27dc1de088432c063cf70368b7e5f47eb43bd057a746a86fcf1efc7416e0cbd40855c7a79ffe77f7d3ab967885d35e5905581cf59438e4c76a4359da55cef9e5473cae44a6d65abe70762fab4a46b91dca5fc6d2ecd4610d64c90c06da2831b5d1fb401bcd9d68949a13993a9173eaa49e3a8a17a7f19773cef6d58bbb639f2267b26b7fa87a3839bdff450ee4c35b9264de3db9e753c23db66270f3601dbf00a189709305df50bdce0a1caaffda3c080f11ff7be0950518f10d783cf8c6bc00049eabc2d72dfdd1d3dbb15b79b07e85feaa375907c7dc094617f88ac127bc7ec26bd9eabe0dad6684f3a240372e3ba12e394deb7c80b8d0460abf85ac00d8daf2cb2f2c14da331578967b17649d12ee218b45a8f123fea08041a35ec7f55fe40f16800585ff923fc8f102dba8c4ab0bffeec0e99dfee029fdbd7855073c6205c0b307bcdaeae313b13e11ebe3e2be8d5896a406e61eb1a6cb4079c41db54b9e958998b8124c2912593d25b3db626a687c3c9ef4897d2cf7148af6e79944c1d654604cc4a92575d70e52c178d257d547237bbaa8a718f794b92bad487125836cdfbfe9c07f6b8b7d5b738bb9399d65ee4a04d8d2eb8583b99adb51ea3b919a1619ca5e51ab93989a0ae4504c9c488dc6937ee2f694a5b349f2013b0286367a77a42eb0da7c68230c1c56591bba383525ae708a07c8ccedf839337497380867e6440083a9b318b6f1d46187b919e185db86818bd48d8d201944706e873cfd2e94dbc2056f23e0692bf2319bbb95abdc4bcb6b3ca4ed1ccca89b59da8a7f0ac557430b725b7b4ce5c7eebac8aab745d9d456c8d495b9811e1be535276264231e0cf4d1dc8b5eeb72e791737a7e15edd446abdc9926bebc194d471d3332a71fe757523fb3b0591598baec7bcbf2f2651791992bf974fce5b0fd5a35fed2d09499a5f1f153d85a0f7b22f754d55f91f586812d91d89e26be81d405dd71abe86dadbb3611593c85ada52c75a12b091fe7d0fb780566108da80d57cd97b53405589dc41fbeb496fd97ebba7410a99b2f893f9c706b2752b3aab91a51776a21776db3eac2ec5cd7c5b6ff516e4b78fd14b6a666644655fd3a5277664a64616e127fd8e6e0f539e035c5032aaf5b3403bd1f981281ce26f1477ab5ce8c08735b3bdccee1d05eebe75ea78a371838913ba73a1eb6c5c0d5955ceeb41623bd4fec127997d9a6c12ac15314e46e7185a15286d0894785ed3b129edbb1429cbb6c5f0c6ed80830f5000c34851808af8de28a1d5797253eb7a3ca7530b3f4d1d296ba74ddcfed8862dc6b6644abdc40e95d76bb3b255a14272e13589cac3911aec69b9e3b377b4af214b656c34e8b54e970877d1b4b9f134a7bc3867e42dffddc89a8ec137f188b9cdca3b64d66727bb83136cfc88c8c2a998536db9f99ba9c39517766e9eae6296cc12b58778a35cbc1545d9b1ab817134eaf87dcc486d1a6b5bc328fccd0fbb1a57175b9d3f20d5d05f6a64a3618997a3fb225cc3e85e2fe6ad03dfc95cba19a3762c7cf0b87550287ee839a1b581a7707b6cbcb72cc164bae02f2871acbaa7e4f3068e3f47cdf4138b5b4675f468f9b61a5fd1cb1d078195ec1ae0b8c4566f4a419fca844f6a59852d48556ea7eb7e7bac4d2dcc4ed24db3ad2923997f5bd3dc57ebdb87a75974d14f878650fe406fa7debbca0d582c3fc479d0a1b2eb5a1b2ab41e2dcd69c9ff70fcac6b9bc2e5a292365eeb0626ae8b3ba2c91855d9cccc3d096f0d4ba53dfaed48d07ba4bfdc4a5a9cb0b835537ae8433b9cdefaeec6d31d6d0c8e29a1f644a64e348abc07b492846a7543603ad9fda68f47ee7bab9b896c8efaf2552b9975c5fe38bfde70edc47a3c9cfc97d10edafe851dbfd786d70b0bdf6b7df93feaa8f78e1cf76cb71fb2306ae2836518c1dbe884bafdd0277dbf38ff21b1d2a276efb8d9bb23558aaf7f3647cb51f73c0bfa1f4c80f3badcdbd7bf90fef81879f0ce0a88ce6064aa90d4ee9e74afb3f1f1394fb0fe7fc8fdb781bd79d548e9cc59b9dd53e5b76ece3c375c5ea2bf3e577556e5cd1dfdf4b21c595f71757eaaedd5ddd7de555fa0f733aecb5fec55efbb3578ccfee7c3891ba31f5bbaeeb0afbeba7c7abba236844707e5e5944fd5cf9cbeb76aefef376aef75efbfee92ba97b79efb39fbff2f0e63d71bc34f5dcaff6faab6b65de1dc9d08a368763f25b69d1fd590ecb3541fd5b1d3410e459f657de23e020acd721fc6d87392c16ea87dc281020d7c0f8ea618ec0d58f89cb1dbfd70e732ae82b93a32ce0eb3c7b3c006a089787e59fb9d1cfdc68e572af4c8fae6d34220e3b22661b1eafcdfd8e82d75ec55897bf025439ded9761cdef14b41e7707be5d7820e8759616bf1a3f0bb5d2cde6721dd6721dd4ffd04e9ff070000ffff010000ffffbe8a2121cf560000`)))
+var _ = pkger.Apply(mem.UnmarshalEmbed([]byte(`1f8b08000000000000ffec7d5993a2da96ff57e9f0f5d439c520999211fd209832a492262a5347c70d061350066f3a7be37ef77faccd20282856659daeee7f3e704e256e600f6baf69ffd65aff6af9d17bbc6a3dfdabe5876ee4cdcc25fcbbe77fb49e5adf3fe278fd3d8c9d4d306b7d6b09e132fe588fccb5d77a3ab5fed692cc70d67a6a85a61fb5beb57ab1dd7a6ab5beb526e6873b5be7af71e3ef961f7d2f3c27c7f1faf22b43736d7bada7ff6afdd5faef6fadf1da0c66ada7f5c76696fe21cfcc551cb59e5ad6c60f9cff107aff11faab103df4adc5c57d3f98ade0f1e5c29d7dfce5c6f096a4e7abd653b409826fadde6c899af8d17af61199c177d3f25bdf0a7faecca8f8b77558cfccc03dbf157f38b38fe24ddb336dcfec7c989153ba1d6f671fa63bfbfeb1b6e36de997e5a6f8a71b9b1fb657bee3ccac8dbb2adf9bed97b30f3f9c45ebf2fdb8d42e3c1bc5f2237ef783d9c7cc8e3f4afdfb30ed59e9ef4db4f6c3d977731d87be5df58bed7ec49b65d52fb3bdbff6e27851f59b5bf92ed7febeb2cda8eaa7d05caeaaefafbdaafb4b18e2f7c0b46641d5cfab43e5db5687956d06c1f7c08f36fb6283d5fac38ea3d282add61f7ee4ae027f5d9ab1354c61f2df2dd1fad60acdb5f7ddf2d7f0bdf433ad6fad4db432df67408f93d96a9d936a429370eb8c4c87c9c67bfa57eb72cf0d61b3a5dba272b372f13076ce6e7f77e3bfc218d69e8b95d9c7ca47fb08ff0b6fb7fefdef7f7f6b01799458c1d3f7d5ec63ebdbb3ef5b7fb65b7df7d661807e8fde63f8bf335b9b7e801e891226805a7c6badfce3acf5d4c6e8876fad307666ad27026f3fb63b6d9c7a4477fe0153df7a6a1118f1f0278efd893f4ef0ce13893d610f7f3d3c3e3c52f823411a30c7ab7f3830be64a8b084c09e66dbd6d3038511ed6f2d218a5b4f388eb7f107e25b4b0afc68d17a22bfb586e8b3f8438726bfb5a6bed37ac2beb5b8f4ffda3ffeb1341d0cfd5b76e06dd8b7d6b8d069265814c7c004b1bd58b59e3adf5addb51fc280c733bbf5843fd204f1807730fa5b4b5aa13b9d07ece1e1017bfcf7b7d6b0b2e943d6341fe7bfbfb5d8e64db57ffc63136d5633a7f5f45fd837ec1bf6df68f1bcd9c717e7fee2dc5f9cfbff3bcefdadb5445ff9576bb4702b3778151bfff7b79663aecdaccb4bf303f489fc25a787d117ae4984efb6b93683d8fdd33afce998ebd99fcb8f19fcfad76d7171f5c94c8ce0449bc8c4489bc07e4c7ebc9bc1ea8600a1da742e40f04c809024dee9dc254092eede2b404eacbe43e0ed07a28dff3602a48a7ace64ca895ad25f2f65c9496c9c4445427aa9a44857a82c2a8a9221695d29114e3bf1ffc4e6bcbea3f29ddb9a91eb85a11a4b2b9cba4228790ed75f5984bdd6a3c5dae0fa9831c67776a810a6aa904284d3acdf750596799c91726069cc4ad7e44060c59dae8998a9f65783d00b74550e6c5f700779fb6ec7e00242e0a495ae49c7d1589c5b0485192a8509dc8d6fcedb2f020bdf4457c76699b9c5f58ff611f3f5905e19acf0c78c5c6d642e384cb93e66f2383d62e9ddc88db3675ca1b7df8da2f5a3c5291b81a5de2caebf192c02cc50f1e3bb86b94618ac8cb1777c1933a4a9ee5702270602276d6d2e080c96595a91bc15f8fc79de0e69dce625fa7dcc8416b15f189a10db8785eb68d2d20a6dd7e2fa94452a98d0dbcf2d420ee0db838059e96a7b33497f7bd7b0ce8090b60637fde3a2bffcfad15475377b46e6959dc50573986b9bcce7f4c6f864dce6f64b9de8638686faba70346665aa30dfd3ea31f3d287a9313b437deb0cc8ee1fc5efccc875608532fdfe7636af0b636971ca51e0e850e0e5d81833475dc50381a33c4b9dba0ee7790ecb78162f025d5002a7b44d4dc204de595adcced5c3a96b72fd8dc1ed61aefdd998d959c47ea9930b77c425f303eb3be5f69ee5533d8bd8af4c9867827a4bda05f4fb64b7d5097a614cda55fd5e1a7e377b5e7438655d3387a1458a6b81c30387f3b6460ff36d8e3e3aace0eaaa84992abd198d852dcca7aeeeb7c681f667637c016b02eb6885c6d20e31bfb48e856bc4051b9b57308197299b9bd242e82d0d4e0e2c5f70adb0bf362698af93d2c10868cc22d6e8be13f6578e3aa505a09f8534992e8c29fa2dc269c1ef6e7fe65b36cc47402f0df6ec3b29cd8da732fd3e16dcc1b1fd52f50da1b7f71d4dc62ca2ed3a7cb043ef2415df52034cf00b7b94943d610eeb4685a626ae472c9d3d575aa7e49dbbad11d2076b72f9cd19b93ee82a1519e3ee4626c5ada331b077ebfa160f0b7d3038e5e8b0f8c2d4041fedc505ec23f9d9d444443b664fb8ec0b7b83cf45689ed6a6067c70e80bec15dae2d78f864a2dcafd651e6784b2b209a5bca7d84a7e19d891b8b5a3a19bf1bceafe763b768f7ab50eccd252fb91a10d5d23a4712b7c731daee3dabcb8d509e5681fba0f2fbdddd6ee5dcef388a517a6a65fddfbe536d76481b1b5c8822c884ef3d0fa347d716dbaf7a989f903997648e218fd776887f803f919da61d2dd2fedf04b3bfc14ed30df0ecd944287eb477f872288be5352fe806106a1c08230c17716a720a13c5195a34d00b3cbbf71baf8f52362cadc1e293d42c47876242f7575b7d1097a6d73fbc0e1822d08c6c142dac2380c4d06e5423234e9a8ab4e50256466e49a34353916586aa86b12360d95a3a3eeb12b026957183330756ca089814d2a2b275360914246bd82f2a41001663e2b6d87c7e9579f799c1da891a3c9738bc083770d4fe68153f6027bba2fb0e2c15041e95216b03e029f28ada9b05b5a611fcdd76081da68d07f59f3b0e47dcac6a8529c4f820afd7ecffc3a2ab5b00ea5b53f09135eaa9cd711df757562efd9e4901642716b716f99c05eda01e3399c870476a5e0e2bb681ec72aee1984c299ea3eb03169eba814ac8b6ba2fb25c57765a80628645b81670e1621053639dc0cfc4b25321f2bac0bdb0525fee8f4d3f5ab9a970a6305142287c50f8626e1169f8d0b94e7e966020aa0e22c1dceebcf78997e9fc42e8c65422a18d029d076a2c832813dee9eee8fbbbe1d2a1ba019831541c9c7ac03a297674303a595f212fabea4ab5ca8d7cce7b9d02f5c1da4a48d99b5a1c9b145bcd142e8043714654c57ed5ae57846aec118db59c8e0ecfa36d73f385ce0592c353f29fc6d50b28a06c0c450f7810eb44d5095fd1c10c6d60eb1cbb52cef471f8c38614ec1bc8948719ac2ba2868ce90a156450fa0644e28ded48c40e0024ce09e5de05903bfbd19b1743cac5062479cbc740a8a296aaf4a5b2b923d53a54051bafc0e5bc937613ed70e297a36cf04b68f6f1d4dbef67c3acfab8559d81f88eec6dd87d1b85bda37e87e153f2d5c3372b5d0097a6571c111d65ce8c574fdfab43753425919aa84196a254d9d2e7efd6887fd8d414c5d91973de3b073452ed9c7e937a6baea1c135acef7f74bedfbaecdbde61c74307835a19636cfaf118768cab5540533397a311a8b3b8b94b03347c5adab63aad4ced1de72e3c9e482850106bccfa0fd0cb2d126c55023e5ad49a0fb84aeee7130b460eeef31082bafde7e69450ceeb00c36d3986034166353951786daf653e39d1642b40fde91d1382e1b8a2217ac413e22b93585f6ca7583f1c7e70168dd1778c3b37805fe7e9c1d644c579d5ff5bd32cf297fb7f01bf543dfd7230573387a931be79184eb21be44fc33977d12a68f915eb0001e01f24b2561ce337ac79dab8674f555cf0fcfafde6e0b7ada557aaa346a9bbfe3c67e747552393a1cbdbeb1af2e6551c46c6df2adc97efed93db879c3a5b11c24fbf35dc37c81ed3498db9fdb7783c58fefbb464ea8224f3f1f6fe1b78c9f239d3290028b53e60ea72cee988785a9e9059a4ffa5696870c66685e73de7ac9d392e76b7989e00b3c3872a4c0e4e895c12987d158ec399a84590406ba60c3b134d82fd5723ced2f8e1ce2ba1a6c10ed673ca2f9b8335e90d3f21567dc5457edce8010718797b756836f543985ceaf114b23076add1cd4bea3964f5cb12d236563701dd0072bf5be8b39069d6e5c9ae35afe304af4b8834128d8682cbeebe1deb3c2952f70dec150755af0e91074a40159bb17afcf1317ac8d0373b4c321bc0b337965adfb749deef9dd2682076382af744d8c8cfa777706c473b3b93de7db676daee8e2a145d018cc5d32efcb935de8fee77f7e92432f9cadbdd8f973350b66f6da8fa33b5c7bd58f664e3ea2dd7efc3b9c7cd4e738f992ee7e39f9be9c7c3fefe4abde18b7dd7d998933d032f10eae043167b95758456ca8fdc58955c4952a9f4ed01b8753360ecbc0d9a66771743450d3f337163f16cc47bff27cb4c87e35a96791caa6d21d536feec319d09baec9f115337f375a18a0762fc00554f74d213587040e54b769721e5c3c7339d6b8ad38773f5a785be7c09016a9ac0c96a12c72ea5a5cf06124ee9c7c6e043ed83a9a90bab294e3e8b8db9abd2ab6ffe367994575213bc72eaf7fbd08fa1bd5e973356aa56b3037f2f1baca04ae3150b50d0fdcb5d9597cea56783ebf9f8bc8ca0bd413d1b3422748dcc0c9f7af3f93adf9ded3436555a2e97bd4f648f40c2ed816e6351dffdb99b94a4df3fda9818b2075a127aee3d36fe32e983da1ae292be779efcd541a079ad6092fd08975609c5486e6e62eda2b994ad4ed24e7f3d4459f462c9d60246ecf4567403498b773f5a6faea585cdf37d45d1d7d5d33290eba2a2fadc3c964caf11e057acecdd7466e192633392ad65a0e66fc5bc13494709b94b7964217d6fcc284799ca98a6747d87646e0604ec5efe3ee5ae0d76b50814df42e851e90eb85ae05b75d47bdddb6c97c95ddf7ded28adefc51ba9fed635cc0730481ae89a7b5f74ff461a92013bc6bbf2153ae80f938bd9790bd59015753c0df9086aa7c543e433a81a1ae2943ed1faadf09eef2ca77cea11f76d83ff5755ecde3abd4ecf3eb8a2cfd19177889df144ca2a34904bb815ac4080d6b4da29a7d303739656e12e276c69ef6824dac97769f2e608d946391cf832cbfc2d7d1f7a6f00e964166967d603093eb63029864636609f2c4012c94ba43f25557a9adc379e02a40ed59ffc778c7884bcd613f1fdfd151c5b509fbeb346f73877bf6470b91129e6574a4a870fd0f436d5f35a7af98c61d7dccc476a884754726d5df4e79c09841aef0114b7bb7de6d143164877357237334c0ed12ca9e1d2d7c84e90adfdc12eeecb8dbea95471acfaec98b8131c7fc0151a2a7ad4d046b53dd7bb6dfec7bd3bc3d23a6f8956ab3f6938f1dafec3ddc0ae5ade0773755cf9debb386261e2c523c0a5c7a74e9c6757cf23666b1708df8ee1f93b093eb7d0297e9a3a5a34bf7d577ab75d8941eec1e601f15cf26a6ae9dbaef04569c3870dcc6328c41f497e00ad4d1bba59da14a4b235ab8bada7675758f199ab8d13579e98453d7e08203b8ff2c52a46afade1910f2d229ed878ba3e4729b2beb6a84f4d6e19443b61685e3c99f753d389b65e0dbe67ab66ae06c386f7c722f3c3e34712fd04f18fe84d17fb5e9f6e303d621f0bbdd0bc4e7b8171eef0d51221f1ef210250aa31e1e1fdb548d7ba1d8341f68b57ba1aee9977be1b7772f9c6f853a874259c9c819e995cd6e728a6714367bf579013a2708ace8cd3d136e9500e0b722de67810736290133ad509aae619a8a18a1b67f2f1629017567b811a95e61e34bca1a526c72bc107f43512b9c9f97be35d9afccc40043d8984b1c10f6007d1c2c68dce1a42d32dcb4a4cf86f6ec9eee03ce470c90c2a7bef97036098a5822d0c0e80990908033ff0b5c8b961b6760cc2d47054068e5587abbad536574541a7917c225139e3f8029ca0c656aebb0ddcd94903c2ba482f3775f80e07918abeb9aaa1c5840cb441fd631a553f9982ab121388d52b0fb1ce847d7441438008aaca9092ef2f96bc31502d4ab6d00d14340426ca8d2874d2a6bfbc06c0d1f1463fa084e22a420f352ac6b10b8f09c3c3fae04c3232548e082b014c87049870024e6d27e3ece887d60f925e0352810d80b3f5c22e03f9c9baa1406c10556aeb8b7dd37c2db5aa414a48101738b6428a4b470ebc0d0baae11895b6bcc04ba2ac5022f050ebb4b950fd4fe609c050a582a4d18ea9bab6bd2d2e0a69b3ac5a3ee4c58e0fa73b4ff2f95d57a65adc999e92701f7ef02b967747a9013438c94702bc5f2bc258e9ba3a3e2b53ca69aa716e6648c47883eeac1e6a70bb05073ea44278943967e3f33d80ad83ba05fe04513535b8252eeea5100ebd01910eea16e7e7ec88193b63f774eda617f61aa9d82630568927907cc45eaf84ae6af78fe0eca71e614636f384d0ad784533c509a756d88f6f26ccc787638bdb2ae29edb1f44a57c5c0eab56fb62dd0cec254e5f680ed2e92f94e68225f936bf450b8601eec5059a1a02a16bd237d1f353654c07b0684a10026d37545b6e448bfd9d794661a8fed9a93f987d6315a3f8abc1858fd94ffdf8de3610686aaec84dede1378d9b301ffd8c37c8d1057ba4a7d087ec1f0467306e7bbf2c150f7d91cf9a3f33e4caa0dec9f71c8d5cbca6e0363ed8e77dc873f902cd20884e712ffbddf81827480fdb152d78280b805b515b8d237f26098577f512d1bcff1ae0d0f52cafa277dd4897e2576e36f9225cd3166a97e3708a4b9ae521b84cd2c3967825ca742f858c09db3d404c6678580f5c13953a516c2733f025cc5c9c992dd2fcebf94bfab0ebb56b9ceb0c74ed88bd361d8255db1ba2a7d24fa457e8899e3344b0eacdf03f330fbf8883f1af81c0aed0a6886dcdb403e9c7b1bb03f71fc4f9c9860d8138e3d91d89d3e06fc81aaf2311058fb5e08c37d2e06aa8391b933e0817e844f3eb62f5c0c0f1885937487c4b2a658a56ba1f4b63686d3f84387687fb916fe37b8160a145fe755500e76481f7e4d2452faee3207efc0513bb272919b570034e4223dc23c47fb7720a4b4d8b6e81940edcfde6df79065de47619e3ef56c681011b02f5ba6bf07d7f22367b66fc0b50aed72ae45e19d264ed2ce13493db5b1bfe80ef600599f3a7733b0874f7192a2eedee52425f036993949698ca23a0f8f0f54b59394c0db3907cb075aed24ad6bfac5c97e7b4e56d805759c2cd8189cd2fe359c0cfc52d3351cc65a447f71a693228ea6105e6023db540cce0146028f0e7802a3d701bb7c7fa697ed460bdc33e13089540ec698593aac734c7d379e1e495b8b573063ccecc0e6417a59f2db2938bd70882400de39fdbec5d1c85f60a9fdb9c9051b63bc732d1274d7d49f84e2adc4c0e268727079e8deccff73e627ae39e4aec0e64b473b34de1d8e8683df73c04d6f8a8b9c827eab8f0f8103bd0b5b20077ae4eb886c8f140f7fb0885da10ff2d20e0190451f666776ed60b15fda000808ed4d76b888ec841a70c2f921a60dbe4e1fdfda3cf8b8a97a405533fbfdc25e4f6d72df22ddd3782231d049e5601301518a7708e9cd357f19ba9e838d4eec71839b160e48838dc04bb8ee43d20778b7bcb5c320b077d5efb9e96769345646d2356f6969ca5ae0e4c0e6e5b8829e368e8afb081899af25eecdfaf4c2d018cc0420851a6c46636157044d0cd99bfe898e4d329e555847d837105bfcd2eb82dd7b005fb6c0ed9790a0e305b40a685fbbb637fd0b8dfc6fb2e6cd2d5e5918e34b3a7038a5ed14802346d6561b56009a0400fee0962a6e743558d9879dfbe25398a57557425f3ee89a140f0ef155c0e0150048adef23d9a77701430283083645fbb6d6475de6d9be1dd2a44d28e75ae7e94a7cac23db67e626476f6d62eaea63e644dfd1e2aaef14c6726dadb273b20917a0331ce34035f6e7954020473a1dcb94060db8fc3e11ce41f273a54120c516e908098f057e6964fc96b6082ad75a85793bb4b8fec19eaf5c45ab897538bf7afba3cdf53735c0df21242132552a1a14cf80aeed89c235e2a51d02459c83dd7caffc6e00d270ca3590f072c4d2a89f75ebd6c8ff5601f81911607938476382578f375d032ba43181a526264793e314b0fbae616106de1da9f426f78d8d85cb3147356b3905df96724a72a3a0df4f6bca65beaf2c8996580764ca78f4ae9eb64be7a5c53db1b672fabb7e8e513c7b29d0ec73fe2e901f55c97dceaf1e9cb3dea6a14bdf56e7bb1e895b8794829106be3e03b7b8cc5f9ce67c60df3653c2db3a583a77114e8b990f710e40cf9c4ef27511d9eab5cfc1b9dac98f0afb558e9455ee932e9dd736d873b76411bf7efcb43dd118bc97c959a03fa9c2a75a9c672ce7e102800ac3e955bff4f9057ac4f5b5bf9af4e9fcea58dce272bf257eed5cff3355d0d341b786f1415cdf7ef953bca4eeccfdccfb5099b0ebf7f050cc63ab817f226f957927da7833ef04fd84614f14f557e7b1ddc1690aa3eef44e5024fe19de89367ec53b813f54ba27c8078acadc139d0ede26b1c7c71af744a96936d26af7445dd32ff7c46fef9ec837419d7362b9d57f4dc4d73f2d028c2040513fafcd90f6076ab0488de8178b10f20c8406d7c7f4310eedd736297b0eaf1c8beda6e93dc4bcddf8a7a3be504416120c3076ea9404e6d20152064f4480c47500b45269345fcc45d83f083e7374f86005069043040b8773531016faf69ba1897370e4bc6b5820ccdb55864ae5bd4a43ff4269856fe00b8ba082745ed07cca1cbd49ddd48910f673b006fa5de168881a03700702fa2010508e42960283dd35fd7608c69ca166802ce80fc525f7d2c444a048ccdbae11f69716a72c58ff8a4258bdde806ec61c6df85061109468aa3807059aea0c48346f7fdcf81e7a1772628410d198b8ffc740d70b09b7a3d4f0b9009a753b8eba3b9f976d96d4eb1ccc780ef013b91c94b71258798e0c0d364de883be9dfd9e8cc5e216d0af8555482c9524184b9f4d0e6713a0602690cb738e5bb7fbbfb14800bf2995fba0a86827f303111cc11c81534e2045b4fe497fab0ee62f9594332300adc709910feb421f6ecd674a83c9314cf493fda94b14e4335e1ad50a07daffb408f198241340df16651ea707e0840ba5c02a1dd8c3ef381cd4cf0d75e79fa260e13e25da18f0ece068a2241c786a489fd6b144bbe9dafd2d119bf781437ae9f8ea14efca243699f302d153067acd8c98b7cb719f0ce5821c4993f89d9ca6677de3609e4f09dcb2f9073999ae41c66b5ce104144e12b3a999c1962bdf7f2861103807267d9f7c69fc562bea351143453a41113609fd87c1c6d4a4d8508bfd45063fe2e5634d02a0c6d2263c046ecdd63fa7f1c98f832a46bc34b7c360e794f9456c44ca26a57ff7855db8b30ba72c43e8900405e90afda5152a07a187b90647cf1d1507c0f7c6d694c08e822cfa6d6bfb6e343801c237a04f8887c5e3052d22e0ab574c04b241fa8506e0530af6e2690d27182db0cec151a90d18cfe281e9bcb35dcf21e0c08626070b8500d93c2568dc0ea5e065cc5c7e0f5db0c6326e87edcbdf79ece5f29e011150478b540e3a31754763666368b62b6b06c890497a3f160f29ef38d8eecb81b93626d160bb1f026b6f8de41dc7cab9e1a5ad03fac698f20c4eee3b20c79ed16142006bf0c23a4053d180654247a5d268f06e6c8cabd630b91cd071d0bee9d2c2f37232c1da1b88aeb289696c8c298838f75e26ab97aa67d379d8653a10d000aceb2090b7408742afe34e54002752a1c5d15bdbf7fe99eb2fe3ee3f85def36ec876b7c9f7a9a945ca9ec52edc57bfbb1ff6ba8fac1b5f7e93ed96e86e10f5714313a92cd991450ab178a07c5d13a2973195e86e638a3455191243d20224e303be1dbdb92f876e248eedcb79465779bdaae9f9a49f8987c50639cc9f731a07ba388db76e0eb96232256aefa8ca61a664d1fcf4e185b5d15e19a806c00d0283b59703741025f7f243c46cde17699bde4f7e4b03e0998cdb872bdf0ad236c79f1d57a67bbdc17aa164506fb90dd2a553dae8391a4a9e8abdeee2eaef25b458d0cbba74f33e9c74b0ca7d07ef678befa696106c602fbce964ba7347e36e24d6cdc333ed9be00862a90f430b8e2fc97846a77701cf621edfc78b8d4dd0078775979095db5e289e4eb8655e7cd22597025ff3bd027f06e7d468cc2c2c42c22d15f44e6a6e870a44fcf62d4e59c37cbeb0ceca1cdbcbd71afe80dec9ed2be920d751c788773d082ce27388e64f8951252b6fa76035730b7deeba793b6db8d15195036af146784b8b7b8b2d6e51dbc7f7f1a2965724fc2dd35f87b0279beeeb5c47be4213799b41186c801f8dfbb234013ec3da515d7f531a78cd9e15f87d47e8af9d97310507ca7d08b678e10cdc0ac1294c6f04d65bdb84fd7bacb926d58e0bc65698eb4f5ec3cc3eb8675f67cfd48f35b33f7e60fd521b64e8bef33b57c5b12bfb163f1a877a195ce05f2b730ce393b73a99eccf41281d0cb58f1953906f48b78a4562bfaca7c9742f7195f208e8e8ea73a7f9e8ef6c4ed918cf526caafbc50bb75fd6eb01d8b2962f27fadc3f8b36bbc03ed3c033ed48d958211ce2dca4ed4cd70220011cacc6b57db94f7667b61ac89eec6013f60f66474a50b7fef7c9b793cd073a426ebf81be1cf6573631ada5fb025d6436cd3dfc2b7b26ba31574bc3ef82bd7284cc3a02ab870257b69546899e3dcf02290df52daad7a50b179f25502fcd6da8c361d6f53ed51ce255dce7b1f2dee6b1e50b9bef0df00d956d9cff9908f6a5f9b1f6cd1be71d59a3ffa9d29a8ff9a107f1555af3abb4e65769cdafd29a5fa5356b4a6be61cfd134b6ca6affc6ec741e0aff234905765c659dbc249799383f2c7a776e709c7ff22688aa6310c7bb8f7a09cf89454aaa8b7f7880c8a26683c1319244676daed0e41548a8c52d37c9c9522a3b6e9d731f96f7f4c5eb3776a0ecd0b4a5fa9420620fce72992b858c6b2d0a63ad526231a3ee3a50829d7227414452ef012209b922c04e3cb128bf631359a7afb3495ea5b8eb62df52b4b0bc9528ca3c9db697a38f3aee15b2b946274580707d49cb7b40fc889b01ee411a5ee52281c6a1415683868ccd24c41d686d739e69e658a7fa949a7999476246a1077bd7d8a4e6f97915c01b3b5b8603ebb4c7599968a5cb8225ffc7e9e62b698e2120e4837590594114b67df3a1b5baebc6707a3d91cffc1ce0be8e9cf2b7977a2c17019ccd64d1978b971c6c1099cfa95f1a39d4f891fc5a93bf9f657fce857fc288a1faddb2b8d18f6294d54827caa08af3aa57c3acff5778ef2016fa579863a386f63aa1294a0fb6354827b1ac0f0bd810aa7b7d2c74095967628ada004c088a597a3f9d9696bb9bcc2799fd75939bb14e582d015e9f33982e8f33d12dfdfe3783dfb68c0a88a0d33264563bf9247d19fc1a368ec8b457db1a89f615145c2bfcd9e7227e229faf3749d01302a41192ced5b844c25dbdec3ac5fa3a37833d369b4ed8b0db36dff889178bef109ecc73c924d58c023f619e665d2ddfb70d85fa53abe4a75944a75546d9cdbdc20cf9309dc60bef3e467baa7f4833779ccc40e874381818ec9cb6b8b8504f154341a8b41a18043073ddf6b97e06500edd2c7ccdc44301d540349519ef5f5eb591eb8112f2f1d6e1f20e86e90fc1bf2b8bdb390bcd9d9c0bb6d8825e102cce2a6e567210e355cb936144180581d80d120981a4aa29f41f362879777f631de0e480903189a0dd03c96f2a10e914d42dc273337b836aae50bb0402b7a5beb9a13d8a8366a071bccedcd90a5e7363984c2f21b87c53d87508e00b31884f8d2f229f8ed02b638e2256ca6ee2b8a1224f35a7b9cc8257d30c23e24b2a65e7d48d0ed6d2df579253cf70f28869f650443dd13904354279e6b8eb920268ec2069ab49c85d307a1f7dcb679b7ae2d2488dfdaf3d8158ed25cea19a131bfec5f050c72039008a837560bdfca20e9084a35adfbfe4e57e50540e45efdee61c833edbaa34d7464cce29ec50591358f5d9d8064eecaa1aebda5f60f104ffbea777702d7c71dae73d907fe328e69904020ebfa9bc0b57ab13b9c7477e2e4fa5aa25c9a93d81d8edb073b54d6757dd5c3fedc249c03c0a88ddef5b5c88f7839faf0ea77e746688475efb5b9fec28078dc63ec0ed9367ebd0ff401f801ccd7ad36030d1511c1a13083a4d5af991ed2ed840e933ee4cfabe276560b05c32127de12d678c8321e7895845e77236962604d9ad126406e079114001ccfe0fa8759fd7afac01b0c524449d16fcda74ed02b8b141e047678d48f6f8411eab5efb54811425a1e849eb89bb1cc11bc4806db9d0f8921265df946ca6b8e90db12e086d56b5671dc5c2ef6b0cebc67a816632dcf91973609b942160f0227ae2c42fab8b59f06aab84531ed93db34753696dd600e393bb066fb30923e4cadbb2ee7c7dbb983ca3ca34ce619ad5b8f85a949107a047cd5871c21661d2c8e6700c20a39301e80ee867305faec0e7bcd68af989764a0e6f9346af9247852618e101c7412bb5678059259ee5bdd58115471a0f657a6ea6c5efda45679431aaa897f6656e69869c2c37783b98068a2b27f95fcb6988f045f65f1c6c2292eb7eebb00ddf59c5eecda611099fc5bb3ef4550f020dd2391b2b10fb5ef477d1968893c7ff5990dc0691bf1da3adec60364f56dadabfba541b41f046e1f18d10ff7fb57ae49d51efb497abbc247eee0eda7c22037fbb586ba97a6da5eeb210d3551d7f5fc349757b97c3af58d690b3cc8db6021b0d2c2e01aaf17e44b59e99ab03635c8c934ace50106171c6d6eefcd26b16b84fba05e361a2b437393daedbdd84dfe5fd77609855ea008c73a0f9dec5dd39b2af9c1d154f125e4cf18a801067cb06e0c790862ef0d179bf1af5a995ea943b16daa7aed9af5db54f1e893e4607ba0394b8397e3341ca09627a0b6aa1798aa13039f1a1ebbbb2bba7846afc0db5d5d5330eb70dabb4dc70df955072abd43a780513dcd9debd316a784ce2d9dccc77d8b84dcabd883d0ebdf23cbe7bada5e5b248360c3f6e1f791d7e04877d81c92e8ce6ee832999c3635196c9179c375498a01a5617d4df880a9522b53a582817a9d1e0bfb8e1227cdc60cfaf16be86d1d088faadd13126e4712b20d6d8e5e0254d76e641b32de0d1b2a951732e8e7075d43e15c0f00cfb6c269137a585a21f800a66b3ddc6f7562d58806d3704580521eacb49eb31dd2f5f638df6c8f37d3d77fc61e12b77608731fbbc3a8288b1adb206b94e75c538eaf7e17bfc27fce7406a5f9fe0e8b90e3dbb6b3742f1facdbfb1c4d189a087a20f9ea331994b7c9f8aae7a17e6c006fdf400a011bfc44aae3996adb9ddddc93c2aedaa751c923820cb63fbba19fbcfadda3cdbbae4dd02b537d7305e2f938ecd5eee3dc57a04f86bbc63e08c2085f559d928e4df67c77a3873414a0bde19372725988daf79abd3bc9cd375d97c23af9463481fc07bf42964bbdeeae390d25057e076a699f2c2dd5fe71ff59d57722d9330e38692721128766fa4eb0b1c0c61be3b572b66a4d1cae1f0d3407fca83b0843cc8b96b2549ab7102f86037c92ccabdc3798aeda894e0c21943e35cf0aa242e80ce4c92ccf3b85e44f03be4f48e31f9bf741981559ec6e52b953a2dd2464edcd4d7ffb711aa8f4f7d6e865973c700fbc0974cde184d9cdd82ed6989eef1d6f78d3963ff9558fdda0e1ba97c114fc6d5fe9907ba686bdeef1b58e16cf64f9dd32f054df0bf6e2522756ee200ce610ae3e6bf64dec0e5d3f2be25bf6f5e5f7e5a3c0ffa41fa2de8ebe533f480b43970bb3bb29d2ad116fa8d5f53ec5df51ddbf419237ade9daed0673aff99c1453fedce645576593c9294b83f0b0ab34fb937ee95c57fda5beff86fadcadf9645198bd3b6b305fd7e6f59e73992a19590af53c2e034b63b0d9b891cd5be36bace403e574359fb3e7cf644a77f3ca57fb6a2ec7cdc4439f5a40fdb5812a2fafd803f7c8adbb7c8c0e411f0c16f721a4dc04da831cd1ea0ac6be6a34a7bcece9e13e188408359df8c1d906b2eb9e3341b6ddbe662bdee79f496d441f74aefd4ad79823f828d07915d7dfa150ce067bc8e1fabec54d3749adaec4172ff0723c60194c5745485781d27625e71a6faec3d5f3c6dbbed49bf66b254fff156b0ee9ca12bf4e8a67e8edb6257c436f9f219fea53b8859002a13a07f33968b3a81b55b5cfc24121e5ce2080ba901ea496393869ada4b3b43e57fa740ab13eb35dcbe9eeeabe7d5633aa7a9dba77d7bc82905e433b015f6f5d7f53edab1fc80f7b02f6a21cde28f588886535adea6a55555ee7f9503f21076c31ddd3454aaa39552ede3d2fa463aabcaa6ba4de4827589992ea178085978119fde9af67e1aa0172f0bc71861e7c00d07e93d834f2f18922ff22318aec60ed07e24ef020457c4a1d6ed4dbfb00c40f8fa714ae54a783637487ae840e42d3bc184d3ece1a20714dd32fe8e0ff1ae8e0f986b80d1f84630acbc7972863c121897548d58782f829aa4f27e82004a0e9ea5b89258e78b9aafc40c7e1dc3f645047260007744bcfa42207337bd433c043462c8d99654862f6ee7814a419caaebf0732bb6286266eaebe6b210770b46a857d647257b4ed0cc8d378d0ef657502d3437a319bb4eb82d8aad87bc7e1cb30cac2d571b837147c86e66a9c649e558800339f95769a9913cbbf7f7ef5f698911411930c4df65056bb3135355528752e7b96062560e983a5f63181150ec35e97184cbafbc1a47b107acff8ebbc8bbdcebbb810fdec779834439d82624c604cb7c63b01c8a98a32016f142e8058154c57f1dd1432f171cac16adea7677856e68203643b1d90f2a28a1e8beb7a2ed63231ee70e2d6e01728982fdd13bf0228bf899cb881b03b35cbc41c853ffe1d187912ff148c3ceaed3d62ee0b21ff8590af46c89fb6c26d010778500b09b66516e1fc061b1db0ca0afc464a55017ec9397fa60337b487926fd1604b6067f6e045eda3cf4eb37b877d0375fbe14c67a16b2225cc29058d9583d4b16f9729772fea3bdc351747083a847bffebe683cf717aee0ba4630f1888f65e5aa183e60ba2d2a18656566739132685fac51541959f5ae3e263b6da84b33f57b360669fe25baf0a91ea47328182931476676dce0e8175e8c73b25ca03f6298653d2ddbfa9366732d046b539f3a65f22e5b71729d51ba24eaca06c736b635c3a5ef2739df14ae6b73c4c2bcdfc76d3cd87b2e5e1417532908cc5b9fb5120c7e645194dcfb30f8c6fa8cae694b1dbc12101871e2ac0be83aa82c576312109c03ad20a06a04b6719d1ab2a208c826c5e184fe021d3b5b1b448e55864a10ed7714d42096c3687f925f7d0f1a6ec19e4702570f441e053d71bf7ec5aa14216df01501d3dcd846d938a3f508dad056516fd7645058f2699f73b55aecb5f2e9ad2793b2f0b991d9315ca551521f5e7ae50796967a5bc6a4a8c6619f1a7a1327734f1686a4600365183f1012d016c70e984d3627953c851e00e8ed595219a8fab5ceeb23c2edc9b3d27a5337f6eee9476521254399eaf4f5e9252936ae7eeac9244aec60c3411b7a261adcbbb58d9620a610955c96b0ad788bb51aa2ba5c94ccdc85de779f29d93ebdc8e2e691112f9a4b6b09fefd30ab5e59e526e45de50787f5e25e5da78d32cf3688f033f4ade01471a72603cf7b1c102f62dee396cd71ffacc0b94581ccebb3b0920606c772f4de29d04d514c63b37f7934c2508fbc220f4348598b9c9d197777cb9e65eef41929ec5257ddd72935754fa48e972379a5fad7491ef2fa75c3618785afd31c4252f2caae997bcefa48256e405b9565adad85a64a1b4f4e7f93a567ee807e647030db5d432534c49a2d34831a59f30f289c0fe22c9c7870e4510f7178d6f7f86629a74f72ec594ec60b90a49b671d4fb6a977eb96936d06ac5b4aee99762fadb2ba6a57d50a78fe6a149bf4607cd43b484ca4a3b6fc53c490bf0fd4a9e5179a4798de794e0765039ae5784034e54e56813fd08aa5415fb908d35395a4fb340f35295bfbd4a9e27f0072d7bae5e9e5f54872a7eeb54198a43ef4bcaa96aa626c7b2e6813c4a2bb9e5d51ff292bb86f6ec9eeec35a8a0192e3ea1bac25d28d917ce3008e1b6cd2f08a24299d82a004fd192f9f9567dd2f47059e5d39963ad975eeffbe2203477cb7402f69eeab739df4a2ca599a8c8fa52610da6485e7c9ff6a2b6645867656b69f2884356ac36d814e0bfd825030d9b342e9aa0d756f39f389da6f9b2a8e5b50765b35420342df422960fd7b600f146ea9626017600f1032e470fd8d4e4c7d81c39730cee1184ac1f7dba3b14048177af2697cb57a723aae2b65cf7fd65536365403a07af15588c4b90bf10a6d25bc85d958e41b248a84ecf91b433dabb4968ecbee516303c1ce9485c0f53706cb1c0c141284ef009a6213705fdada5c005550b686cf602637756da20fb6ead1e4684ce0fa0763cc84081ac832fe0c65bfc777ba2642783694574faa0f690ce6a8fd95c0799e4db82801a7a1f6e726cb2c2db51f19e34b3d0cc1237829482a14503c0a73ade6511dbb4771691f1e67c43eb0fcee468674061a0355fb60deb1177eb814383a14f89cefa7faae721cf84c2f2b090fe7b27949651e5cbbebc0d0baae11895b6bcc0450fd03ec6a87ddb9ba4a2d001a668cc16e079898b3b4b89dab8753d752690274745d93960637dd5cd8fe69df93502c666d68726c116f5005736b938df7ec25bda4effd5becf0e6df82d40318aae630fed1bd583a1bbe460fa78b5f3f8a5cb0107a312db094289fed97f30b12aeeae03b4a2b40bec1bf6f3c730e6bbb0ccdaee599a7ab7799d4d6e2140ffc07757ce7269f8c1044795b58830f4355761a99cc095ad3dc8612aeadefd9c50c0db54f186306fc6498c92914e203d7d6a1212f2d5ce7474a67a1cc786565cc5bfa078c7bc6ee5c914bc2a15142dd05fc1b3f56c9d3fa0b55fc3c186a52f63ca12f48e1a4ac070b84f7d84c4365057a87132a491bf6ade40b6c36d7d9da2632a87e3ece2af736bba01222d8c8a023d04240fb16d7df9807c13579313010df4163d9c2be80545359df1bcffdf9d5dba3702f9b14e7e8988bf3704b152ede3f22412e4d694b5516a6a6ac7fe47b17d5209b5d17bc0c858daa9d826f0ce401f30e7d3641b69ef1331120e259e5ccb190eafb4c608fbb0b5395db022bfb202bde357c6e72cadc2494457ec435ee6eefef3333807d2df0e7dfeec6a88f9076614c6928b488c7e919b44be87f334e4300de356c79173db274c2afeaf0246c13fdb8fa2afb5b7ea44db56e54b8aefa034f3a30846f0b7f4c408e8f9949b6df84e74437b08f57fd80755556038b9782aa7918f1dd3f2661a76037a6ba119c4f84346e856feeabbfa8d6932eaae9363b662ea715a24f3c8544a90aaa78cadfa357dc51f136f7d3a38abf14aac22af085b457271b0f55c94e6cde826e3c057fc09bfb86c299a913b438bd3f3dad8798ac8794d3579dde7eee434cc7e4091c8446e950b5780e953b2d8e8e2a20c93f5c31f64a75f91052fe9daacb7fea513a204aa25903f764b161e69da4898670e336f184537f9144e7e1b14ddf8b3626b14ff14da2cedee39aa43a34f998b926e90ed17e243192ac744d42d31c989c0db32e6d714dd32fd7e46fef9a2cee813acfa402f59137c658ccb9fa959d1d1b6a7f71dad971a55592273f621904ceb2ce2d8b2b9ecf7246770102620a5e4740b12699d1cb522df758f5ad483e98aa52a9618fd220b382665b0caeaa9240d97368dc75bfa71c17b31b688e099a99064f8587c638af41479f5f095a3a45eb4e01ed1c5fed4f2a41119abbec99bcfd2c781eaf209f07e4f5f918f1f265f0d6154b69b050360e2f2e0ded86857d1b955d8f5a26a1d6731f0100afa08d2baf112f2f409a0f3244bb7603e17c03f16caa54b2f6b790d2850b10d5d7e9ebb6263a206bd7056802ed8dd26f3f55d35ef8e32deccfcd03835907e6a481645a223fec0c88e76c1dcb814c57f8c3cfd4654c84d3ac5a3a9d8ba2afd27e5fa5fdbe4afbfd1f2aedf7efff070000ffff03003d3be2fafbec0000`)))
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"github.com/markbates/pkger"
 	"html/template"
+	"imgnheap/service/models"
 	"io"
 	"log"
 	"os"
@@ -42,6 +43,7 @@ type Page struct {
 	Title          string
 	DirPath        string
 	WithStartAgain bool
+	CSRFToken      string
 }
 
 // NewPage returns a new Page object
@@ -53,30 +55,98 @@ func NewPage(title, dirPath string, withStartAgain bool) Page {
 	}
 }
 
+// NewSessionPage returns a new Page object for the provided session, whose forms carry the session's CSRF token
+func NewSessionPage(title string, sess *models.Session) Page {
+	return Page{
+		Title:          title,
+		DirPath:        sess.BaseDir,
+		WithStartAgain: true,
+		CSRFToken:      sess.CSRFToken,
+	}
+}
+
 // IndexPage represents the dataset required by the index page
 type IndexPage struct {
 	Page
+	Listing    models.DirectoryListing
+	ShowHidden bool
+}
+
+// ResumeSelectionPage represents the dataset required by the resume selection page
+type ResumeSelectionPage struct {
+	Page
+	Recursive bool
+	MaxDepth  int
+	Excludes  string
+	Runs      []models.SessionRun
 }
 
 // CatalogMethodSelectionPage represents the dataset required by the catalog method selection page
 type CatalogMethodSelectionPage struct {
 	Page
-	ImageFilesCount int
+	ImageFilesCount    int
+	Layouts            []models.DirLayoutPreset
+	DefaultLayout      string
+	CollisionSelection CollisionSelection
+	PendingUndos       int
 }
 
 // CatalogByTagPage represents the dataset required by the catalog by tag page
 type CatalogByTagPage struct {
 	Page
-	ImageFilesCount   int
-	ImageFileName     string
-	TagsWithCount     map[string]int
+	ImageFilesCount    int
+	ImageFileName      string
+	TagsWithCount      map[string]int
+	CompletionMessage  string
+	PendingUndos       int
+	CollisionSelection CollisionSelection
+	LastResult         *models.ProcessResult
+}
+
+// CollisionSelection represents the dataset required by the collision policy selection partial
+type CollisionSelection struct {
+	Policies   []models.CollisionPolicyOption
+	Selected   string
+	AutoSubmit bool
+}
+
+// ByDatePreviewPage represents the dataset required by the by date preview page
+type ByDatePreviewPage struct {
+	Page
+	Plan   *models.Plan
+	Groups []models.PlanGroup
+}
+
+// JobPage represents the dataset required by the job page
+type JobPage struct {
+	Page
+	Job models.Job
+}
+
+// DuplicatesPage represents the dataset required by the duplicates page
+type DuplicatesPage struct {
+	Page
+	CompletionMessage string
+	Results           []models.ProcessResult
+	Scanned           bool
+	Groups            []models.DuplicateGroup
+}
+
+// SimilarPage represents the dataset required by the similar images page
+type SimilarPage struct {
+	Page
 	CompletionMessage string
+	Results           []models.ProcessResult
+	Distance          int
+	Scanned           bool
+	Groups            []models.SimilarGroup
 }
 
-// ProcessedByDatePage represents the dataset required by the processed by date page
-type ProcessedByDatePage struct {
+// UndonePage represents the dataset required by the undone page
+type UndonePage struct {
 	Page
 	CompletionMessage string
+	Entries           []models.JournalEntry
 }
 
 // ErrorPage represents the dataset required by an error page